@@ -0,0 +1,22 @@
+package po
+
+import (
+	"time"
+)
+
+// Decides how often subscriptions catch up on records whose notification was lost
+type CatchUpPolicy struct {
+	Interval time.Duration // time between catch ups of a group, zero to disable them
+	Jitter   float64       // fraction of the interval added at random, from 0 to 1
+}
+
+func DefaultCatchUpPolicy() CatchUpPolicy {
+	return CatchUpPolicy{
+		Interval: 10 * time.Second,
+		Jitter:   0.2,
+	}
+}
+
+func (policy CatchUpPolicy) jitter() time.Duration {
+	return time.Duration(float64(policy.Interval) * policy.Jitter)
+}
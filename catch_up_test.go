@@ -0,0 +1,62 @@
+package po
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-po/po/internal/broker"
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store/inmemory"
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+// protocol losing every notification
+type droppingProtocol struct{}
+
+func (droppingProtocol) Register(ctx context.Context, group string, input broker.RecordHandler) (broker.RecordHandler, error) {
+	return droppingProtocol{}.Publisher(ctx, group)
+}
+
+func (droppingProtocol) Publisher(ctx context.Context, group string) (broker.RecordHandler, error) {
+	return broker.RecordHandlerFunc(func(ctx context.Context, record record.Record) (bool, error) {
+		return false, nil
+	}), nil
+}
+
+func TestPo_CatchUp(t *testing.T) {
+	// setup
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	es, err := NewFromOptions(
+		WithStore(inmemory.New()),
+		WithProtocol(droppingProtocol{}),
+		WithRegistry(testRegistry),
+		WithCatchUp(CatchUpPolicy{Interval: 10 * time.Millisecond, Jitter: 0.5}),
+	)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	received := make(chan streams.Message, 1)
+	_, err = es.Subscribe(ctx, "catch-up", streams.ParseId("lost"), HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+		received <- msg
+		return nil
+	}))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// execute
+	_, err = es.Append(ctx, streams.ParseId("lost-1"), Msg{Name: "lost"})
+
+	// verify
+	assert.True(t, errors.Is(err, ErrNotifyFailed), "notification lost")
+	select {
+	case msg := <-received:
+		assert.Equal(t, "lost-1", msg.Stream.String())
+	case <-time.After(time.Second):
+		t.Fatal("not caught up")
+	}
+}
@@ -0,0 +1,52 @@
+package po
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// time allowed for listing the subscriptions on each scrape
+const collectTimeout = 10 * time.Second
+
+// Exports the lag of every subscription listed by Subscriptions, read on each scrape.
+// Register it with the prometheus registry of the application.
+func (po *Po) SubscriptionCollector() prometheus.Collector {
+	labels := []string{"subscription", "stream"}
+	return &subscriptionCollector{
+		po: po,
+		lag: prometheus.NewDesc("po_subscription_lag",
+			"number of messages after the position of the subscription", labels, nil),
+		lagSeconds: prometheus.NewDesc("po_subscription_lag_seconds",
+			"seconds since the oldest message after the position of the subscription was appended", labels, nil),
+	}
+}
+
+type subscriptionCollector struct {
+	po         *Po
+	lag        *prometheus.Desc
+	lagSeconds *prometheus.Desc
+}
+
+func (collector *subscriptionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.lag
+	ch <- collector.lagSeconds
+}
+
+func (collector *subscriptionCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+	subscriptions, err := collector.po.Subscriptions(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(collector.lag, err)
+		return
+	}
+	for _, subscription := range subscriptions {
+		labels := []string{subscription.SubscriptionId, subscription.Stream.String()}
+		ch <- prometheus.MustNewConstMetric(collector.lag, prometheus.GaugeValue,
+			float64(subscription.Lag), labels...)
+		ch <- prometheus.MustNewConstMetric(collector.lagSeconds, prometheus.GaugeValue,
+			subscription.LagTime.Seconds(), labels...)
+	}
+}
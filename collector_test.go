@@ -0,0 +1,46 @@
+package po
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-po/po/streams"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPo_SubscriptionCollector(t *testing.T) {
+	// setup
+	ctx := context.Background()
+	es, err := NewFromOptions(WithStoreInMemory(), WithProtocolChannels(), WithRegistry(testRegistry))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = es.Subscribe(ctx, "collected", streams.ParseId("collect"), HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+		return errors.New("refused")
+	}))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = es.Append(ctx, streams.ParseId("collect-1"), Msg{Name: "a"})
+	assert.NoError(t, err)
+	registry := prometheus.NewRegistry()
+
+	// execute
+	err = registry.Register(es.SubscriptionCollector())
+	families, gatherErr := registry.Gather()
+
+	// verify
+	assert.NoError(t, err)
+	assert.NoError(t, gatherErr)
+	lags := map[string]float64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			lags[family.GetName()] = metric.GetGauge().GetValue()
+			assert.Equal(t, "collected", metric.GetLabel()[1].GetValue())
+		}
+	}
+	assert.Equal(t, float64(1), lags["po_subscription_lag"])
+	assert.True(t, lags["po_subscription_lag_seconds"] > 0, "lag seconds")
+}
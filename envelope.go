@@ -0,0 +1,56 @@
+package po
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/streams"
+	"github.com/google/uuid"
+)
+
+// Wraps a message with values to store along with it.
+// Can be appended in place of the message it wraps.
+type Envelope struct {
+	Message       interface{}      // the message to append
+	MessageId     string           // uuid identifying the message, generated when blank
+	CorrelationId string           // defaults to the correlation id carried by the context
+	CausationId   string           // defaults to the causation id carried by the context
+	Metadata      streams.Metadata // merged on top of the metadata carried by the context
+}
+
+func toRecordData(ctx context.Context, registry Registry, msg interface{}) (record.Data, error) {
+	envelope, isEnvelope := msg.(Envelope)
+	if !isEnvelope {
+		envelope = Envelope{Message: msg}
+	}
+	if envelope.MessageId == "" {
+		envelope.MessageId = uuid.New().String()
+	} else if _, err := uuid.Parse(envelope.MessageId); err != nil {
+		return record.Data{}, fmt.Errorf("message id [%s]: %w", envelope.MessageId, err)
+	}
+	if envelope.CorrelationId == "" {
+		envelope.CorrelationId = streams.CorrelationIdFromContext(ctx)
+	}
+	if envelope.CausationId == "" {
+		envelope.CausationId = streams.CausationIdFromContext(ctx)
+	}
+
+	metadata := streams.MetadataFromContext(ctx).Merge(envelope.Metadata)
+	if len(metadata) == 0 {
+		metadata = nil
+	}
+
+	b, contentType, err := registry.Marshal(envelope.Message)
+	if err != nil {
+		return record.Data{}, err
+	}
+	return record.Data{
+		MessageId:     envelope.MessageId,
+		ContentType:   contentType,
+		Data:          b,
+		CorrelationId: envelope.CorrelationId,
+		CausationId:   envelope.CausationId,
+		Metadata:      metadata,
+	}, nil
+}
@@ -0,0 +1,66 @@
+package po
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-po/po/internal/broker"
+	"github.com/go-po/po/internal/observer/binary"
+	"github.com/go-po/po/internal/observer/unary"
+	"github.com/go-po/po/streams"
+)
+
+// What a subscriber does with a message its handler keeps failing on
+type FailureAction = broker.FailureAction
+
+const (
+	StopOnFailure       = broker.StopOnFailure       // stops at the message until it is handled
+	SkipOnFailure       = broker.SkipOnFailure       // moves on to the next message
+	DeadLetterOnFailure = broker.DeadLetterOnFailure // parks the message in the dead-letter stream of the subscriber and moves on
+)
+
+// Message a subscriber gave up on, see DeadLetterOnFailure
+type DeadLetter = broker.DeadLetter
+
+// Decides how a subscriber handles errors from its handler
+type SubscriberErrorPolicy struct {
+	MaxAttempts int           // attempts including the first one
+	BaseDelay   time.Duration // delay before the first retry, doubled for each retry after it
+	MaxDelay    time.Duration // upper bound of the delay, zero for no bound
+	Jitter      float64       // fraction of the delay randomized, from 0 to 1
+	OnFailure   FailureAction // once all attempts failed
+}
+
+// Stops the subscriber at the first error, retrying the message on the next notification
+func DefaultSubscriberErrorPolicy() SubscriberErrorPolicy {
+	return SubscriberErrorPolicy{
+		MaxAttempts: 1,
+		OnFailure:   StopOnFailure,
+	}
+}
+
+func (policy SubscriberErrorPolicy) toBroker() broker.ErrorPolicy {
+	backoff := RetryPolicy{
+		BaseDelay: policy.BaseDelay,
+		MaxDelay:  policy.MaxDelay,
+		Jitter:    policy.Jitter,
+	}
+	return broker.ErrorPolicy{
+		MaxAttempts: policy.MaxAttempts,
+		Delay:       backoff.delay,
+		OnFailure:   policy.OnFailure,
+	}
+}
+
+// observes the first value of the binary trace with the counter as well,
+// keeping the error messages out of the metric labels
+func countFirst(trace binary.ClientTrace, counter unary.ClientTrace) binary.ClientTrace {
+	return binary.Combine(trace, binary.ClientTraceFunc(func(ctx context.Context, a, b string) func() {
+		return counter.Observe(ctx, a)
+	}))
+}
+
+// Stream the messages of the subscriber are parked in
+func DeadLetterStream(subscriberId string) streams.Id {
+	return broker.DeadLetterStream(subscriberId)
+}
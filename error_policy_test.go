@@ -0,0 +1,81 @@
+package po
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-po/po/internal/broker/channels"
+	"github.com/go-po/po/internal/logger"
+	"github.com/go-po/po/internal/observer"
+	"github.com/go-po/po/internal/store/inmemory"
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPo_DeadLetters(t *testing.T) {
+	ctx := context.Background()
+	id := streams.ParseId("letters-a")
+
+	// setup
+	es := newPo(inmemory.New(), channels.New(), testRegistry, &logger.NoopLogger{}, observer.NewStub(), CatchUpPolicy{})
+	poisoned := true
+	var received []string
+	_, err := es.Subscribe(ctx, "letters", streams.ParseId(id.Group), HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+		name := msg.Data.(Msg).Name
+		if name == "poison" && poisoned {
+			return errors.New("poisoned")
+		}
+		received = append(received, name)
+		return nil
+	}), SubscribeWithErrorPolicy(SubscriberErrorPolicy{MaxAttempts: 2, OnFailure: DeadLetterOnFailure}))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// execute
+	_, err = es.Append(ctx, id, Msg{Name: "poison"}, Msg{Name: "next"})
+
+	// verify
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"next"}, received, "moved on")
+	letters, err := es.DeadLetters(ctx, "letters")
+	assert.NoError(t, err)
+	if !assert.Len(t, letters, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, Msg{Name: "poison"}, letters[0].Message.Data)
+	assert.Equal(t, id, letters[0].Message.Stream)
+	assert.Equal(t, "poisoned", letters[0].Error)
+
+	t.Run("replay", func(t *testing.T) {
+		// setup
+		poisoned = false
+		// execute
+		err := es.ReplayDeadLetter(ctx, "letters", letters[0].Number)
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"next", "poison"}, received)
+		remaining, err := es.DeadLetters(ctx, "letters")
+		assert.NoError(t, err)
+		assert.Empty(t, remaining)
+	})
+
+	t.Run("discard resolved", func(t *testing.T) {
+		// execute
+		err := es.DiscardDeadLetter(ctx, "letters", letters[0].Number)
+		// verify
+		assert.True(t, errors.Is(err, ErrDeadLetterNotFound))
+	})
+}
+
+func TestNewFromOptions_SubscriberErrorPolicy(t *testing.T) {
+	// execute
+	_, err := NewFromOptions(
+		WithStoreInMemory(),
+		WithProtocolChannels(),
+		WithSubscriberErrorPolicy(SubscriberErrorPolicy{}),
+	)
+	// verify
+	assert.Error(t, err)
+}
@@ -1 +1,131 @@
 package po
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-po/po/internal/broker"
+	"github.com/go-po/po/internal/registry"
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
+)
+
+// Sentinel errors to match with errors.Is
+var (
+	ErrWrongExpectedVersion = store.ErrWrongExpectedVersion  // the stream was not at the expected version, including write conflicts
+	ErrStreamNotFound       = store.ErrStreamNotFound        // the stream have no messages
+	ErrUnknownMessageType   = registry.ErrUnknownMessageType // no message registered for the type
+	ErrNotifyFailed         = broker.ErrNotifyFailed         // subscribers could not be notified
+	ErrNoSubscriber         = broker.ErrNoSubscriber         // no subscriber registered for the stream group
+	ErrSubscribed           = broker.ErrSubscribed           // the subscription id is already subscribed to a stream of the group
+	ErrDeadLetterNotFound   = broker.ErrDeadLetterNotFound   // no unresolved dead letter at the number
+	ErrNoLeases             = broker.ErrNoLeases             // the store can not hold the leases of partitioned subscribers
+	ErrClosed               = broker.ErrClosed               // subscribing after Close
+	ErrNoOutbox             = broker.ErrNoOutbox             // the store can not keep an outbox of notifications
+	ErrNoPositions          = broker.ErrNoPositions          // the store can not locate or move subscription positions
+	ErrNoSubscriptionList   = broker.ErrNoSubscriptionList   // the store can not list the subscriptions
+	ErrRetriesExhausted     = errors.New("retries exhausted")
+	ErrSnapshotDecode       = errors.New("snapshot decode failed")
+)
+
+// Returned when an append conflicts with a concurrent write to the stream.
+type WriteConflictError = store.WriteConflictError
+
+// Returned when reading a message with a type not registered.
+type UnknownMessageTypeError = registry.UnknownMessageTypeError
+
+// Returned by the broker when subscribers could not be notified of a message.
+type NotifyError = broker.NotifyError
+
+// Returned when appending to a stream that is not at the expected version.
+type WrongExpectedVersionError struct {
+	StreamId streams.Id
+	Expected ExpectedVersion // version the append expected
+	Actual   int64           // current position of the stream, -1 if it have no messages
+	Err      error           // possible underlying store error
+}
+
+func (err WrongExpectedVersionError) Error() string {
+	return fmt.Sprintf("wrong expected version on [%s]: expected %s, actual %d", err.StreamId, err.Expected, err.Actual)
+}
+
+func (err WrongExpectedVersionError) Is(target error) bool {
+	return target == ErrWrongExpectedVersion
+}
+
+func (err WrongExpectedVersionError) Unwrap() error {
+	return err.Err
+}
+
+// Returned by Execute when the lock of a stream could not be taken within the timeout of the LockMode.
+type LockTimeoutError struct {
+	StreamId streams.Id
+	Timeout  time.Duration
+	Err      error // possible underlying store error
+}
+
+func (err LockTimeoutError) Error() string {
+	return fmt.Sprintf("timeout locking [%s] after %s", err.StreamId, err.Timeout)
+}
+
+func (err LockTimeoutError) Unwrap() error {
+	return err.Err
+}
+
+// Returned by Execute when every attempt allowed by the RetryPolicy failed.
+type RetriesExhaustedError struct {
+	StreamId streams.Id
+	Attempts int   // number of attempts made
+	Err      error // error of the last attempt
+}
+
+func (err RetriesExhaustedError) Error() string {
+	return fmt.Sprintf("retries exhausted on [%s] after %d attempts: %s", err.StreamId, err.Attempts, err.Err)
+}
+
+func (err RetriesExhaustedError) Is(target error) bool {
+	return target == ErrRetriesExhausted
+}
+
+func (err RetriesExhaustedError) Unwrap() error {
+	return err.Err
+}
+
+// Returned when messages were written to the store, but subscribers could not be notified of them.
+type NotifyFailedError struct {
+	StreamId streams.Id
+	Position int64 // position of the last message written
+	Err      error
+}
+
+func (err NotifyFailedError) Error() string {
+	return fmt.Sprintf("written to [%s] at position %d, but notify failed: %s", err.StreamId, err.Position, err.Err)
+}
+
+func (err NotifyFailedError) Is(target error) bool {
+	return target == ErrNotifyFailed
+}
+
+func (err NotifyFailedError) Unwrap() error {
+	return err.Err
+}
+
+// Returned when a stored snapshot could not be decoded onto the projection.
+type SnapshotDecodeError struct {
+	StreamId   streams.Id
+	SnapshotId string
+	Err        error
+}
+
+func (err SnapshotDecodeError) Error() string {
+	return fmt.Sprintf("decode snapshot %s of [%s]: %s", err.SnapshotId, err.StreamId, err.Err)
+}
+
+func (err SnapshotDecodeError) Is(target error) bool {
+	return target == ErrSnapshotDecode
+}
+
+func (err SnapshotDecodeError) Unwrap() error {
+	return err.Err
+}
@@ -0,0 +1,66 @@
+package po
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-po/po/internal/broker"
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrors(t *testing.T) {
+	id := streams.ParseId("errors-1")
+	_, unknownType := testRegistry.Unmarshal("po.Unknown", nil)
+
+	tests := []struct {
+		name   string
+		err    error
+		target error
+	}{
+		{name: "write conflict", err: store.WriteConflictError{StreamId: id}, target: ErrWrongExpectedVersion},
+		{name: "wrong expected version", err: WrongExpectedVersionError{StreamId: id}, target: ErrWrongExpectedVersion},
+		{name: "stream not found", err: store.CheckPosition(id, store.ExistingStream, -1), target: ErrStreamNotFound},
+		{name: "retries exhausted", err: RetriesExhaustedError{StreamId: id, Err: store.WriteConflictError{}}, target: ErrRetriesExhausted},
+		{name: "retries exhausted conflict", err: RetriesExhaustedError{StreamId: id, Err: store.WriteConflictError{}}, target: ErrWrongExpectedVersion},
+		{name: "unknown message type", err: unknownType, target: ErrUnknownMessageType},
+		{name: "notify failed", err: NotifyFailedError{StreamId: id, Err: fmt.Errorf("broken")}, target: ErrNotifyFailed},
+		{name: "broker notify", err: broker.NotifyError{Stream: id, Err: ErrNoSubscriber}, target: ErrNotifyFailed},
+		{name: "snapshot decode", err: SnapshotDecodeError{StreamId: id, Err: fmt.Errorf("broken")}, target: ErrSnapshotDecode},
+		{name: "wrapped", err: fmt.Errorf("wrapped: %w", WrongExpectedVersionError{StreamId: id}), target: ErrWrongExpectedVersion},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.True(t, errors.Is(test.err, test.target), "%s is %s", test.err, test.target)
+		})
+	}
+}
+
+type failingBroker struct {
+	Broker
+}
+
+func (failingBroker) Notify(ctx context.Context, records ...record.Record) error {
+	return broker.NotifyError{Stream: records[0].Stream, Number: records[0].Number, Err: ErrNoSubscriber}
+}
+
+func TestAppend_NotifyFailed(t *testing.T) {
+	// setup
+	store := &stubAppenderStore{messageCount: 0}
+	sut := newAppenderFunc(store, failingBroker{}, testRegistry)
+	id := streams.ParseId("notify-1")
+	// execute
+	position, err := sut(context.Background(), id, -1, Msg{Name: "written"})
+	// verify
+	failed := NotifyFailedError{}
+	if assert.True(t, errors.As(err, &failed), "notify failed error") {
+		assert.Equal(t, position, failed.Position)
+		assert.Equal(t, id.String(), failed.StreamId.String())
+	}
+	assert.True(t, errors.Is(err, ErrNoSubscriber))
+	assert.Equal(t, 1, len(store.records), "written")
+}
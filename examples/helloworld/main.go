@@ -16,7 +16,7 @@ func main() {
 	es := po.New(po.NewStoreInMemory(), po.NewProtocolChannels())
 
 	id := streams.ParseId("messages")
-	err := es.Subscribe(rootCtx, "messages handler", id, Subscriber{})
+	_, err := es.Subscribe(rootCtx, "messages handler", id, Subscriber{})
 	if err != nil {
 		log.Fatalf("failed subscribing: %s", err)
 	}
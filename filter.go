@@ -0,0 +1,69 @@
+package po
+
+import (
+	"fmt"
+
+	"github.com/go-po/po/streams"
+)
+
+// Messages handed to a subscriber or a projection, the zero value lets every message through
+type Filter struct {
+	types     []interface{}
+	predicate func(msg streams.Message) bool
+}
+
+// Messages of the types only, given as values of the types as they are appended.
+// Stores skip the messages of other types, so they are never fetched nor decoded.
+func FilterTypes(messages ...interface{}) Filter {
+	return Filter{types: messages}
+}
+
+// Messages the predicate accepts only, called with each decoded message
+func FilterWhere(predicate func(msg streams.Message) bool) Filter {
+	return Filter{predicate: predicate}
+}
+
+// Narrows the filter to the messages the predicate accepts as well
+func (filter Filter) Where(predicate func(msg streams.Message) bool) Filter {
+	if filter.predicate == nil {
+		filter.predicate = predicate
+		return filter
+	}
+	first := filter.predicate
+	filter.predicate = func(msg streams.Message) bool {
+		return first(msg) && predicate(msg)
+	}
+	return filter
+}
+
+func (filter Filter) isZero() bool {
+	return len(filter.types) == 0 && filter.predicate == nil
+}
+
+// content types of the messages of the types, as the registry marshals them
+func (filter Filter) contentTypes(registry Registry) ([]string, error) {
+	var contentTypes []string
+	for _, msg := range filter.types {
+		_, contentType, err := registry.Marshal(msg)
+		if err != nil {
+			return nil, fmt.Errorf("filter type %T: %w", msg, err)
+		}
+		contentTypes = append(contentTypes, contentType)
+	}
+	return contentTypes, nil
+}
+
+type projectOptions struct {
+	filter Filter
+}
+
+type ProjectOption func(opt *projectOptions)
+
+// Projects the messages the filter lets through only.
+// The stream is still locked to its last message, as the others are skipped.
+// Filtered projections are not snapshotted, even if the projection implements streams.NamedSnapshot.
+func ProjectWithFilter(filter Filter) ProjectOption {
+	return func(opt *projectOptions) {
+		opt.filter = filter
+	}
+}
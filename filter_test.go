@@ -0,0 +1,117 @@
+package po
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-po/po/internal/store/inmemory"
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPo_SubscribeWithFilter(t *testing.T) {
+	ctx := context.Background()
+	notB := func(msg streams.Message) bool { return msg.Data.(Msg).Name != "b" }
+	tests := []struct {
+		name   string
+		store  Store
+		filter Filter
+		want   []string
+	}{
+		{name: "types", store: inmemory.New(), filter: FilterTypes(Msg{}), want: []string{"a", "b", "c"}},
+		{name: "types once read", store: struct{ Store }{inmemory.New()}, filter: FilterTypes(Msg{}), want: []string{"a", "b", "c"}},
+		{name: "types and predicate", store: inmemory.New(), filter: FilterTypes(Msg{}).Where(notB), want: []string{"a", "c"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// setup
+			es, err := NewFromOptions(WithStore(test.store), WithProtocolChannels(), WithRegistry(testRegistry))
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			_, err = es.Append(ctx, streams.ParseId("filter-1"), Msg{Name: "a"}, OtherMsg{Name: "x"}, Msg{Name: "b"})
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			log := &nameLog{}
+			// execute
+			_, err = es.Subscribe(ctx, "filtered", streams.ParseId("filter"), log, SubscribeWithFilter(test.filter))
+			_, _ = es.Append(ctx, streams.ParseId("filter-1"), OtherMsg{Name: "y"}, Msg{Name: "c"}, OtherMsg{Name: "z"})
+			// verify
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, log.received())
+		})
+	}
+}
+
+// counts the messages projected, snapshotted by its count
+type countProjection struct {
+	Count int
+}
+
+func (projection *countProjection) SnapshotName() string {
+	return "count"
+}
+
+func (projection *countProjection) Handle(ctx context.Context, msg streams.Message) error {
+	projection.Count = projection.Count + 1
+	return nil
+}
+
+func TestStream_ProjectWithFilter(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		store Store
+	}{
+		{name: "filtered by the store", store: inmemory.New()},
+		{name: "filtered once read", store: struct{ Store }{inmemory.New()}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// setup
+			es, err := NewFromOptions(WithStore(test.store), WithProtocolChannels(), WithRegistry(testRegistry))
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			id := streams.ParseId("project-1")
+			_, err = es.Append(ctx, id, Msg{Name: "a"}, OtherMsg{Name: "x"}, Msg{Name: "b"}, OtherMsg{Name: "y"})
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			stream := es.Stream(ctx, id)
+			log := &nameLog{}
+			// execute
+			err = stream.Project(log, ProjectWithFilter(FilterTypes(Msg{})))
+			// verify
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"a", "b"}, log.received())
+			position, err := stream.Append(Msg{Name: "c"})
+			assert.NoError(t, err, "locked at the last message")
+			assert.Equal(t, int64(4), position)
+		})
+	}
+
+	t.Run("snapshot not shared with unfiltered projections", func(t *testing.T) {
+		// setup
+		es, err := NewFromOptions(WithStoreInMemory(), WithProtocolChannels(), WithRegistry(testRegistry))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		id := streams.ParseId("snapshot-1")
+		_, err = es.Append(ctx, id, Msg{Name: "a"}, OtherMsg{Name: "x"}, Msg{Name: "b"}, OtherMsg{Name: "y"})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		filtered := &countProjection{}
+		unfiltered := &countProjection{}
+		// execute
+		err = es.Project(ctx, id, filtered, ProjectWithFilter(FilterTypes(Msg{})))
+		assert.NoError(t, err)
+		err = es.Project(ctx, id, unfiltered)
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, 2, filtered.Count)
+		assert.Equal(t, 4, unfiltered.Count)
+	})
+}
@@ -3,11 +3,14 @@ module github.com/go-po/po
 go 1.13
 
 require (
+	github.com/go-sql-driver/mysql v1.5.0
 	github.com/golang-migrate/migrate/v4 v4.9.1
 	github.com/golang/protobuf v1.4.2 // indirect
+	github.com/google/uuid v1.1.1
 	github.com/jteeuwen/go-bindata v3.0.7+incompatible // indirect
 	github.com/kyleconroy/sqlc v1.0.0 // indirect
-	github.com/lib/pq v1.3.0
+	github.com/lib/pq v1.10.9
+	github.com/mattn/go-sqlite3 v1.14.6
 	github.com/prometheus/client_golang v1.6.0
 	github.com/prometheus/common v0.10.0 // indirect
 	github.com/streadway/amqp v0.0.0-20200108173154-1c71cc93ed71
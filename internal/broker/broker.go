@@ -3,11 +3,17 @@ package broker
 import (
 	"context"
 	"fmt"
+	"io"
 	"sync"
+	"time"
 
+	"github.com/go-po/po/internal/observer/binary"
+	"github.com/go-po/po/internal/observer/unary"
+	"github.com/go-po/po/internal/observer/value"
 	"github.com/go-po/po/internal/record"
 	"github.com/go-po/po/internal/store"
 	"github.com/go-po/po/streams"
+	"github.com/google/uuid"
 )
 
 type Handler interface {
@@ -24,6 +30,7 @@ type Store interface {
 	SubscriptionPositionLock(tx store.Tx, id streams.Id, subscriptionIds ...string) ([]store.SubscriptionPosition, error)
 	ReadRecords(ctx context.Context, id streams.Id, from, to, limit int64) ([]record.Record, error)
 	SetSubscriptionPosition(tx store.Tx, id streams.Id, position store.SubscriptionPosition) error
+	WriteRecords(ctx context.Context, id streams.Id, data ...record.Data) ([]record.Record, error)
 }
 
 type RecordHandler interface {
@@ -36,23 +43,62 @@ func (fn RecordHandlerFunc) Handle(ctx context.Context, record record.Record) (b
 }
 
 type Protocol interface {
+	// Consumes the group until ctx is done, returning its publisher
 	Register(ctx context.Context, group string, input RecordHandler) (RecordHandler, error)
+	// Publishes to the group without consuming it, for groups without subscribers in this process
+	Publisher(ctx context.Context, group string) (RecordHandler, error)
 }
 
 type Subscription interface {
 	Handle(ctx context.Context, record record.Record) (bool, error)
-	AddSubscriber(id streams.Id, subscriptionId string, subscriber streams.Handler)
+	// Handles the records of the subscribers behind the head of their stream only
+	CatchUp(ctx context.Context, behind func(id streams.Id, subscriptionId string) bool) (bool, error)
+	AddSubscriber(id streams.Id, subscriptionId string, subscriber streams.Handler, opts ...SubscriberOption)
+	Replay(ctx context.Context, subscriptionId string, msg streams.Message) (bool, error)
+	RemoveSubscriber(subscriptionId string) bool
+	HasSubscriber(subscriptionId string) bool
+	Close()
 }
 
-func New(store Store, registry Registry, protocol Protocol) *Broker {
-	return &Broker{
-		store:       store,
-		registry:    registry,
-		protocol:    protocol,
-		mu:          sync.Mutex{},
-		subscribers: make(map[string]Subscription),
-		publishers:  make(map[string]RecordHandler),
+func New(store Store, registry Registry, protocol Protocol, opts ...Option) *Broker {
+	broker := &Broker{
+		store:           store,
+		registry:        registry,
+		protocol:        protocol,
+		mu:              sync.Mutex{},
+		subscribers:     make(map[string]Subscription),
+		publishers:      make(map[string]RecordHandler),
+		coordinators:    make(map[string]*coordinator),
+		heldBackDelay:   DefaultHeldBackDelay,
+		heldBackWaiting: make(map[string]bool),
+		done:            make(chan struct{}),
+		onCatchUpError:  binary.Noop(),
+
+		onSubscriberError:   binary.Noop(),
+		onSubscriberFailure: binary.Noop(),
+		subscriberErrors:    &subscriberErrors{last: make(map[string]subscriberError)},
+
+		instanceId: uuid.New().String(),
+		leaseTTL:   DefaultLeaseTTL,
+
+		relayWake:      make(chan struct{}, 1),
+		onRelayError:   unary.Noop(),
+		onRelayLag:     value.Noop(),
+		onRelayBacklog: value.Noop(),
+	}
+	broker.ctx, broker.cancel = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(broker)
 	}
+	if broker.outbox != nil {
+		broker.outbox.EnableOutbox()
+		broker.background.Add(1)
+		go func() {
+			defer broker.background.Done()
+			broker.relay()
+		}()
+	}
+	return broker
 }
 
 type Broker struct {
@@ -60,12 +106,53 @@ type Broker struct {
 	registry Registry
 	protocol Protocol
 
-	mu          sync.Mutex
-	subscribers map[string]Subscription
-	publishers  map[string]RecordHandler
+	catchUpInterval time.Duration // zero when disabled
+	catchUpJitter   time.Duration
+	onCatchUpError  binary.ClientTrace
+
+	onSubscriberError   binary.ClientTrace // subscriber id, error
+	onSubscriberFailure binary.ClientTrace // subscriber id, action
+
+	leases     LeaseStore // nil if the store holds no leases
+	instanceId string
+	leaseTTL   time.Duration
+
+	heldBack      HeldBackStore // nil if the store holds back no records
+	heldBackDelay time.Duration
+
+	positions        PositionStore     // nil if the store cannot locate positions
+	subscriptionList SubscriptionStore // nil if the store cannot list the subscriptions
+	subscriberErrors *subscriberErrors
+
+	outbox         OutboxStore // nil when Notify publishes the records itself
+	relayInterval  time.Duration
+	relayBatchSize int
+	relayDelay     func(retry int) time.Duration
+	relayWake      chan struct{}
+	onRelayError   unary.ClientTrace // error
+	onRelayLag     value.ClientTrace // milliseconds from write to publish
+	onRelayBacklog value.ClientTrace // notifications left in the outbox
+
+	ctx    context.Context    // of the work in the background, cancelled on Close
+	cancel context.CancelFunc // of ctx
+
+	mu              sync.Mutex
+	subscribers     map[string]Subscription
+	publishers      map[string]RecordHandler
+	coordinators    map[string]*coordinator // of partitioned subscribers, by group and subscriber id
+	heldBackWaiting map[string]bool         // groups to wake once records held back might be released
+	closed          bool
+	done            chan struct{}  // closed with the broker
+	background      sync.WaitGroup // catch-ups and coordinators running
 }
 
+// Publishes the records to the protocol.
+// With an outbox, the records were written along with their notifications, so the relay is woken instead.
 func (broker *Broker) Notify(ctx context.Context, records ...record.Record) error {
+	if broker.outbox != nil {
+		broker.wakeRelay()
+		return nil
+	}
 	for _, record := range records {
 		err := broker.notify(ctx, record)
 		if err != nil {
@@ -76,60 +163,158 @@ func (broker *Broker) Notify(ctx context.Context, records ...record.Record) erro
 }
 
 func (broker *Broker) notify(ctx context.Context, r record.Record) error {
-	h, found := broker.publishers[r.Group]
-	if !found {
-		return fmt.Errorf("missing subscriber: %s", r.Group)
+	h, err := broker.publisher(ctx, r.Group)
+	if err != nil {
+		return NotifyError{Stream: r.Stream, Number: r.Number, Err: err}
 	}
 	send, err := h.Handle(ctx, r)
 	if err != nil {
-		return err
+		return NotifyError{Stream: r.Stream, Number: r.Number, Err: err}
 	}
 	if !send {
-		return fmt.Errorf("failed to publish %s:%d", r.Stream, r.Number)
+		return NotifyError{Stream: r.Stream, Number: r.Number, Err: fmt.Errorf("not published")}
 	}
 	return nil
 }
 
-func (broker *Broker) Register(ctx context.Context, subscriberId string, streamId streams.Id, subscriber streams.Handler) error {
+// Publisher of the group, created on demand if no subscriber registered for it,
+// so instances consuming the group elsewhere are still notified
+func (broker *Broker) publisher(ctx context.Context, group string) (RecordHandler, error) {
 	broker.mu.Lock()
 	defer broker.mu.Unlock()
+	h, found := broker.publishers[group]
+	if found {
+		return h, nil
+	}
+	h, err := broker.protocol.Publisher(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+	broker.publishers[group] = h
+	return h, nil
+}
 
-	tx, err := broker.store.Begin(ctx)
+func (broker *Broker) Register(ctx context.Context, subscriberId string, streamId streams.Id, subscriber streams.Handler, opts ...SubscriberOption) error {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	if broker.closed {
+		return ErrClosed
+	}
+
+	sub, err := broker.subscription(streamId.Group)
 	if err != nil {
 		return err
 	}
-	defer func() {
-		_ = tx.Rollback()
-	}()
-	// rely on SetSubscriptionPosition to ignore this command if the new position is lower
-	err = broker.store.SetSubscriptionPosition(tx, streamId, store.SubscriptionPosition{
-		SubscriptionId: subscriberId,
-		Position:       -1,
-	})
+	if sub.HasSubscriber(subscriberId) {
+		return fmt.Errorf("register %s: %w", subscriberId, ErrSubscribed)
+	}
+
+	err = broker.initPositions(ctx, streamId, startOf(opts), subscriberId)
 	if err != nil {
 		return err
 	}
-	err = tx.Commit()
+
+	opts = append([]SubscriberOption{
+		observeSubscriber(broker.onSubscriberError, broker.onSubscriberFailure),
+		trackErrors(broker.subscriberErrors),
+	}, opts...)
+	sub.AddSubscriber(streamId, subscriberId, subscriber, opts...)
+
+	return nil
+}
+
+// subscription of the group, registered with the protocol until the broker is closed.
+// must be called while holding the lock
+func (broker *Broker) subscription(group string) (Subscription, error) {
+	sub, found := broker.subscribers[group]
+	if found {
+		return sub, nil
+	}
+
+	s := newSub(broker.registry, broker.store, group)
+	if broker.heldBack != nil {
+		s.heldBack = broker.heldBack
+		s.onHeldBack = func() {
+			broker.wakeHeldBack(group)
+		}
+	}
+	publisher, err := broker.protocol.Register(broker.ctx, group, s)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	// replaces a publisher created before the group was consumed
+	broker.publishers[group] = publisher
+	broker.subscribers[group] = s
+	if broker.catchUpInterval > 0 {
+		broker.background.Add(1)
+		go func() {
+			defer broker.background.Done()
+			broker.catchUp(group, s)
+		}()
+	}
+	return s, nil
+}
 
+// Removes the subscriber from the stream group, once its batch in flight is committed.
+// Partitioned subscribers leave their partitions to the other instances.
+func (broker *Broker) Unregister(subscriberId string, streamId streams.Id) error {
+	broker.mu.Lock()
 	sub, found := broker.subscribers[streamId.Group]
-	if !found {
-		sub = newSub(broker.registry, broker.store, streamId.Group)
-		broker.subscribers[streamId.Group] = sub
+	key := coordinatorKey(streamId.Group, subscriberId)
+	c := broker.coordinators[key]
+	delete(broker.coordinators, key)
+	broker.mu.Unlock()
+
+	if !found || !sub.RemoveSubscriber(subscriberId) {
+		return fmt.Errorf("unregister %s: %w", subscriberId, ErrNoSubscriber)
+	}
+	if c != nil {
+		c.close()
 	}
+	return nil
+}
 
-	_, found = broker.publishers[streamId.Group]
-	if !found {
-		publisher, err := broker.protocol.Register(ctx, streamId.Group, sub)
-		if err != nil {
-			return err
-		}
-		broker.publishers[streamId.Group] = publisher
+// Stops the subscribers once their batches in flight are committed,
+// then the catch-ups and partition coordinators, and closes the protocol if it can be closed.
+// Returns the error of the context if it is done first, leaving the rest to stop in the background.
+func (broker *Broker) Close(ctx context.Context) error {
+	broker.mu.Lock()
+	if broker.closed {
+		broker.mu.Unlock()
+		return nil
+	}
+	broker.closed = true
+	close(broker.done)
+	broker.cancel()
+	var subs []Subscription
+	for _, sub := range broker.subscribers {
+		subs = append(subs, sub)
 	}
+	var coordinators []*coordinator
+	for _, c := range broker.coordinators {
+		coordinators = append(coordinators, c)
+	}
+	broker.mu.Unlock()
 
-	sub.AddSubscriber(streamId, subscriberId, subscriber)
+	stopped := make(chan struct{})
+	go func() {
+		for _, sub := range subs {
+			sub.Close()
+		}
+		for _, c := range coordinators {
+			c.close()
+		}
+		broker.background.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-stopped:
+	}
 
+	if closer, ok := broker.protocol.(io.Closer); ok {
+		return closer.Close()
+	}
 	return nil
 }
@@ -2,6 +2,7 @@ package broker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/go-po/po/internal/record"
 	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/internal/store/inmemory"
 	"github.com/go-po/po/streams"
 	"github.com/stretchr/testify/assert"
 )
@@ -53,6 +55,11 @@ func (mock *mockStore) SetSubscriptionPosition(tx store.Tx, id streams.Id, posit
 	return nil
 }
 
+func (mock *mockStore) WriteRecords(ctx context.Context, id streams.Id, data ...record.Data) ([]record.Record, error) {
+	mock.t.Fatalf("unexpected write to %s", id)
+	return nil, nil
+}
+
 func (mock *mockStore) verifyPosition(t *testing.T, subscriberId string, expectedPosition int64) {
 	got, isSet := mock.sets[subscriberId]
 	if assert.True(t, isSet, "subscriber id %s position not set", subscriberId) {
@@ -76,10 +83,19 @@ func (m mockRegistry) ToMessage(r record.Record) (streams.Message, error) {
 type mockProtocol struct {
 	publisher RecordHandler
 	input     RecordHandler
+	ctx       context.Context // registered with
 }
 
 func (mock *mockProtocol) Register(ctx context.Context, group string, input RecordHandler) (RecordHandler, error) {
 	mock.input = input
+	mock.ctx = ctx
+	return mock.publisher, nil
+}
+
+func (mock *mockProtocol) Publisher(ctx context.Context, group string) (RecordHandler, error) {
+	if mock.publisher == nil {
+		return nil, fmt.Errorf("no publisher for %s", group)
+	}
 	return mock.publisher, nil
 }
 
@@ -167,6 +183,50 @@ func TestBroker(t *testing.T) {
 		}
 	})
 
+	t.Run("cancelled registration context", func(t *testing.T) {
+		// setup
+		protocol := &mockProtocol{}
+		broker := New(newMockStore(t, nil, nil), registry, protocol)
+		registration, cancel := context.WithCancel(ctx)
+		err := broker.Register(registration, "A", id, emptyHandler)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		cancel()
+		// verify
+		assert.NoError(t, protocol.ctx.Err(), "consumed after the registration")
+		assert.NoError(t, broker.Close(ctx))
+		assert.Error(t, protocol.ctx.Err(), "consumed until closed")
+	})
+
+	t.Run("notify without subscriber", func(t *testing.T) {
+		// setup
+		var published []record.Record
+		broker := New(newMockStore(t, nil, nil), registry, &mockProtocol{publisher: RecordHandlerFunc(func(ctx context.Context, record record.Record) (bool, error) {
+			published = append(published, record)
+			return true, nil
+		})})
+		// execute
+		err := broker.Notify(ctx, R(id, 0, 0), R(id, 1, 1))
+		// verify
+		assert.NoError(t, err)
+		assert.Len(t, published, 2)
+	})
+
+	t.Run("notify without publisher", func(t *testing.T) {
+		// setup
+		broker := New(newMockStore(t, nil, nil), registry, &mockProtocol{})
+		// execute
+		err := broker.Notify(ctx, R(id, 0, 0))
+		// verify
+		assert.True(t, errors.Is(err, ErrNotifyFailed), "notify failed")
+		notifyErr := NotifyError{}
+		if assert.True(t, errors.As(err, &notifyErr)) {
+			assert.Equal(t, id.String(), notifyErr.Stream.String())
+		}
+	})
+
 	t.Run("notify", func(t *testing.T) {
 		// setup
 		store := newMockStore(t, nil, Rs(id, 0, 2))
@@ -189,7 +249,7 @@ func TestBroker(t *testing.T) {
 	t.Run("notify with position", func(t *testing.T) {
 		// setup
 		store := newMockStore(t,
-			[]store.SubscriptionPosition{{"A", 0}},
+			[]store.SubscriptionPosition{{SubscriptionId: "A", Position: 0}},
 			Rs(id, 0, 2),
 		)
 		protocol := &mockProtocol{publisher: RecordHandlerFunc(func(ctx context.Context, record record.Record) (bool, error) {
@@ -210,7 +270,7 @@ func TestBroker(t *testing.T) {
 	t.Run("notify multiples", func(t *testing.T) {
 		// setup
 		mockStore := newMockStore(t,
-			[]store.SubscriptionPosition{{"A", 0}, {"B", 2}},
+			[]store.SubscriptionPosition{{SubscriptionId: "A", Position: 0}, {SubscriptionId: "B", Position: 2}},
 			Rs(id, 0, 4),
 		)
 		protocol := &mockProtocol{publisher: RecordHandlerFunc(func(ctx context.Context, record record.Record) (bool, error) {
@@ -236,7 +296,7 @@ func TestBroker(t *testing.T) {
 	t.Run("one failing", func(t *testing.T) {
 		// setup
 		mockStore := newMockStore(t,
-			[]store.SubscriptionPosition{{"A", 0}, {"B", 0}},
+			[]store.SubscriptionPosition{{SubscriptionId: "A", Position: 0}, {SubscriptionId: "B", Position: 0}},
 			Rs(id, 0, 4),
 		)
 		protocol := &mockProtocol{publisher: RecordHandlerFunc(func(ctx context.Context, record record.Record) (bool, error) {
@@ -259,3 +319,228 @@ func TestBroker(t *testing.T) {
 		mockStore.verifyPosition(t, "B", 1)
 	})
 }
+
+func TestBroker_CatchUp(t *testing.T) {
+	// setup
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mem := inmemory.New()
+	id := streams.ParseId("catchup")
+	_, err := mem.WriteRecords(ctx, id.WithEntity("a"), record.Data{ContentType: "application/json", Data: []byte(`{}`)})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	received := make(chan streams.Message, 1)
+	protocol := &mockProtocol{publisher: RecordHandlerFunc(func(ctx context.Context, record record.Record) (bool, error) {
+		return true, nil
+	})}
+	broker := New(mem, &mockRegistry{}, protocol, WithCatchUp(10*time.Millisecond, 5*time.Millisecond))
+
+	// execute
+	err = broker.Register(ctx, "A", id, streams.HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+		received <- msg
+		return nil
+	}))
+
+	// verify
+	assert.NoError(t, err)
+	select {
+	case msg := <-received:
+		assert.Equal(t, "catchup-a", msg.Stream.String())
+	case <-time.After(time.Second):
+		t.Fatal("record written without notification not received")
+	}
+}
+
+// store counting the transactions begun
+type beginCountingStore struct {
+	*inmemory.InMemory
+	mu    sync.Mutex
+	begun int
+}
+
+func (s *beginCountingStore) Begin(ctx context.Context) (store.Tx, error) {
+	s.mu.Lock()
+	s.begun = s.begun + 1
+	s.mu.Unlock()
+	return s.InMemory.Begin(ctx)
+}
+
+func (s *beginCountingStore) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.begun
+}
+
+func TestBroker_CatchUpLifetime(t *testing.T) {
+	id := streams.ParseId("lifetime")
+	write := func(t *testing.T, mem *inmemory.InMemory) {
+		_, err := mem.WriteRecords(context.Background(), id.WithEntity("a"), record.Data{ContentType: "application/json", Data: []byte(`{}`)})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+	}
+
+	t.Run("registration context done", func(t *testing.T) {
+		// setup
+		mem := inmemory.New()
+		broker := New(mem, &mockRegistry{}, &mockProtocol{}, WithCatchUp(10*time.Millisecond, 0))
+		defer func() {
+			_ = broker.Close(context.Background())
+		}()
+		log := &mockNumberLog{}
+		ctx, cancel := context.WithCancel(context.Background())
+		assert.NoError(t, broker.Register(ctx, "A", id, log))
+		// execute
+		cancel()
+		write(t, mem)
+		// verify
+		assert.Eventually(t, func() bool { return len(log.numbers()) == 1 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("subscribers at the head are skipped", func(t *testing.T) {
+		// setup
+		mem := &beginCountingStore{InMemory: inmemory.New()}
+		broker := New(mem, &mockRegistry{}, &mockProtocol{},
+			WithCatchUp(10*time.Millisecond, 0),
+			WithPositions(mem),
+			WithSubscriptionList(mem),
+		)
+		defer func() {
+			_ = broker.Close(context.Background())
+		}()
+		log := &mockNumberLog{}
+		assert.NoError(t, broker.Register(context.Background(), "A", id, log))
+		write(t, mem.InMemory)
+		assert.Eventually(t, func() bool { return len(log.numbers()) == 1 }, time.Second, time.Millisecond)
+		time.Sleep(20 * time.Millisecond) // position committed
+		begun := mem.count()
+		// execute
+		time.Sleep(100 * time.Millisecond)
+		// verify
+		assert.Equal(t, begun, mem.count(), "transactions begun at the head")
+		write(t, mem.InMemory)
+		assert.Eventually(t, func() bool { return len(log.numbers()) == 2 }, time.Second, time.Millisecond)
+	})
+}
+
+func TestBroker_Unregister(t *testing.T) {
+	// setup
+	ctx := context.Background()
+	mem := inmemory.New()
+	id := streams.ParseId("unregister")
+	protocol := &mockProtocol{publisher: RecordHandlerFunc(func(ctx context.Context, record record.Record) (bool, error) {
+		return true, nil
+	})}
+	broker := New(mem, &mockRegistry{}, protocol)
+	handlerA := newCountingHandler()
+	handlerB := newCountingHandler()
+	_ = broker.Register(ctx, "A", id, handlerA)
+	_ = broker.Register(ctx, "B", id, handlerB)
+	records, err := mem.WriteRecords(ctx, id.WithEntity("a"), record.Data{ContentType: "application/json", Data: []byte(`{}`)})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// execute
+	err = broker.Unregister("A", id)
+	_, _ = protocol.publish(t, records[0])
+
+	// verify
+	assert.NoError(t, err)
+	assert.Equal(t, 0, handlerA.count, "count A")
+	assert.Equal(t, 1, handlerB.count, "count B")
+
+	t.Run("twice", func(t *testing.T) {
+		err := broker.Unregister("A", id)
+		assert.True(t, errors.Is(err, ErrNoSubscriber))
+	})
+}
+
+// protocol recording if it was closed
+type closingProtocol struct {
+	mockProtocol
+	closed bool
+}
+
+func (mock *closingProtocol) Close() error {
+	mock.closed = true
+	return nil
+}
+
+func TestBroker_Close(t *testing.T) {
+	setup := func(t *testing.T) (*Broker, *inmemory.InMemory, *closingProtocol, chan struct{}) {
+		ctx := context.Background()
+		mem := inmemory.New()
+		id := streams.ParseId("close")
+		protocol := &closingProtocol{mockProtocol: mockProtocol{publisher: RecordHandlerFunc(func(ctx context.Context, record record.Record) (bool, error) {
+			return true, nil
+		})}}
+		broker := New(mem, &mockRegistry{}, protocol)
+		handling := make(chan struct{}, 1)
+		release := make(chan struct{})
+		err := broker.Register(ctx, "A", id, streams.HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+			handling <- struct{}{}
+			<-release
+			return nil
+		}))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		records, err := mem.WriteRecords(ctx, id.WithEntity("a"), record.Data{ContentType: "application/json", Data: []byte(`{}`)})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		go func() {
+			_, _ = protocol.input.Handle(ctx, records[0])
+		}()
+		<-handling
+		return broker, mem, protocol, release
+	}
+
+	t.Run("drains the batch in flight", func(t *testing.T) {
+		// setup
+		broker, mem, protocol, release := setup(t)
+		closed := make(chan error, 1)
+
+		// execute
+		go func() {
+			closed <- broker.Close(context.Background())
+		}()
+
+		// verify
+		select {
+		case <-closed:
+			t.Fatal("closed before the batch in flight was done")
+		case <-time.After(20 * time.Millisecond):
+		}
+		close(release)
+		assert.NoError(t, <-closed)
+		assert.True(t, protocol.closed, "protocol closed")
+
+		tx, _ := mem.Begin(context.Background())
+		positions, err := mem.SubscriptionPositionLock(tx, streams.ParseId("close"), "A")
+		_ = tx.Rollback()
+		if assert.NoError(t, err) && assert.Len(t, positions, 1) {
+			assert.Equal(t, int64(1), positions[0].Position)
+		}
+
+		err = broker.Register(context.Background(), "B", streams.ParseId("close"), newCountingHandler())
+		assert.True(t, errors.Is(err, ErrClosed))
+	})
+
+	t.Run("context done first", func(t *testing.T) {
+		// setup
+		broker, _, protocol, release := setup(t)
+		defer close(release)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		// execute
+		err := broker.Close(ctx)
+
+		// verify
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.False(t, protocol.closed, "protocol closed")
+	})
+}
@@ -0,0 +1,94 @@
+package broker
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/go-po/po/internal/observer/binary"
+	"github.com/go-po/po/streams"
+)
+
+type Option func(broker *Broker)
+
+// Handles the subscriptions of each group every interval, plus up to jitter at random,
+// so records are processed even if their notification was lost.
+// A zero interval disables it.
+func WithCatchUp(interval, jitter time.Duration) Option {
+	return func(broker *Broker) {
+		broker.catchUpInterval = interval
+		broker.catchUpJitter = jitter
+	}
+}
+
+// Observes the group and error of failed catch-ups
+func WithCatchUpErrors(trace binary.ClientTrace) Option {
+	return func(broker *Broker) {
+		broker.onCatchUpError = trace
+	}
+}
+
+// Runs until the broker is closed, whichever subscriber started it.
+// Only the subscribers stored behind the head of their stream are run,
+// so subscribers at the head cost no transaction of their own.
+func (broker *Broker) catchUp(group string, sub Subscription) {
+	ctx := broker.ctx
+	for {
+		timer := time.NewTimer(broker.catchUpDelay())
+		select {
+		case <-broker.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		behind, err := broker.behind(ctx, group)
+		if err == nil {
+			_, err = sub.CatchUp(ctx, behind)
+		}
+		if err != nil && ctx.Err() == nil {
+			broker.onCatchUpError.Observe(ctx, group, err.Error())()
+		}
+	}
+}
+
+// Reports whether the position stored for a subscriber of the group is behind the head of its stream.
+// Subscribers without a stored position are behind, as are all of them if the store cannot tell.
+func (broker *Broker) behind(ctx context.Context, group string) (func(id streams.Id, subscriptionId string) bool, error) {
+	if broker.subscriptionList == nil || broker.positions == nil {
+		return func(id streams.Id, subscriptionId string) bool {
+			return true
+		}, nil
+	}
+	subscriptions, err := broker.subscriptionList.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	heads := make(map[string]int64)
+	atHead := make(map[string]bool)
+	for _, subscription := range subscriptions {
+		if subscription.Stream.Group != group {
+			continue
+		}
+		stream := subscription.Stream.String()
+		head, found := heads[stream]
+		if !found {
+			head, err = broker.positions.HeadPosition(ctx, subscription.Stream)
+			if err != nil {
+				return nil, err
+			}
+			heads[stream] = head
+		}
+		atHead[stream+"/"+subscription.SubscriptionId] = subscription.Position >= head
+	}
+	return func(id streams.Id, subscriptionId string) bool {
+		return !atHead[id.String()+"/"+subscriptionId]
+	}, nil
+}
+
+func (broker *Broker) catchUpDelay() time.Duration {
+	delay := broker.catchUpInterval
+	if broker.catchUpJitter > 0 {
+		delay = delay + time.Duration(rand.Int63n(int64(broker.catchUpJitter)))
+	}
+	return delay
+}
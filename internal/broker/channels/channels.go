@@ -13,6 +13,14 @@ func New() *Channels {
 
 type Channels struct{}
 
+// Channels only reach subscribers in this process, so there is no one to notify
+// of groups without a subscriber
+func (ch *Channels) Publisher(ctx context.Context, group string) (broker.RecordHandler, error) {
+	return broker.RecordHandlerFunc(func(ctx context.Context, record record.Record) (bool, error) {
+		return true, nil
+	}), nil
+}
+
 func (ch *Channels) Register(ctx context.Context, group string, input broker.RecordHandler) (broker.RecordHandler, error) {
 	return broker.RecordHandlerFunc(func(ctx context.Context, record record.Record) (bool, error) {
 		return input.Handle(ctx, record)
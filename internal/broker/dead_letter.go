@@ -0,0 +1,274 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/streams"
+	"github.com/google/uuid"
+)
+
+// number of records read per page of a dead-letter stream
+const pageSize = 50
+
+// group of the dead-letter streams, one for each subscriber
+const deadLetterGroup = "$dlq"
+
+// metadata keys of the records in dead-letter streams
+const (
+	deadLetterKey = "po.dead_letter"          // parked message
+	resolvedKey   = "po.dead_letter_resolved" // parked message replayed or discarded
+)
+
+// namespace of the message ids written to dead-letter streams,
+// making writing the same dead letter twice a no-op
+var deadLetterSpace = uuid.MustParse("0b5a3c36-3f5e-4a52-9b7e-1f6c1c5f9d21")
+
+// Stream the messages a subscriber gave up on are parked in
+func DeadLetterStream(subscriberId string) streams.Id {
+	return streams.Id{Group: deadLetterGroup, Entity: subscriberId}
+}
+
+// Message a subscriber gave up on
+type DeadLetter struct {
+	Number       int64           // position in the dead-letter stream, used to replay or discard it
+	SubscriberId string          // subscriber that gave up on the message
+	Error        string          // error of the last attempt
+	Attempts     int             // number of attempts made
+	Time         time.Time       // when the message was parked
+	Message      streams.Message // as read by the subscriber, with its original stream and numbers
+}
+
+type deadLetterHeader struct {
+	Stream       string    `json:"stream"`
+	Number       int64     `json:"number"`
+	GlobalNumber int64     `json:"global_number"`
+	MessageId    string    `json:"message_id"`
+	Time         time.Time `json:"time"`
+	Error        string    `json:"error"`
+	Attempts     int       `json:"attempts"`
+}
+
+type resolution struct {
+	Number int64  `json:"number"` // of the dead letter resolved
+	Action string `json:"action"`
+}
+
+// parks a copy of the record in the dead-letter stream of the subscriber
+func (sh *streamHandler) deadLetter(ctx context.Context, r record.Record, attempts int, cause error) error {
+	header := streams.Metadata{}
+	err := header.Set(deadLetterKey, deadLetterHeader{
+		Stream:       r.Stream.String(),
+		Number:       r.Number,
+		GlobalNumber: r.GlobalNumber,
+		MessageId:    r.MessageId,
+		Time:         r.Time,
+		Error:        cause.Error(),
+		Attempts:     attempts,
+	})
+	if err != nil {
+		return err
+	}
+	var messageId string
+	if r.MessageId != "" {
+		generation, err := resolvedGeneration(ctx, sh.store, sh.subscriberId, r.MessageId)
+		if err != nil {
+			return err
+		}
+		messageId = deadLetterMessageId(sh.subscriberId, r.MessageId)
+		if generation > 0 {
+			messageId = deadLetterMessageId(sh.subscriberId, fmt.Sprintf("%s/%d", r.MessageId, generation))
+		}
+	}
+	_, err = sh.store.WriteRecords(ctx, DeadLetterStream(sh.subscriberId), record.Data{
+		MessageId:     messageId,
+		ContentType:   r.ContentType,
+		Data:          r.Data,
+		CorrelationId: r.CorrelationId,
+		CausationId:   r.MessageId,
+		Metadata:      r.Metadata.Merge(header),
+	})
+	return err
+}
+
+func deadLetterMessageId(subscriberId, name string) string {
+	return uuid.NewSHA1(deadLetterSpace, []byte(subscriberId+"/"+name)).String()
+}
+
+// Number of times the message was parked by the subscriber and resolved since.
+// Parking it again after that is a new dead letter, rather than a write of the resolved one.
+func resolvedGeneration(ctx context.Context, reader RecordReader, subscriberId, messageId string) (int, error) {
+	parked, resolved, err := readDeadLetters(ctx, reader, subscriberId)
+	if err != nil {
+		return 0, err
+	}
+	generation := 0
+	for _, r := range parked {
+		header := deadLetterHeader{}
+		_, err := r.Metadata.Get(deadLetterKey, &header)
+		if err != nil {
+			return 0, err
+		}
+		if header.MessageId == messageId && resolved[r.Number] {
+			generation = generation + 1
+		}
+	}
+	return generation, nil
+}
+
+// parked records of the dead-letter stream of the subscriber, and the numbers of those resolved
+func readDeadLetters(ctx context.Context, reader RecordReader, subscriberId string) ([]record.Record, map[int64]bool, error) {
+	id := DeadLetterStream(subscriberId)
+	var parked []record.Record
+	resolved := make(map[int64]bool)
+	var from int64 = -1
+	for {
+		records, err := reader.ReadRecords(ctx, id, from, math.MaxInt64, pageSize)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, r := range records {
+			from = r.Number
+			res := resolution{}
+			found, err := r.Metadata.Get(resolvedKey, &res)
+			if err != nil {
+				return nil, nil, err
+			}
+			if found {
+				resolved[res.Number] = true
+				continue
+			}
+			parked = append(parked, r)
+		}
+		if len(records) < pageSize {
+			return parked, resolved, nil
+		}
+	}
+}
+
+// Messages parked by the subscriber, and not yet replayed or discarded
+func (broker *Broker) DeadLetters(ctx context.Context, subscriberId string) ([]DeadLetter, error) {
+	parked, resolved, err := readDeadLetters(ctx, broker.store, subscriberId)
+	if err != nil {
+		return nil, err
+	}
+
+	var letters []DeadLetter
+	for _, r := range parked {
+		if resolved[r.Number] {
+			continue
+		}
+		letter, err := broker.toDeadLetter(subscriberId, r)
+		if err != nil {
+			return nil, err
+		}
+		letters = append(letters, letter)
+	}
+	return letters, nil
+}
+
+func (broker *Broker) toDeadLetter(subscriberId string, r record.Record) (DeadLetter, error) {
+	header := deadLetterHeader{}
+	_, err := r.Metadata.Get(deadLetterKey, &header)
+	if err != nil {
+		return DeadLetter{}, err
+	}
+	msg, err := broker.registry.ToMessage(r)
+	if err != nil {
+		// parked as the subscriber could not decode it, so listed with its raw data
+		msg = streams.Message{Data: r.Data}
+	}
+	metadata := make(streams.Metadata, len(r.Metadata))
+	for key, value := range r.Metadata {
+		if key != deadLetterKey {
+			metadata[key] = value
+		}
+	}
+	msg.MessageId = header.MessageId
+	msg.Stream = streams.ParseId(header.Stream)
+	msg.Number = header.Number
+	msg.GlobalNumber = header.GlobalNumber
+	msg.Time = header.Time
+	msg.Metadata = metadata
+	return DeadLetter{
+		Number:       r.Number,
+		SubscriberId: subscriberId,
+		Error:        header.Error,
+		Attempts:     header.Attempts,
+		Time:         r.Time,
+		Message:      msg,
+	}, nil
+}
+
+// Hands the dead letter to the handler of the subscriber once more,
+// resolving it if the handler succeeds.
+// The subscriber must be registered with the broker.
+func (broker *Broker) ReplayDeadLetter(ctx context.Context, subscriberId string, number int64) error {
+	letter, err := broker.deadLetter(ctx, subscriberId, number)
+	if err != nil {
+		return err
+	}
+
+	// the handler might append, which needs the lock to publish
+	broker.mu.Lock()
+	var subs []Subscription
+	for _, sub := range broker.subscribers {
+		subs = append(subs, sub)
+	}
+	broker.mu.Unlock()
+
+	var replayed bool
+	for _, sub := range subs {
+		replayed, err = sub.Replay(ctx, subscriberId, letter.Message)
+		if replayed || err != nil {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if !replayed {
+		return fmt.Errorf("replay dead letter %d: %w: %s", number, ErrNoSubscriber, subscriberId)
+	}
+	return broker.resolve(ctx, subscriberId, number, "replayed")
+}
+
+// Resolves the dead letter without handling it
+func (broker *Broker) DiscardDeadLetter(ctx context.Context, subscriberId string, number int64) error {
+	_, err := broker.deadLetter(ctx, subscriberId, number)
+	if err != nil {
+		return err
+	}
+	return broker.resolve(ctx, subscriberId, number, "discarded")
+}
+
+func (broker *Broker) deadLetter(ctx context.Context, subscriberId string, number int64) (DeadLetter, error) {
+	letters, err := broker.DeadLetters(ctx, subscriberId)
+	if err != nil {
+		return DeadLetter{}, err
+	}
+	for _, letter := range letters {
+		if letter.Number == number {
+			return letter, nil
+		}
+	}
+	return DeadLetter{}, fmt.Errorf("%w: %d in [%s]", ErrDeadLetterNotFound, number, DeadLetterStream(subscriberId))
+}
+
+func (broker *Broker) resolve(ctx context.Context, subscriberId string, number int64, action string) error {
+	metadata := streams.Metadata{}
+	err := metadata.Set(resolvedKey, resolution{Number: number, Action: action})
+	if err != nil {
+		return err
+	}
+	_, err = broker.store.WriteRecords(ctx, DeadLetterStream(subscriberId), record.Data{
+		MessageId:   deadLetterMessageId(subscriberId, fmt.Sprintf("resolved/%d", number)),
+		ContentType: "application/json",
+		Data:        []byte(`{}`),
+		Metadata:    metadata,
+	})
+	return err
+}
@@ -0,0 +1,156 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store/inmemory"
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroker_DeadLetters(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T, group string, handler Handler) *Broker {
+		t.Helper()
+		mem := inmemory.New()
+		_, err := mem.WriteRecords(ctx, streams.ParseId("%s-a", group), record.Data{
+			ContentType: "application/json",
+			Data:        []byte(`{}`),
+			MessageId:   "a6a1a3d0-4c4b-4cb6-8a8e-3d8e0b3f0d3e",
+		})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		protocol := &mockProtocol{}
+		broker := New(mem, &mockRegistry{}, protocol)
+		err = broker.Register(ctx, "sub", streams.ParseId(group), handler, WithErrorPolicy(ErrorPolicy{
+			MaxAttempts: 1,
+			OnFailure:   DeadLetterOnFailure,
+		}))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		_, err = protocol.publish(t, record.Record{Stream: streams.ParseId("%s-a", group), Group: group})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return broker
+	}
+
+	t.Run("replay", func(t *testing.T) {
+		// setup
+		handler := &mockPoisonHandler{poison: "a", failures: 1}
+		broker := setup(t, "replay", handler)
+
+		// execute
+		err := broker.ReplayDeadLetter(ctx, "sub", 0)
+
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "a"}, handler.calls)
+		letters, err := broker.DeadLetters(ctx, "sub")
+		assert.NoError(t, err)
+		assert.Empty(t, letters)
+	})
+
+	t.Run("replay appending", func(t *testing.T) {
+		// setup
+		var broker *Broker
+		poison := &mockPoisonHandler{poison: "a", failures: 1}
+		handler := streams.HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+			err := poison.Handle(ctx, msg)
+			if err != nil {
+				return err
+			}
+			return broker.Notify(ctx, record.Record{Stream: streams.ParseId("appended-1"), Group: "appended"})
+		})
+		broker = setup(t, "appending", handler)
+		broker.publishers["appended"] = RecordHandlerFunc(func(ctx context.Context, record record.Record) (bool, error) {
+			return true, nil
+		})
+
+		// execute
+		done := make(chan error, 1)
+		go func() {
+			done <- broker.ReplayDeadLetter(ctx, "sub", 0)
+		}()
+
+		// verify
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			assert.Fail(t, "replay deadlocked")
+		}
+	})
+
+	t.Run("replay failing", func(t *testing.T) {
+		// setup
+		handler := &mockPoisonHandler{poison: "a", failures: -1}
+		broker := setup(t, "failing", handler)
+
+		// execute
+		err := broker.ReplayDeadLetter(ctx, "sub", 0)
+
+		// verify
+		assert.Error(t, err)
+		letters, err := broker.DeadLetters(ctx, "sub")
+		assert.NoError(t, err)
+		assert.Len(t, letters, 1, "still parked")
+	})
+
+	t.Run("discard", func(t *testing.T) {
+		// setup
+		handler := &mockPoisonHandler{poison: "a", failures: -1}
+		broker := setup(t, "discard", handler)
+
+		// execute
+		err := broker.DiscardDeadLetter(ctx, "sub", 0)
+
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a"}, handler.calls)
+		letters, err := broker.DeadLetters(ctx, "sub")
+		assert.NoError(t, err)
+		assert.Empty(t, letters)
+		err = broker.DiscardDeadLetter(ctx, "sub", 0)
+		assert.True(t, errors.Is(err, ErrDeadLetterNotFound), "already resolved")
+	})
+
+	t.Run("parked again once resolved", func(t *testing.T) {
+		// setup
+		handler := &mockPoisonHandler{poison: "a", failures: -1}
+		broker := setup(t, "again", handler)
+		WithPositions(broker.store.(PositionStore))(broker)
+		if !assert.NoError(t, broker.DiscardDeadLetter(ctx, "sub", 0)) {
+			t.FailNow()
+		}
+
+		// execute
+		err := broker.MoveSubscriber(ctx, "sub", streams.ParseId("again"), 0, nil)
+
+		// verify
+		assert.NoError(t, err)
+		assert.Eventually(t, func() bool {
+			letters, err := broker.DeadLetters(ctx, "sub")
+			return err == nil && len(letters) == 1 && letters[0].Number > 0
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("unknown subscriber", func(t *testing.T) {
+		// setup
+		handler := &mockPoisonHandler{poison: "a", failures: -1}
+		broker := setup(t, "unknown", handler)
+
+		// execute
+		err := broker.ReplayDeadLetter(ctx, "other", 0)
+
+		// verify
+		assert.True(t, errors.Is(err, ErrDeadLetterNotFound))
+	})
+}
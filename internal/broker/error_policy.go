@@ -0,0 +1,81 @@
+package broker
+
+import (
+	"time"
+
+	"github.com/go-po/po/internal/observer/binary"
+)
+
+// What a subscriber does with a message its handler keeps failing on
+type FailureAction int
+
+const (
+	StopOnFailure       FailureAction = iota // stops at the message until it is handled
+	SkipOnFailure                            // moves on to the next message
+	DeadLetterOnFailure                      // parks the message in the dead-letter stream of the subscriber and moves on
+)
+
+func (action FailureAction) String() string {
+	switch action {
+	case SkipOnFailure:
+		return "skip"
+	case DeadLetterOnFailure:
+		return "dead_letter"
+	default:
+		return "stop"
+	}
+}
+
+// Decides how a subscriber handles errors from its handler
+type ErrorPolicy struct {
+	MaxAttempts int                           // attempts including the first one
+	Delay       func(retry int) time.Duration // delay before the given retry, counting from 1
+	OnFailure   FailureAction                 // once all attempts failed
+}
+
+func (policy ErrorPolicy) attempts() int {
+	if policy.MaxAttempts < 1 {
+		return 1
+	}
+	return policy.MaxAttempts
+}
+
+func (policy ErrorPolicy) delay(retry int) time.Duration {
+	if policy.Delay == nil {
+		return 0
+	}
+	return policy.Delay(retry)
+}
+
+type SubscriberOption func(sh *streamHandler)
+
+func WithErrorPolicy(policy ErrorPolicy) SubscriberOption {
+	return func(sh *streamHandler) {
+		sh.policy = policy
+	}
+}
+
+// Number of records the subscriber processes per transaction
+func WithBatchSize(size int) SubscriberOption {
+	return func(sh *streamHandler) {
+		if size > 0 {
+			sh.batchSize = size
+		}
+	}
+}
+
+func observeSubscriber(onError, onFailure binary.ClientTrace) SubscriberOption {
+	return func(sh *streamHandler) {
+		sh.onError = onError
+		sh.onFailure = onFailure
+	}
+}
+
+// Observes the subscriber id and error of every failed attempt,
+// and the subscriber id and action taken once all attempts failed
+func WithSubscriberErrors(onError, onFailure binary.ClientTrace) Option {
+	return func(broker *Broker) {
+		broker.onSubscriberError = onError
+		broker.onSubscriberFailure = onFailure
+	}
+}
@@ -0,0 +1,194 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store/inmemory"
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+// handler failing on the message with the given entity, counting all calls
+type mockPoisonHandler struct {
+	poison   string
+	failures int // before succeeding on the poison message, negative to always fail
+	calls    []string
+}
+
+func (mock *mockPoisonHandler) Handle(ctx context.Context, msg streams.Message) error {
+	mock.calls = append(mock.calls, msg.Stream.Entity)
+	if msg.Stream.Entity == mock.poison && mock.failures != 0 {
+		mock.failures = mock.failures - 1
+		return fmt.Errorf("poison %s", msg.Stream)
+	}
+	return nil
+}
+
+// registry failing to decode the records of the given entity
+type mockUndecodableRegistry struct {
+	mockRegistry
+	poison string
+}
+
+func (mock mockUndecodableRegistry) ToMessage(r record.Record) (streams.Message, error) {
+	if r.Stream.Entity == mock.poison {
+		return streams.Message{}, fmt.Errorf("undecodable %s", r.Stream)
+	}
+	return mock.mockRegistry.ToMessage(r)
+}
+
+func TestStreamHandler_ErrorPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	setupRegistry := func(t *testing.T, group string, registry Registry, handler Handler, policy ErrorPolicy) (*Broker, *inmemory.InMemory, *mockProtocol) {
+		t.Helper()
+		mem := inmemory.New()
+		for _, entity := range []string{"a", "b", "c"} {
+			_, err := mem.WriteRecords(ctx, streams.ParseId("%s-%s", group, entity), record.Data{
+				ContentType: "application/json",
+				Data:        []byte(`{}`),
+			})
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+		}
+		protocol := &mockProtocol{}
+		broker := New(mem, registry, protocol)
+		err := broker.Register(ctx, "sub", streams.ParseId(group), handler, WithErrorPolicy(policy))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return broker, mem, protocol
+	}
+	setup := func(t *testing.T, group string, handler Handler, policy ErrorPolicy) (*Broker, *inmemory.InMemory, *mockProtocol) {
+		t.Helper()
+		return setupRegistry(t, group, &mockRegistry{}, handler, policy)
+	}
+
+	t.Run("retry until handled", func(t *testing.T) {
+		// setup
+		handler := &mockPoisonHandler{poison: "b", failures: 2}
+		_, _, protocol := setup(t, "retry", handler, ErrorPolicy{MaxAttempts: 3})
+
+		// execute
+		_, err := protocol.publish(t, record.Record{Stream: streams.ParseId("retry-c"), Group: "retry"})
+
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "b", "b", "c"}, handler.calls)
+	})
+
+	t.Run("retry with delay", func(t *testing.T) {
+		// setup
+		handler := &mockPoisonHandler{poison: "b", failures: 1}
+		var retries []int
+		_, _, protocol := setup(t, "delay", handler, ErrorPolicy{MaxAttempts: 2, Delay: func(retry int) time.Duration {
+			retries = append(retries, retry)
+			return time.Millisecond
+		}})
+
+		// execute
+		_, err := protocol.publish(t, record.Record{Stream: streams.ParseId("delay-c"), Group: "delay"})
+
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, []int{1}, retries)
+		assert.Equal(t, []string{"a", "b", "b", "c"}, handler.calls)
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		// setup
+		handler := &mockPoisonHandler{poison: "b", failures: -1}
+		_, _, protocol := setup(t, "stop", handler, ErrorPolicy{MaxAttempts: 2, OnFailure: StopOnFailure})
+
+		// execute
+		_, err := protocol.publish(t, record.Record{Stream: streams.ParseId("stop-c"), Group: "stop"})
+		_, _ = protocol.publish(t, record.Record{Stream: streams.ParseId("stop-c"), Group: "stop"})
+
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "b", "b", "b"}, handler.calls, "stopped at b")
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		// setup
+		handler := &mockPoisonHandler{poison: "b", failures: -1}
+		broker, _, protocol := setup(t, "skip", handler, ErrorPolicy{MaxAttempts: 2, OnFailure: SkipOnFailure})
+
+		// execute
+		_, err := protocol.publish(t, record.Record{Stream: streams.ParseId("skip-c"), Group: "skip"})
+		_, _ = protocol.publish(t, record.Record{Stream: streams.ParseId("skip-c"), Group: "skip"})
+
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "b", "c"}, handler.calls)
+		letters, err := broker.DeadLetters(ctx, "sub")
+		assert.NoError(t, err)
+		assert.Empty(t, letters)
+	})
+
+	t.Run("dead letter", func(t *testing.T) {
+		// setup
+		handler := &mockPoisonHandler{poison: "b", failures: -1}
+		broker, _, protocol := setup(t, "dlq", handler, ErrorPolicy{MaxAttempts: 2, OnFailure: DeadLetterOnFailure})
+
+		// execute
+		_, err := protocol.publish(t, record.Record{Stream: streams.ParseId("dlq-c"), Group: "dlq"})
+
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "b", "c"}, handler.calls)
+		letters, err := broker.DeadLetters(ctx, "sub")
+		assert.NoError(t, err)
+		if assert.Len(t, letters, 1) {
+			letter := letters[0]
+			assert.Equal(t, int64(0), letter.Number)
+			assert.Equal(t, "sub", letter.SubscriberId)
+			assert.Equal(t, "poison dlq-b", letter.Error)
+			assert.Equal(t, 2, letter.Attempts)
+			assert.Equal(t, "dlq-b", letter.Message.Stream.String())
+			assert.Equal(t, int64(0), letter.Message.Number)
+			assert.Equal(t, int64(2), letter.Message.GlobalNumber)
+			assert.NotContains(t, letter.Message.Metadata, deadLetterKey)
+		}
+	})
+
+	t.Run("undecodable stop", func(t *testing.T) {
+		// setup
+		handler := &mockPoisonHandler{}
+		_, _, protocol := setupRegistry(t, "undecodable", mockUndecodableRegistry{poison: "b"}, handler, ErrorPolicy{MaxAttempts: 2, OnFailure: StopOnFailure})
+
+		// execute
+		_, err := protocol.publish(t, record.Record{Stream: streams.ParseId("undecodable-c"), Group: "undecodable"})
+
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a"}, handler.calls, "stopped at b")
+	})
+
+	t.Run("undecodable dead letter", func(t *testing.T) {
+		// setup
+		handler := &mockPoisonHandler{}
+		broker, _, protocol := setupRegistry(t, "undecodable", mockUndecodableRegistry{poison: "b"}, handler, ErrorPolicy{MaxAttempts: 2, OnFailure: DeadLetterOnFailure})
+
+		// execute
+		_, err := protocol.publish(t, record.Record{Stream: streams.ParseId("undecodable-c"), Group: "undecodable"})
+
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "c"}, handler.calls)
+		letters, err := broker.DeadLetters(ctx, "sub")
+		assert.NoError(t, err)
+		if assert.Len(t, letters, 1) {
+			letter := letters[0]
+			assert.Equal(t, "undecodable undecodable-b", letter.Error)
+			assert.Equal(t, 1, letter.Attempts, "not retried")
+			assert.Equal(t, "undecodable-b", letter.Message.Stream.String())
+			assert.Equal(t, []byte(`{}`), letter.Message.Data, "raw data")
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package broker
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-po/po/streams"
+)
+
+var (
+	ErrNotifyFailed = errors.New("notify failed")
+	ErrNoSubscriber = errors.New("missing subscriber")
+	ErrSubscribed   = errors.New("subscriber already registered")
+	ErrClosed       = errors.New("broker closed")
+
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+)
+
+// Returned when subscribers could not be notified of a message
+type NotifyError struct {
+	Stream streams.Id
+	Number int64
+	Err    error
+}
+
+func (err NotifyError) Error() string {
+	return fmt.Sprintf("notify %s:%d: %s", err.Stream, err.Number, err.Err)
+}
+
+func (err NotifyError) Is(target error) bool {
+	return target == ErrNotifyFailed
+}
+
+func (err NotifyError) Unwrap() error {
+	return err.Err
+}
@@ -0,0 +1,69 @@
+package broker
+
+import (
+	"context"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
+)
+
+// Reads the records of a stream, implemented by every store
+type RecordReader interface {
+	ReadRecords(ctx context.Context, id streams.Id, from, to, limit int64) ([]record.Record, error)
+}
+
+// Implemented by stores able to read the records of some content types only, without fetching the others
+type FilterStore interface {
+	// Records of the content types after from, up to to, and the position the read got to.
+	// Reaching the limit, the position is the one of the last record returned.
+	// Otherwise the read covered the range up to the head of the stream, and the position is past the records skipped.
+	ReadRecordsOfTypes(ctx context.Context, id streams.Id, from, to, limit int64, contentTypes []string) ([]record.Record, int64, error)
+}
+
+// Reads the records of the content types, or every record when there is none, and the position the read got to.
+// Stores unable to filter are read in full, and the records of other types dropped before they are decoded.
+func ReadRecordsOfTypes(ctx context.Context, reader RecordReader, id streams.Id, from, to, limit int64, contentTypes []string) ([]record.Record, int64, error) {
+	if len(contentTypes) == 0 {
+		records, err := reader.ReadRecords(ctx, id, from, to, limit)
+		if err != nil || len(records) == 0 {
+			return records, from, err
+		}
+		return records, store.RecordPosition(id, records[len(records)-1]), nil
+	}
+	if filter, ok := reader.(FilterStore); ok {
+		return filter.ReadRecordsOfTypes(ctx, id, from, to, limit, contentTypes)
+	}
+
+	position := from
+	var result []record.Record
+	for {
+		records, err := reader.ReadRecords(ctx, id, position, to, limit)
+		if err != nil {
+			return nil, from, err
+		}
+		for _, r := range records {
+			position = store.RecordPosition(id, r)
+			if !store.IsContentType(r.ContentType, contentTypes) {
+				continue
+			}
+			result = append(result, r)
+			if int64(len(result)) >= limit {
+				return result, position, nil
+			}
+		}
+		if int64(len(records)) < limit {
+			return result, position, nil
+		}
+	}
+}
+
+// Delivers the records of the content types only, all of them when there is none,
+// and the messages the predicate accepts, if any.
+// The position of the subscriber moves past the messages skipped.
+func Filtering(contentTypes []string, predicate func(msg streams.Message) bool) SubscriberOption {
+	return func(sh *streamHandler) {
+		sh.contentTypes = contentTypes
+		sh.predicate = predicate
+	}
+}
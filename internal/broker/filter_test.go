@@ -0,0 +1,119 @@
+package broker
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store/inmemory"
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTyped(t *testing.T, mem *inmemory.InMemory, id streams.Id, contentTypes ...string) {
+	for _, contentType := range contentTypes {
+		_, err := mem.WriteRecords(context.Background(), id, record.Data{ContentType: contentType, Data: []byte(`{}`)})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+	}
+}
+
+func TestReadRecordsOfTypes(t *testing.T) {
+	ctx := context.Background()
+	id := streams.ParseId("typed-1")
+	mem := inmemory.New()
+	writeTyped(t, mem, id, "a", "b", "b", "b", "a", "b")
+
+	tests := []struct {
+		name         string
+		reader       RecordReader
+		limit        int64
+		contentTypes []string
+		numbers      []int64
+		position     int64
+	}{
+		{name: "all types", reader: mem, limit: 4, numbers: []int64{0, 1, 2, 3}, position: 3},
+		{name: "filtered by the store", reader: mem, limit: 100, contentTypes: []string{"a"}, numbers: []int64{0, 4}, position: 5},
+		{name: "filtered once read", reader: struct{ RecordReader }{mem}, limit: 100, contentTypes: []string{"a"}, numbers: []int64{0, 4}, position: 5},
+		{name: "limit filtered by the store", reader: mem, limit: 1, contentTypes: []string{"a"}, numbers: []int64{0}, position: 0},
+		{name: "limit filtered once read", reader: struct{ RecordReader }{mem}, limit: 2, contentTypes: []string{"a"}, numbers: []int64{0, 4}, position: 4},
+		{name: "none of the types", reader: struct{ RecordReader }{mem}, limit: 2, contentTypes: []string{"c"}, position: 5},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// execute
+			records, position, err := ReadRecordsOfTypes(ctx, test.reader, id, -1, math.MaxInt64, test.limit, test.contentTypes)
+			// verify
+			assert.NoError(t, err)
+			assert.Equal(t, test.position, position)
+			var numbers []int64
+			for _, r := range records {
+				numbers = append(numbers, r.Number)
+			}
+			assert.Equal(t, test.numbers, numbers)
+		})
+	}
+}
+
+func TestBroker_RegisterFiltering(t *testing.T) {
+	ctx := context.Background()
+	group := streams.ParseId("filtered")
+
+	position := func(t *testing.T, mem *inmemory.InMemory, subscriberId string) int64 {
+		tx, err := mem.Begin(ctx)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer func() {
+			_ = tx.Rollback()
+		}()
+		positions, err := mem.SubscriptionPositionLock(tx, group, subscriberId)
+		if !assert.NoError(t, err) || !assert.Equal(t, 1, len(positions)) {
+			t.FailNow()
+		}
+		return positions[0].Position
+	}
+
+	tests := []struct {
+		name         string
+		contentTypes []string
+		predicate    func(msg streams.Message) bool
+		want         []int64
+	}{
+		{
+			name:         "content types",
+			contentTypes: []string{"a"},
+			want:         []int64{1, 3},
+		},
+		{
+			name:      "predicate",
+			predicate: func(msg streams.Message) bool { return msg.GlobalNumber > 2 },
+			want:      []int64{3, 4, 5},
+		},
+		{
+			name:         "content types and predicate",
+			contentTypes: []string{"b"},
+			predicate:    func(msg streams.Message) bool { return msg.GlobalNumber < 4 },
+			want:         []int64{2},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// setup
+			mem := inmemory.New()
+			broker := New(mem, &mockRegistry{}, &mockProtocol{})
+			writeTyped(t, mem, group.WithEntity("1"), "a", "b", "a", "b", "c")
+			log := &mockNumberLog{}
+			// execute
+			err := broker.Register(ctx, "F", group, log, Filtering(test.contentTypes, test.predicate))
+			broker.wake(group.Group)
+			// verify
+			assert.NoError(t, err)
+			assert.Eventually(t, func() bool { return position(t, mem, "F") == 5 }, time.Second, 10*time.Millisecond)
+			assert.Equal(t, test.want, log.numbers())
+		})
+	}
+}
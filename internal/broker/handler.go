@@ -2,46 +2,94 @@ package broker
 
 import (
 	"context"
+	"math"
+	"sync"
+	"time"
 
+	"github.com/go-po/po/internal/observer/binary"
+	"github.com/go-po/po/internal/record"
 	"github.com/go-po/po/internal/store"
 	"github.com/go-po/po/streams"
 )
 
-func newStreamHandler(id streams.Id, subscriberId string, store Store, inner Handler) *streamHandler {
-	return &streamHandler{
-		id:       subscriberId,
-		store:    store,
-		handler:  inner,
-		stream:   id,
-		position: -1,
+// number of records processed per transaction, unless set with WithBatchSize
+const DefaultBatchSize = 50
+
+func newStreamHandler(id streams.Id, subscriberId string, store Store, registry Registry, inner Handler) *streamHandler {
+	sh := &streamHandler{
+		id:           subscriberId,
+		subscriberId: subscriberId,
+		store:        store,
+		registry:     registry,
+		handler:      inner,
+		stream:       id,
+		position:     -1,
+		batchSize:    DefaultBatchSize,
+		onError:      binary.Noop(),
+		onFailure:    binary.Noop(),
 	}
+	sh.idle = sync.NewCond(&sh.mu)
+	return sh
 }
 
 type streamHandler struct {
-	id       string
-	handler  Handler
-	stream   streams.Id
-	position int64
-	store    Store
+	id           string // of the position
+	subscriberId string // as registered
+	handler      Handler
+	stream       streams.Id
+	position     int64
+	store        Store
+	registry     Registry
+	batchSize    int
+	policy       ErrorPolicy
+	start        Start                          // of a new subscriber, nil for the beginning
+	contentTypes []string                       // of the records read, all of them when empty
+	predicate    func(msg streams.Message) bool // of the messages delivered, nil for all of them
+	heldBack     HeldBackStore                  // nil if the store holds back no records
+	onError      binary.ClientTrace             // subscriber id, error
+	onFailure    binary.ClientTrace             // subscriber id, action
+
+	partition  int             // of the streams handled, when partitioned
+	partitions int             // zero when not partitioned
+	lease      *partitionLease // ownership of the partition
+
+	mu      sync.Mutex // guards the fields below
+	running bool       // processing records
+	held    bool       // records were held back from the last batch
+	pending bool       // records notified while running
+	stopped bool       // removed from the subscription
+	idle    *sync.Cond // signalled when it stops running
 }
 
-func (sh *streamHandler) Handle(ctx context.Context, msg streams.Message) error {
-	var nextPosition int64
+// message read for the subscribers, along with the record it was read from
+type delivery struct {
+	record record.Record
+	msg    streams.Message
+}
+
+// position of the subscriber after the message, false if it is not for the subscriber
+func (sh *streamHandler) next(msg streams.Message) (int64, bool) {
 	if sh.stream.HasEntity() {
 		if sh.stream.Entity != msg.Stream.Entity {
-			return nil
-		}
-		if msg.Number > sh.position {
-			return nil
+			return 0, false
 		}
-		nextPosition = msg.Number
-	} else {
-		if msg.GlobalNumber <= sh.position {
-			return nil
+		if msg.Number <= sh.position {
+			return 0, false
 		}
-		nextPosition = msg.GlobalNumber
+		return msg.Number, true
 	}
-	err := sh.handler.Handle(ctx, msg)
+	if msg.GlobalNumber <= sh.position {
+		return 0, false
+	}
+	return msg.GlobalNumber, true
+}
+
+func (sh *streamHandler) Handle(ctx context.Context, msg streams.Message) error {
+	nextPosition, ok := sh.next(msg)
+	if !ok {
+		return nil
+	}
+	err := sh.handler.Handle(streams.ContextWithMessage(ctx, msg), msg)
 	if err != nil {
 		return err
 	}
@@ -49,24 +97,232 @@ func (sh *streamHandler) Handle(ctx context.Context, msg streams.Message) error
 	return nil
 }
 
-func (sh *streamHandler) Process(ctx context.Context, tx store.Tx, inbox <-chan streams.Message) {
-	failed := false
-	for msg := range inbox {
-		if failed {
+// Handles the message as decided by the error policy.
+// Returns an error if the subscriber must stop at the message.
+func (sh *streamHandler) deliver(ctx context.Context, d delivery) error {
+	attempts := sh.policy.attempts()
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			if err := sleep(ctx, sh.policy.delay(attempt-1)); err != nil {
+				return err
+			}
+		}
+		err = sh.Handle(ctx, d.msg)
+		if err == nil {
+			return nil
+		}
+		sh.onError.Observe(ctx, sh.subscriberId, err.Error())()
+	}
+	return sh.fail(ctx, d.record, attempts, err)
+}
+
+// Takes the failure action of the error policy on the record, once all attempts failed.
+// Returns an error if the subscriber must stop at the record.
+func (sh *streamHandler) fail(ctx context.Context, r record.Record, attempts int, err error) error {
+	sh.onFailure.Observe(ctx, sh.subscriberId, sh.policy.OnFailure.String())()
+	switch sh.policy.OnFailure {
+	case SkipOnFailure:
+	case DeadLetterOnFailure:
+		dlqErr := sh.deadLetter(ctx, r, attempts, err)
+		if dlqErr != nil {
+			sh.onError.Observe(ctx, sh.subscriberId, dlqErr.Error())()
+			return err
+		}
+	default:
+		return err
+	}
+	if sh.stream.HasEntity() {
+		sh.position = r.Number
+	} else {
+		sh.position = r.GlobalNumber
+	}
+	return nil
+}
+
+// Reports true if the caller must run the handler.
+// If it is already running, it runs once more when done instead.
+func (sh *streamHandler) claim() bool {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if sh.stopped {
+		return false
+	}
+	if sh.running {
+		sh.pending = true
+		return false
+	}
+	sh.running = true
+	return true
+}
+
+// processes batches until the subscriber is at the head of the stream,
+// or stopped by the error policy
+func (sh *streamHandler) run(ctx context.Context) error {
+	for {
+		err := sh.process(ctx)
+
+		sh.mu.Lock()
+		again := err == nil && sh.pending && !sh.stopped
+		sh.pending = false
+		sh.running = again
+		if !again {
+			sh.idle.Broadcast()
+		}
+		sh.mu.Unlock()
+
+		if !again {
+			return err
+		}
+	}
+}
+
+func (sh *streamHandler) process(ctx context.Context) error {
+	for {
+		more, err := sh.processBatch(ctx)
+		if err != nil || !more || sh.isStopped() {
+			return err
+		}
+	}
+}
+
+// Stops handling records, waiting for the batch in flight to commit
+func (sh *streamHandler) stop() {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	sh.stopped = true
+	for sh.running {
+		sh.idle.Wait()
+	}
+}
+
+// Reports true if the store held back records after the last batch processed
+func (sh *streamHandler) isHeldBack() bool {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	return sh.held
+}
+
+func (sh *streamHandler) isStopped() bool {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	return sh.stopped
+}
+
+// Handles the next batch of records after the position of the subscriber, and moves it in the same transaction.
+// Reports true if there might be more records to handle.
+func (sh *streamHandler) processBatch(ctx context.Context) (bool, error) {
+	if sh.lease != nil {
+		if !sh.lease.enter() {
+			return false, nil
+		}
+		defer sh.lease.exit()
+	}
+
+	tx, err := sh.store.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	positions, err := sh.store.SubscriptionPositionLock(tx, sh.stream, sh.id)
+	if err != nil {
+		return false, err
+	}
+	sh.position = -1
+	for _, position := range positions {
+		if position.SubscriptionId == sh.id {
+			sh.position = position.Position
+		}
+	}
+	start := sh.position
+
+	// Group subscribers read by global number, which is shared between groups,
+	// so the count of records read can not be used to find the start of the next batch.
+	read := streams.ParseId(sh.stream.Group)
+	if sh.stream.HasEntity() {
+		read = sh.stream
+	}
+	records, readPosition, err := ReadRecordsOfTypes(ctx, sh.store, read, sh.position, math.MaxInt64, int64(sh.batchSize), sh.contentTypes)
+	if err != nil {
+		return false, err
+	}
+
+	stopped := false
+	for _, r := range records {
+		if sh.partitions > 0 && partitionOf(r.Stream, sh.partitions) != sh.partition {
+			sh.position = r.GlobalNumber
 			continue
 		}
-		err := sh.Handle(ctx, msg)
+		msg, err := sh.registry.ToMessage(r)
 		if err != nil {
-			// TODO log this error
-			failed = true
+			// decoding again gives the same error, so it is not retried
+			sh.onError.Observe(ctx, sh.subscriberId, err.Error())()
+			err = sh.fail(ctx, r, 1, err)
+			if err != nil {
+				stopped = true
+				break
+			}
+			continue
 		}
+		if sh.predicate != nil && !sh.predicate(msg) {
+			if nextPosition, ok := sh.next(msg); ok {
+				sh.position = nextPosition
+			}
+			continue
+		}
+		err = sh.deliver(ctx, delivery{record: r, msg: msg})
+		if err != nil {
+			stopped = true
+			break
+		}
+	}
+
+	// records of other types were skipped by the read
+	if !stopped && len(sh.contentTypes) > 0 && readPosition > sh.position {
+		sh.position = readPosition
 	}
-	err := sh.store.SetSubscriptionPosition(tx, sh.stream, store.SubscriptionPosition{
-		SubscriptionId: sh.id,
-		Position:       sh.position,
-	})
+
+	if sh.position != start {
+		err = sh.store.SetSubscriptionPosition(tx, sh.stream, store.SubscriptionPosition{
+			SubscriptionId: sh.id,
+			Position:       sh.position,
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+	err = tx.Commit()
 	if err != nil {
-		// TODO log this error
+		return false, err
+	}
+	more := !stopped && sh.position != start && len(records) >= sh.batchSize
+	held := false
+	if !more && !stopped && sh.heldBack != nil {
+		held, err = sh.heldBack.HeldBack(ctx, read, sh.position)
+		if err != nil {
+			return false, err
+		}
 	}
+	sh.mu.Lock()
+	sh.held = held
+	sh.mu.Unlock()
+	return more, nil
+}
 
+// waits for the delay, unless the context is done first
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
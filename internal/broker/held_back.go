@@ -0,0 +1,53 @@
+package broker
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-po/po/streams"
+)
+
+// delay before reading records held back by the store again, unless set with WithHeldBack
+const DefaultHeldBackDelay = 100 * time.Millisecond
+
+// Implemented by stores holding back committed records of groups while older transactions are still running,
+// as records numbered before them might still become visible.
+// Nothing notifies of the records once they are released, so the broker reads them again after a delay.
+type HeldBackStore interface {
+	// Reports true if records of the group after the position are held back
+	HeldBack(ctx context.Context, id streams.Id, position int64) (bool, error)
+}
+
+// Reads the groups again after the delay, as long as the store holds back records after the position of a subscriber
+func WithHeldBack(store HeldBackStore, delay time.Duration) Option {
+	return func(broker *Broker) {
+		broker.heldBack = store
+		broker.heldBackDelay = delay
+	}
+}
+
+// Wakes the subscription of the group once the delay is over, unless the broker is closed first.
+// A group already waiting is not woken twice.
+func (broker *Broker) wakeHeldBack(group string) {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	if broker.closed || broker.heldBackWaiting[group] {
+		return
+	}
+	broker.heldBackWaiting[group] = true
+	broker.background.Add(1)
+	go func() {
+		defer broker.background.Done()
+		timer := time.NewTimer(broker.heldBackDelay)
+		defer timer.Stop()
+		select {
+		case <-broker.done:
+			return
+		case <-timer.C:
+		}
+		broker.mu.Lock()
+		delete(broker.heldBackWaiting, group)
+		broker.mu.Unlock()
+		broker.wake(group)
+	}()
+}
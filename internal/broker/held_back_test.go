@@ -0,0 +1,65 @@
+package broker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store/inmemory"
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+// store holding back the records after a position, as if written after a transaction still running
+type holdingStore struct {
+	*inmemory.InMemory
+	mu       sync.Mutex
+	after    int64
+	released bool
+}
+
+func (s *holdingStore) ReadRecords(ctx context.Context, id streams.Id, from, to, limit int64) ([]record.Record, error) {
+	s.mu.Lock()
+	if !s.released && to > s.after {
+		to = s.after
+	}
+	s.mu.Unlock()
+	return s.InMemory.ReadRecords(ctx, id, from, to, limit)
+}
+
+func (s *holdingStore) HeldBack(ctx context.Context, id streams.Id, position int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.released, nil
+}
+
+func (s *holdingStore) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.released = true
+}
+
+func TestBroker_HeldBack(t *testing.T) {
+	// setup
+	ctx := context.Background()
+	group := streams.ParseId("held")
+	mem := &holdingStore{InMemory: inmemory.New(), after: 1}
+	writeTyped(t, mem.InMemory, group.WithEntity("1"), "a", "a", "a")
+	broker := New(mem, &mockRegistry{}, &mockProtocol{}, WithHeldBack(mem, 10*time.Millisecond))
+	defer func() {
+		_ = broker.Close(ctx)
+	}()
+	log := &mockNumberLog{}
+	assert.NoError(t, broker.Register(ctx, "H", group, log))
+	broker.wake(group.Group)
+	assert.Eventually(t, func() bool { return len(log.numbers()) == 1 }, time.Second, time.Millisecond)
+
+	// execute
+	mem.release()
+
+	// verify
+	assert.Eventually(t, func() bool { return len(log.numbers()) == 3 }, time.Second, time.Millisecond, "read again without a notification")
+	assert.Equal(t, []int64{1, 2, 3}, log.numbers())
+}
@@ -0,0 +1,142 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/go-po/po/internal/observer/binary"
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
+)
+
+var ErrNoSubscriptionList = errors.New("store cannot list subscriptions")
+
+// Implemented by stores able to list the subscriptions, and count the records behind them
+type SubscriptionStore interface {
+	// Stored positions of every subscription
+	ListSubscriptions(ctx context.Context) ([]store.Subscription, error)
+	// Number of records of the stream after the position
+	CountRecords(ctx context.Context, id streams.Id, after int64) (int64, error)
+}
+
+// Subscriptions listed by Subscriptions
+func WithSubscriptionList(subscriptions SubscriptionStore) Option {
+	return func(broker *Broker) {
+		broker.subscriptionList = subscriptions
+	}
+}
+
+// Where a subscription is, and how far behind the head of its stream.
+// Partitions of partitioned subscribers are listed on their own, lagging behind the whole group.
+type SubscriptionState struct {
+	SubscriptionId string // as stored, suffixed with the partition when partitioned
+	Stream         streams.Id
+	Position       int64
+	Head           int64         // position of the last record of the stream
+	Lag            int64         // records after the position
+	LagTime        time.Duration // since the oldest record after the position was written, zero when caught up
+	Updated        time.Time     // when the position last moved, zero if unknown
+	LastError      string        // last error of the subscriber in this process, empty if none
+	LastErrorTime  time.Time
+}
+
+// last error of each subscriber, by the group and id of its position
+type subscriberErrors struct {
+	mu   sync.Mutex
+	last map[string]subscriberError
+}
+
+type subscriberError struct {
+	err  string
+	time time.Time
+}
+
+func (errs *subscriberErrors) set(group, subscriptionId, err string) {
+	errs.mu.Lock()
+	defer errs.mu.Unlock()
+	errs.last[subscriberErrorKey(group, subscriptionId)] = subscriberError{err: err, time: time.Now()}
+}
+
+func (errs *subscriberErrors) get(group, subscriptionId string) (subscriberError, bool) {
+	errs.mu.Lock()
+	defer errs.mu.Unlock()
+	err, found := errs.last[subscriberErrorKey(group, subscriptionId)]
+	return err, found
+}
+
+// subscriber ids are unique within their group only
+func subscriberErrorKey(group, subscriptionId string) string {
+	return group + "\x00" + subscriptionId
+}
+
+// keeps the errors observed by the subscriber
+func trackErrors(errs *subscriberErrors) SubscriberOption {
+	return func(sh *streamHandler) {
+		sh.onError = binary.Combine(sh.onError, binary.ClientTraceFunc(func(ctx context.Context, subscriberId, err string) func() {
+			errs.set(sh.stream.Group, sh.id, err)
+			return func() {}
+		}))
+	}
+}
+
+// Lists the stored subscriptions, with the lag of each
+func (broker *Broker) Subscriptions(ctx context.Context) ([]SubscriptionState, error) {
+	if broker.subscriptionList == nil {
+		return nil, fmt.Errorf("subscriptions: %w", ErrNoSubscriptionList)
+	}
+	if broker.positions == nil {
+		return nil, fmt.Errorf("subscriptions: %w", ErrNoPositions)
+	}
+	subscriptions, err := broker.subscriptionList.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var result []SubscriptionState
+	for _, subscription := range subscriptions {
+		state, err := broker.subscriptionState(ctx, subscription, now)
+		if err != nil {
+			return nil, fmt.Errorf("subscription %s of %s: %w", subscription.SubscriptionId, subscription.Stream, err)
+		}
+		result = append(result, state)
+	}
+	return result, nil
+}
+
+func (broker *Broker) subscriptionState(ctx context.Context, subscription store.Subscription, now time.Time) (SubscriptionState, error) {
+	state := SubscriptionState{
+		SubscriptionId: subscription.SubscriptionId,
+		Stream:         subscription.Stream,
+		Position:       subscription.Position,
+		Updated:        subscription.Updated,
+	}
+	if last, found := broker.subscriberErrors.get(subscription.Stream.Group, subscription.SubscriptionId); found {
+		state.LastError = last.err
+		state.LastErrorTime = last.time
+	}
+
+	var err error
+	state.Head, err = broker.positions.HeadPosition(ctx, subscription.Stream)
+	if err != nil {
+		return state, err
+	}
+	if state.Head <= state.Position {
+		return state, nil
+	}
+	state.Lag, err = broker.subscriptionList.CountRecords(ctx, subscription.Stream, subscription.Position)
+	if err != nil {
+		return state, err
+	}
+	oldest, err := broker.store.ReadRecords(ctx, subscription.Stream, subscription.Position, math.MaxInt64, 1)
+	if err != nil {
+		return state, err
+	}
+	if len(oldest) > 0 && now.After(oldest[0].Time) {
+		state.LagTime = now.Sub(oldest[0].Time)
+	}
+	return state, nil
+}
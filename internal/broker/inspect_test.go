@@ -0,0 +1,94 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store/inmemory"
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroker_Subscriptions(t *testing.T) {
+	ctx := context.Background()
+	group := streams.ParseId("inspected")
+
+	t.Run("no subscription list", func(t *testing.T) {
+		// setup
+		mem := inmemory.New()
+		broker := New(mem, &mockRegistry{}, &mockProtocol{}, WithPositions(mem))
+		// execute
+		_, err := broker.Subscriptions(ctx)
+		// verify
+		assert.True(t, errors.Is(err, ErrNoSubscriptionList))
+	})
+
+	t.Run("lag and last error", func(t *testing.T) {
+		// setup
+		mem := inmemory.New()
+		broker := New(mem, &mockRegistry{}, &mockProtocol{}, WithPositions(mem), WithSubscriptionList(mem))
+		for i := 0; i < 4; i++ {
+			_, err := mem.WriteRecords(ctx, group.WithEntity("1"), record.Data{ContentType: "application/json", Data: []byte(`{}`)})
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, broker.Register(ctx, "failing", group, newFailingHandler(1)))
+		assert.NoError(t, broker.Register(ctx, "idle", group, newCountingHandler(), StartingAt(StartAtHead())))
+		_, err := broker.subscribers[group.Group].Handle(ctx, record.Record{Stream: group, Group: group.Group})
+		assert.NoError(t, err)
+
+		// execute
+		subscriptions, err := broker.Subscriptions(ctx)
+
+		// verify
+		assert.NoError(t, err)
+		if !assert.Equal(t, 2, len(subscriptions)) {
+			t.FailNow()
+		}
+		failing, idle := subscriptions[0], subscriptions[1]
+		assert.Equal(t, "failing", failing.SubscriptionId)
+		assert.Equal(t, group, failing.Stream)
+		assert.Equal(t, int64(1), failing.Position)
+		assert.Equal(t, int64(4), failing.Head)
+		assert.Equal(t, int64(3), failing.Lag)
+		assert.True(t, failing.LagTime > 0, "lag time")
+		assert.Equal(t, "failed at 2", failing.LastError)
+		assert.WithinDuration(t, time.Now(), failing.LastErrorTime, time.Second)
+
+		assert.Equal(t, "idle", idle.SubscriptionId)
+		assert.Equal(t, int64(4), idle.Position)
+		assert.Equal(t, int64(0), idle.Lag)
+		assert.Equal(t, time.Duration(0), idle.LagTime)
+		assert.Empty(t, idle.LastError)
+	})
+
+	t.Run("same subscriber id in other groups", func(t *testing.T) {
+		// setup
+		mem := inmemory.New()
+		broker := New(mem, &mockRegistry{}, &mockProtocol{}, WithPositions(mem), WithSubscriptionList(mem))
+		other := streams.ParseId("uninspected")
+		for _, id := range []streams.Id{group, other} {
+			_, err := mem.WriteRecords(ctx, id.WithEntity("1"), record.Data{ContentType: "application/json", Data: []byte(`{}`)})
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, broker.Register(ctx, "sub", group, newFailingHandler(0)))
+		assert.NoError(t, broker.Register(ctx, "sub", other, newCountingHandler()))
+		for _, id := range []streams.Id{group, other} {
+			_, err := broker.subscribers[id.Group].Handle(ctx, record.Record{Stream: id, Group: id.Group})
+			assert.NoError(t, err)
+		}
+
+		// execute
+		subscriptions, err := broker.Subscriptions(ctx)
+
+		// verify
+		assert.NoError(t, err)
+		errs := make(map[string]string)
+		for _, subscription := range subscriptions {
+			errs[subscription.Stream.Group] = subscription.LastError
+		}
+		assert.Equal(t, map[string]string{group.Group: "failed at 1", other.Group: ""}, errs)
+	})
+}
@@ -0,0 +1,131 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-po/po/internal/observer/unary"
+	"github.com/go-po/po/internal/observer/value"
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
+)
+
+var ErrNoOutbox = errors.New("store keeps no outbox")
+
+// Implemented by stores able to keep an outbox of notifications,
+// written in the same transaction as the records they announce
+type OutboxStore interface {
+	// Writes the notifications of records to the outbox, from now on
+	EnableOutbox()
+	// Claims the oldest notifications not claimed by another relay, up to the limit, and publishes them in order
+	// until one fails. The published are removed from the outbox, and the claim of the rest is released.
+	// Reports the number of notifications claimed.
+	RelayNotifications(ctx context.Context, limit int, publish func(n store.Notification) error) (int, error)
+	// Number of notifications in the outbox
+	CountNotifications(ctx context.Context) (int64, error)
+}
+
+// Relays the notifications of the outbox to the protocol, instead of publishing them from Notify,
+// so records are announced even if the process stops right after writing them.
+// The outbox is relayed every interval and when Notify is called, in batches of up to batchSize.
+// Failed relays are retried after the delay of the retry, counting from 1.
+func WithOutbox(outbox OutboxStore, interval time.Duration, batchSize int, delay func(retry int) time.Duration) Option {
+	return func(broker *Broker) {
+		broker.outbox = outbox
+		broker.relayInterval = interval
+		broker.relayBatchSize = batchSize
+		broker.relayDelay = delay
+	}
+}
+
+// Observes the errors relaying the outbox, the milliseconds from writing
+// to publishing each notification, and the number of notifications left after each relay
+func WithOutboxObservers(onError unary.ClientTrace, lag, backlog value.ClientTrace) Option {
+	return func(broker *Broker) {
+		broker.onRelayError = onError
+		broker.onRelayLag = lag
+		broker.onRelayBacklog = backlog
+	}
+}
+
+// wakes up the relay, unless already woken
+func (broker *Broker) wakeRelay() {
+	select {
+	case broker.relayWake <- struct{}{}:
+	default:
+	}
+}
+
+// Runs until the broker is closed.
+// Notifications are published at least once, as the outbox might be left before they are removed.
+func (broker *Broker) relay() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-broker.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	retry := 0
+	for {
+		more, err := broker.relayBatch(ctx)
+		wait := broker.relayInterval
+		wake := broker.relayWake
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			broker.onRelayError.Observe(ctx, err.Error())()
+			retry = retry + 1
+			wait = broker.relayDelay(retry)
+			wake = nil // backs off even if woken
+		} else {
+			retry = 0
+			if more {
+				continue
+			}
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-broker.done:
+			timer.Stop()
+			return
+		case <-wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// Publishes the oldest notifications not claimed by other instances, removing them from the outbox once published.
+// Reports true if there might be more to publish.
+func (broker *Broker) relayBatch(ctx context.Context) (bool, error) {
+	claimed, err := broker.outbox.RelayNotifications(ctx, broker.relayBatchSize, func(n store.Notification) error {
+		err := broker.notify(ctx, record.Record{
+			Number:       n.Number,
+			Stream:       n.Stream,
+			Group:        n.Stream.Group,
+			GlobalNumber: n.GlobalNumber,
+			Time:         n.Time,
+		})
+		if err != nil {
+			return err
+		}
+		broker.onRelayLag.Observe(ctx, float64(time.Since(n.Time).Milliseconds()))
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	backlog, err := broker.outbox.CountNotifications(ctx)
+	if err != nil {
+		return false, err
+	}
+	broker.onRelayBacklog.Observe(ctx, float64(backlog))
+	return claimed >= broker.relayBatchSize, nil
+}
@@ -0,0 +1,121 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-po/po/internal/observer/unary"
+	"github.com/go-po/po/internal/observer/value"
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store/inmemory"
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+// publisher failing the first attempts
+type mockOutboxPublisher struct {
+	mu        sync.Mutex
+	failures  int
+	published []record.Record
+}
+
+func (mock *mockOutboxPublisher) Handle(ctx context.Context, r record.Record) (bool, error) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	if mock.failures > 0 {
+		mock.failures = mock.failures - 1
+		return false, fmt.Errorf("publish failed")
+	}
+	mock.published = append(mock.published, r)
+	return true, nil
+}
+
+func (mock *mockOutboxPublisher) count() int {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return len(mock.published)
+}
+
+func TestBroker_Outbox(t *testing.T) {
+	ctx := context.Background()
+	id := streams.ParseId("outbox-1")
+	data := record.Data{ContentType: "application/json", Data: []byte(`{}`)}
+	delay := func(retry int) time.Duration {
+		return time.Millisecond
+	}
+
+	t.Run("relays on notify", func(t *testing.T) {
+		// setup
+		mem := inmemory.New()
+		publisher := &mockOutboxPublisher{}
+		var backlog []float64
+		mu := sync.Mutex{}
+		broker := New(mem, &mockRegistry{}, &mockProtocol{publisher: publisher},
+			WithOutbox(mem, time.Hour, 2, delay),
+			WithOutboxObservers(unary.Noop(), value.Noop(), value.ClientTraceFunc(func(ctx context.Context, v float64) {
+				mu.Lock()
+				defer mu.Unlock()
+				backlog = append(backlog, v)
+			})),
+		)
+		defer func() {
+			_ = broker.Close(ctx)
+		}()
+		written, err := mem.WriteRecords(ctx, id, data, data, data)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		// execute
+		err = broker.Notify(ctx, written...)
+
+		// verify
+		assert.NoError(t, err)
+		assert.Eventually(t, func() bool { return publisher.count() == 3 }, time.Second, time.Millisecond)
+		for i, r := range publisher.published {
+			assert.Equal(t, written[i].Number, r.Number)
+			assert.Equal(t, written[i].GlobalNumber, r.GlobalNumber)
+			assert.Equal(t, id.Group, r.Group)
+		}
+		assert.Eventually(t, func() bool {
+			count, _ := mem.CountNotifications(ctx)
+			return count == 0
+		}, time.Second, time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		if assert.NotEmpty(t, backlog) {
+			assert.Equal(t, float64(0), backlog[len(backlog)-1])
+		}
+	})
+
+	t.Run("retries failed relays", func(t *testing.T) {
+		// setup
+		mem := inmemory.New()
+		publisher := &mockOutboxPublisher{failures: 2}
+		errs := make(chan string, 10)
+		broker := New(mem, &mockRegistry{}, &mockProtocol{publisher: publisher},
+			WithOutbox(mem, time.Hour, 10, delay),
+			WithOutboxObservers(unary.ClientTraceFunc(func(ctx context.Context, err string) func() {
+				errs <- err
+				return func() {}
+			}), value.Noop(), value.Noop()),
+		)
+		defer func() {
+			_ = broker.Close(ctx)
+		}()
+
+		// execute
+		written, err := mem.WriteRecords(ctx, id, data)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		_ = broker.Notify(ctx, written...)
+
+		// verify
+		assert.Eventually(t, func() bool { return publisher.count() == 1 }, time.Second, time.Millisecond)
+		assert.Len(t, errs, 2)
+	})
+}
@@ -0,0 +1,336 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-po/po/internal/observer/binary"
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
+)
+
+var ErrNoLeases = errors.New("store holds no leases")
+
+// time a partition is leased for, unless set with WithInstance
+const DefaultLeaseTTL = 15 * time.Second
+
+// Implemented by stores able to hold leases, needed by partitioned subscribers
+type LeaseStore interface {
+	// Takes or renews the lease for the owner, reporting false if another owner holds it
+	AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+	// Gives up the lease, if held by the owner
+	ReleaseLease(ctx context.Context, name, owner string) error
+	// Unexpired leases with names starting with the prefix
+	Leases(ctx context.Context, prefix string) ([]store.Lease, error)
+}
+
+// Leases held by partitioned subscribers
+func WithLeases(leases LeaseStore) Option {
+	return func(broker *Broker) {
+		broker.leases = leases
+	}
+}
+
+// Identifies this instance among the instances sharing partitioned subscribers,
+// holding their leases for the ttl between heartbeats
+func WithInstance(instanceId string, leaseTTL time.Duration) Option {
+	return func(broker *Broker) {
+		broker.instanceId = instanceId
+		broker.leaseTTL = leaseTTL
+	}
+}
+
+// Splits the group subscription between the instances registering the same subscriber.
+// Streams are assigned to partitions by a hash of their entity, keeping the order of each stream,
+// and the partitions are spread over the live instances with leases held in the store.
+func (broker *Broker) RegisterPartitioned(ctx context.Context, subscriberId string, streamId streams.Id, partitions int, subscriber streams.Handler, opts ...SubscriberOption) error {
+	if broker.leases == nil {
+		return fmt.Errorf("partitioned subscriber %s: %w", subscriberId, ErrNoLeases)
+	}
+	if streamId.HasEntity() {
+		return fmt.Errorf("partitioned subscriber %s: not a group stream: %s", subscriberId, streamId)
+	}
+	if partitions < 1 {
+		return fmt.Errorf("partitioned subscriber %s: needs at least one partition", subscriberId)
+	}
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	if broker.closed {
+		return ErrClosed
+	}
+
+	sub, err := broker.subscription(streamId.Group)
+	if err != nil {
+		return err
+	}
+	if sub.HasSubscriber(subscriberId) {
+		return fmt.Errorf("partitioned subscriber %s: %w", subscriberId, ErrSubscribed)
+	}
+
+	var ids []string
+	for partition := 0; partition < partitions; partition++ {
+		ids = append(ids, partitionSubscriberId(subscriberId, partition))
+	}
+	err = broker.initPositions(ctx, streamId, startOf(opts), ids...)
+	if err != nil {
+		return err
+	}
+
+	c := &coordinator{
+		leases:       broker.leases,
+		instanceId:   broker.instanceId,
+		ttl:          broker.leaseTTL,
+		heartbeat:    broker.leaseTTL / 3,
+		group:        streamId.Group,
+		subscriberId: subscriberId,
+		owned:        make([]*partitionLease, partitions),
+		wake: func(ctx context.Context) {
+			broker.background.Add(1)
+			go func() {
+				defer broker.background.Done()
+				_, err := sub.Handle(ctx, record.Record{Stream: streamId, Group: streamId.Group})
+				if err != nil && ctx.Err() == nil {
+					broker.onSubscriberError.Observe(ctx, subscriberId, err.Error())()
+				}
+			}()
+		},
+		onError: broker.onSubscriberError,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	for partition, id := range ids {
+		c.owned[partition] = &partitionLease{}
+		sub.AddSubscriber(streamId, id, subscriber, append([]SubscriberOption{
+			observeSubscriber(broker.onSubscriberError, broker.onSubscriberFailure),
+			trackErrors(broker.subscriberErrors),
+			inPartition(subscriberId, partition, partitions, c.owned[partition]),
+		}, opts...)...)
+	}
+	broker.coordinators[coordinatorKey(streamId.Group, subscriberId)] = c
+	// runs for the lifetime of the broker, not of the registering context
+	broker.background.Add(1)
+	go func() {
+		defer broker.background.Done()
+		c.run(broker.ctx)
+	}()
+	return nil
+}
+
+func coordinatorKey(group, subscriberId string) string {
+	return group + "\x00" + subscriberId
+}
+
+// position of each partition is kept as a subscriber of its own
+func partitionSubscriberId(subscriberId string, partition int) string {
+	return fmt.Sprintf("%s/%d", subscriberId, partition)
+}
+
+// partition of the stream, by a hash of its entity
+func partitionOf(id streams.Id, partitions int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id.Entity))
+	return int(h.Sum32() % uint32(partitions))
+}
+
+func inPartition(subscriberId string, partition, partitions int, lease *partitionLease) SubscriberOption {
+	return func(sh *streamHandler) {
+		sh.subscriberId = subscriberId
+		sh.partition = partition
+		sh.partitions = partitions
+		sh.lease = lease
+	}
+}
+
+// Ownership of a partition by this instance,
+// checked by the handler of the partition before each batch.
+type partitionLease struct {
+	mu sync.RWMutex // read locked by the batch in flight
+
+	untilMu sync.Mutex
+	until   time.Time // end of the ownership, zero when not owned
+}
+
+// reports true if the partition is owned, holding it until exit is called
+func (lease *partitionLease) enter() bool {
+	lease.mu.RLock()
+	if lease.valid(time.Now()) {
+		return true
+	}
+	lease.mu.RUnlock()
+	return false
+}
+
+func (lease *partitionLease) exit() {
+	lease.mu.RUnlock()
+}
+
+func (lease *partitionLease) valid(now time.Time) bool {
+	lease.untilMu.Lock()
+	defer lease.untilMu.Unlock()
+	return now.Before(lease.until)
+}
+
+// reports true if the partition was not owned before
+func (lease *partitionLease) extend(until time.Time) bool {
+	lease.untilMu.Lock()
+	defer lease.untilMu.Unlock()
+	acquired := !time.Now().Before(lease.until)
+	lease.until = until
+	return acquired
+}
+
+// gives up the ownership once the batch in flight is done
+func (lease *partitionLease) revoke() {
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+	lease.untilMu.Lock()
+	defer lease.untilMu.Unlock()
+	lease.until = time.Time{}
+}
+
+// Spreads the partitions of a subscriber over the live instances.
+// Every instance holds a member lease, renewed on each heartbeat,
+// and takes the partitions assigned to it by its place among the members.
+// A partition changes owner once the previous owner released its lease, or the lease expired.
+type coordinator struct {
+	leases       LeaseStore
+	instanceId   string
+	ttl          time.Duration
+	heartbeat    time.Duration
+	group        string
+	subscriberId string
+	owned        []*partitionLease         // by partition
+	wake         func(ctx context.Context) // handles the partitions in the background, without blocking
+	onError      binary.ClientTrace        // subscriber id, error
+
+	stopOnce sync.Once
+	stop     chan struct{} // closed to stop the coordinator
+	stopped  chan struct{} // closed once the leases are released
+}
+
+// Runs until the context is done or the coordinator closed, then releases the leases held
+func (c *coordinator) run(ctx context.Context) {
+	defer close(c.stopped)
+	for {
+		err := c.rebalance(ctx)
+		if err != nil && ctx.Err() == nil {
+			c.onError.Observe(ctx, c.subscriberId, err.Error())()
+		}
+		timer := time.NewTimer(c.heartbeat)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			c.releaseAll()
+			return
+		case <-c.stop:
+			timer.Stop()
+			c.releaseAll()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// stops the coordinator, waiting for it to release the leases
+func (c *coordinator) close() {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+	<-c.stopped
+}
+
+// leases of the subscriber, apart from the subscribers of the same id in other groups
+func (c *coordinator) prefix() string {
+	return "po/" + c.group + "/" + c.subscriberId + "/"
+}
+
+func (c *coordinator) memberLease(instanceId string) string {
+	return c.prefix() + "members/" + instanceId
+}
+
+func (c *coordinator) partitionLease(partition int) string {
+	return fmt.Sprintf("%spartitions/%d", c.prefix(), partition)
+}
+
+// live instances, in the same order on every instance
+func (c *coordinator) members(ctx context.Context) ([]string, error) {
+	_, err := c.leases.AcquireLease(ctx, c.memberLease(c.instanceId), c.instanceId, c.ttl)
+	if err != nil {
+		return nil, err
+	}
+	leases, err := c.leases.Leases(ctx, c.memberLease(""))
+	if err != nil {
+		return nil, err
+	}
+	members := []string{c.instanceId}
+	for _, lease := range leases {
+		instanceId := strings.TrimPrefix(lease.Name, c.memberLease(""))
+		if instanceId != c.instanceId {
+			members = append(members, instanceId)
+		}
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+func (c *coordinator) rebalance(ctx context.Context) error {
+	start := time.Now()
+	members, err := c.members(ctx)
+	if err != nil {
+		return err
+	}
+	acquired := false
+	for partition, owned := range c.owned {
+		name := c.partitionLease(partition)
+		if members[partition%len(members)] != c.instanceId {
+			if owned.valid(time.Now()) {
+				owned.revoke()
+				err = c.leases.ReleaseLease(ctx, name, c.instanceId)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		held, err := c.leases.AcquireLease(ctx, name, c.instanceId, c.ttl)
+		if err != nil {
+			return err
+		}
+		if !held {
+			owned.revoke()
+			continue
+		}
+		// stop using the lease a heartbeat before it expires in the store
+		if owned.extend(start.Add(c.ttl - c.heartbeat)) {
+			acquired = true
+		}
+	}
+	if acquired {
+		c.wake(ctx)
+	}
+	return nil
+}
+
+// leaving the partitions to the other instances
+func (c *coordinator) releaseAll() {
+	ctx := context.Background()
+	for partition, owned := range c.owned {
+		owned.revoke()
+		err := c.leases.ReleaseLease(ctx, c.partitionLease(partition), c.instanceId)
+		if err != nil {
+			c.onError.Observe(ctx, c.subscriberId, err.Error())()
+		}
+	}
+	err := c.leases.ReleaseLease(ctx, c.memberLease(c.instanceId), c.instanceId)
+	if err != nil {
+		c.onError.Observe(ctx, c.subscriberId, err.Error())()
+	}
+}
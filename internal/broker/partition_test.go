@@ -0,0 +1,200 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store/inmemory"
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+// records the instance handling each message, by stream
+type mockPartitionLog struct {
+	mu       sync.Mutex
+	handled  map[string][]int64  // numbers by stream
+	handlers map[string][]string // instances by stream
+}
+
+func (log *mockPartitionLog) handler(instanceId string) streams.Handler {
+	return streams.HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+		log.mu.Lock()
+		defer log.mu.Unlock()
+		stream := msg.Stream.String()
+		log.handled[stream] = append(log.handled[stream], msg.Number)
+		log.handlers[stream] = append(log.handlers[stream], instanceId)
+		return nil
+	})
+}
+
+func (log *mockPartitionLog) count() int {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	count := 0
+	for _, numbers := range log.handled {
+		count = count + len(numbers)
+	}
+	return count
+}
+
+func TestPartitionOf(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		id := streams.ParseId("partition-%d", i)
+		partition := partitionOf(id, 4)
+		assert.True(t, partition >= 0 && partition < 4, "in range")
+		assert.Equal(t, partition, partitionOf(id, 4), "stable")
+	}
+}
+
+func TestBroker_RegisterPartitioned(t *testing.T) {
+	const ttl = 90 * time.Millisecond
+	group := streams.ParseId("partitioned")
+
+	t.Run("no leases", func(t *testing.T) {
+		// setup
+		broker := New(newMockStore(t, nil, nil), &mockRegistry{}, &mockProtocol{})
+		// execute
+		err := broker.RegisterPartitioned(context.Background(), "P", group, 4, newCountingHandler())
+		// verify
+		assert.True(t, errors.Is(err, ErrNoLeases))
+	})
+
+	t.Run("instances share the partitions", func(t *testing.T) {
+		// setup
+		mem := inmemory.New()
+		log := &mockPartitionLog{handled: make(map[string][]int64), handlers: make(map[string][]string)}
+		write := func(count int) {
+			for i := 0; i < count; i++ {
+				_, err := mem.WriteRecords(context.Background(), group.WithEntity("%d", i%10), record.Data{
+					ContentType: "application/json",
+					Data:        []byte(`{}`),
+				})
+				assert.NoError(t, err)
+			}
+		}
+		start := func(instanceId string) *Broker {
+			broker := New(mem, &mockRegistry{}, &mockProtocol{},
+				WithLeases(mem),
+				WithInstance(instanceId, ttl),
+				WithCatchUp(10*time.Millisecond, 0),
+			)
+			err := broker.RegisterPartitioned(context.Background(), "P", group, 4, log.handler(instanceId))
+			assert.NoError(t, err)
+			return broker
+		}
+		brokerA := start("A")
+		defer func() {
+			_ = brokerA.Close(context.Background())
+		}()
+		brokerB := start("B")
+		time.Sleep(ttl) // rebalanced
+
+		// execute
+		write(40)
+		assert.Eventually(t, func() bool { return log.count() == 40 }, 2*time.Second, 10*time.Millisecond)
+		shared := map[string]bool{}
+		log.mu.Lock()
+		for _, instances := range log.handlers {
+			for _, instanceId := range instances {
+				shared[instanceId] = true
+			}
+		}
+		log.mu.Unlock()
+		assert.NoError(t, brokerB.Close(context.Background()))
+		write(40)
+
+		// verify
+		assert.Equal(t, map[string]bool{"A": true, "B": true}, shared, "both instances handled messages")
+		assert.Eventually(t, func() bool { return log.count() == 80 }, 2*time.Second, 10*time.Millisecond)
+		time.Sleep(2 * ttl)
+		assert.Equal(t, 80, log.count(), "handled once")
+		log.mu.Lock()
+		defer log.mu.Unlock()
+		for stream, numbers := range log.handled {
+			for i, number := range numbers {
+				assert.Equal(t, int64(i), number, fmt.Sprintf("order of %s", stream))
+			}
+			assert.Equal(t, "A", log.handlers[stream][len(numbers)-1], "taken over by A")
+		}
+	})
+
+	t.Run("cancelled registration context", func(t *testing.T) {
+		// setup
+		mem := inmemory.New()
+		log := &mockPartitionLog{handled: make(map[string][]int64), handlers: make(map[string][]string)}
+		broker := New(mem, &mockRegistry{}, &mockProtocol{},
+			WithLeases(mem),
+			WithInstance("A", ttl),
+			WithCatchUp(10*time.Millisecond, 0),
+		)
+		defer func() {
+			_ = broker.Close(context.Background())
+		}()
+		ctx, cancel := context.WithCancel(context.Background())
+		err := broker.RegisterPartitioned(ctx, "C", group, 2, log.handler("A"))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		// execute
+		cancel()
+		for i := 0; i < 10; i++ {
+			_, err := mem.WriteRecords(context.Background(), group.WithEntity("%d", i), record.Data{
+				ContentType: "application/json",
+				Data:        []byte(`{}`),
+			})
+			assert.NoError(t, err)
+		}
+
+		// verify
+		assert.Eventually(t, func() bool { return log.count() == 10 }, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("unregister releases the leases", func(t *testing.T) {
+		// setup
+		mem := inmemory.New()
+		broker := New(mem, &mockRegistry{}, &mockProtocol{}, WithLeases(mem), WithInstance("A", ttl))
+		err := broker.RegisterPartitioned(context.Background(), "U", group, 2, newCountingHandler())
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Eventually(t, func() bool {
+			leases, _ := mem.Leases(context.Background(), "po/partitioned/U/partitions/")
+			return len(leases) == 2
+		}, time.Second, 10*time.Millisecond)
+
+		// execute
+		err = broker.Unregister("U", group)
+
+		// verify
+		assert.NoError(t, err)
+		leases, err := mem.Leases(context.Background(), "po/partitioned/U/")
+		assert.NoError(t, err)
+		assert.Empty(t, leases)
+	})
+
+	t.Run("same subscriber id in other groups", func(t *testing.T) {
+		// setup
+		mem := inmemory.New()
+		broker := New(mem, &mockRegistry{}, &mockProtocol{}, WithLeases(mem), WithInstance("A", ttl))
+		defer func() {
+			_ = broker.Close(context.Background())
+		}()
+		// execute
+		err := broker.RegisterPartitioned(context.Background(), "S", group, 2, newCountingHandler())
+		assert.NoError(t, err)
+		err = broker.RegisterPartitioned(context.Background(), "S", streams.ParseId("other"), 3, newCountingHandler())
+		assert.NoError(t, err)
+		// verify
+		assert.Eventually(t, func() bool {
+			first, _ := mem.Leases(context.Background(), "po/partitioned/S/partitions/")
+			second, _ := mem.Leases(context.Background(), "po/other/S/partitions/")
+			return len(first) == 2 && len(second) == 3
+		}, time.Second, 10*time.Millisecond, "partitions of each group leased")
+	})
+}
@@ -0,0 +1,27 @@
+package pgnotify
+
+import "time"
+
+type config struct {
+	Log          Logger
+	ListenerUrl  string
+	MinReconnect time.Duration
+	MaxReconnect time.Duration
+}
+
+type Option func(opt *config)
+
+func WithLogger(log Logger) Option {
+	return func(opt *config) {
+		opt.Log = log
+	}
+}
+
+// Time waited before reconnecting a lost listener connection,
+// doubling after each failed attempt up to max
+func WithReconnectInterval(min, max time.Duration) Option {
+	return func(opt *config) {
+		opt.MinReconnect = min
+		opt.MaxReconnect = max
+	}
+}
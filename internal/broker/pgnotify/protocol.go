@@ -0,0 +1,240 @@
+package pgnotify
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-po/po/internal/broker"
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/streams"
+	"github.com/lib/pq"
+)
+
+// Protocol waking up subscribers with LISTEN/NOTIFY on a channel per group.
+// Notifications carry the stream and numbers of a record but not its data,
+// as subscribers read the records from the store.
+// They are sent on db, and received on a connection of their own opened from listenerUrl,
+// as the driver only hands over notifications to a listener.
+func New(db *sql.DB, listenerUrl string, opts ...Option) *Protocol {
+	cfg := config{
+		Log:          noopLogger{},
+		ListenerUrl:  listenerUrl,
+		MinReconnect: 100 * time.Millisecond,
+		MaxReconnect: 10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &Protocol{
+		cfg:       cfg,
+		db:        db,
+		consumers: make(map[string]*consumer),
+		done:      make(chan struct{}),
+	}
+}
+
+type Protocol struct {
+	cfg config
+	db  *sql.DB // sending notifications
+
+	mu        sync.Mutex           // guards the fields below
+	listener  *pq.Listener         // connection receiving notifications
+	consumers map[string]*consumer // by channel
+	closed    bool
+	done      chan struct{}  // closed with the protocol
+	running   sync.WaitGroup // consumers and the dispatch running
+}
+
+// The consumer of the group runs until the protocol is closed, whatever the context.
+func (p *Protocol) Register(ctx context.Context, group string, input broker.RecordHandler) (broker.RecordHandler, error) {
+	err := p.db.PingContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("po/pgnotify connect: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, fmt.Errorf("po/pgnotify closed")
+	}
+	p.connect()
+
+	channel := channelName(group)
+	c := &consumer{
+		group: group,
+		input: input,
+		log:   p.cfg.Log,
+		wake:  make(chan record.Record, 1),
+	}
+	p.consumers[channel] = c
+	err = p.listener.Listen(channel)
+	if err != nil && err != pq.ErrChannelAlreadyOpen {
+		delete(p.consumers, channel)
+		return nil, fmt.Errorf("po/pgnotify listen %s: %w", channel, err)
+	}
+	p.running.Add(1)
+	go func() {
+		defer p.running.Done()
+		c.run(p.done)
+	}()
+
+	return p.publisher(channel), nil
+}
+
+func (p *Protocol) Publisher(ctx context.Context, group string) (broker.RecordHandler, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, fmt.Errorf("po/pgnotify closed")
+	}
+	return p.publisher(channelName(group)), nil
+}
+
+// Sends the notification when called. Records are notified once their
+// transaction has committed, so the notification is never seen before the record.
+func (p *Protocol) publisher(channel string) broker.RecordHandlerFunc {
+	return func(ctx context.Context, r record.Record) (bool, error) {
+		_, err := p.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", channel, broker.ToMessageId(r))
+		if err != nil {
+			return false, fmt.Errorf("po/pgnotify notify: %w", err)
+		}
+		return true, nil
+	}
+}
+
+// opens the listener, which reconnects by itself.
+// must be called while holding the lock
+func (p *Protocol) connect() {
+	if p.listener != nil {
+		return
+	}
+	p.listener = pq.NewListener(p.cfg.ListenerUrl, p.cfg.MinReconnect, p.cfg.MaxReconnect, p.event)
+	p.running.Add(1)
+	go func() {
+		defer p.running.Done()
+		p.dispatch(p.listener.Notify)
+	}()
+}
+
+func (p *Protocol) event(event pq.ListenerEventType, err error) {
+	switch event {
+	case pq.ListenerEventDisconnected:
+		p.cfg.Log.Errorf("po/pgnotify disconnected: %s", err)
+	case pq.ListenerEventConnectionAttemptFailed:
+		p.cfg.Log.Errorf("po/pgnotify reconnect failed: %s", err)
+	case pq.ListenerEventReconnected:
+		p.cfg.Log.Infof("po/pgnotify reconnected")
+	}
+}
+
+// Hands notifications to the consumer of their channel, until the listener is closed.
+// The listener listens again on all channels after reconnecting, signalled by a nil
+// notification, and every consumer is woken as notifications might have been missed.
+func (p *Protocol) dispatch(notifications <-chan *pq.Notification) {
+	for n := range notifications {
+		if n == nil {
+			p.mu.Lock()
+			for _, c := range p.consumers {
+				c.offer(record.Record{Stream: streams.ParseId(c.group), Group: c.group})
+			}
+			p.mu.Unlock()
+			continue
+		}
+		p.mu.Lock()
+		c, found := p.consumers[n.Channel]
+		p.mu.Unlock()
+		if !found {
+			continue
+		}
+		r, err := toRecord(n.Extra)
+		if err != nil {
+			p.cfg.Log.Errorf("po/pgnotify parse notification: %s", err)
+			continue
+		}
+		c.offer(r)
+	}
+}
+
+// Stops listening once the records in flight are handled, and closes the listener.
+// The database is left to its owner.
+func (p *Protocol) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+	listener := p.listener
+	p.mu.Unlock()
+
+	var err error
+	if listener != nil {
+		// ends the dispatch by closing the notification channel
+		err = listener.Close()
+	}
+	p.running.Wait()
+	return err
+}
+
+func toRecord(payload string) (record.Record, error) {
+	stream, number, globalNumber, err := broker.ParseMessageId(payload)
+	if err != nil {
+		return record.Record{}, err
+	}
+	id := streams.ParseId(stream)
+	return record.Record{
+		Number:       number,
+		Stream:       id,
+		Group:        id.Group,
+		GlobalNumber: globalNumber,
+	}, nil
+}
+
+// longest identifier postgres allows
+const maxChannelLength = 63
+
+func channelName(group string) string {
+	channel := "po." + group
+	if len(channel) <= maxChannelLength {
+		return channel
+	}
+	sum := sha1.Sum([]byte(group))
+	return "po." + hex.EncodeToString(sum[:])
+}
+
+// Passes records of a group to the input, one at a time
+type consumer struct {
+	group string
+	input broker.RecordHandler
+	log   Logger
+	wake  chan record.Record // pending record
+}
+
+// A subscription reads all records of the group when handling one,
+// so a pending record covers any offered while it waits.
+func (c *consumer) offer(r record.Record) {
+	select {
+	case c.wake <- r:
+	default:
+	}
+}
+
+func (c *consumer) run(done <-chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case r := <-c.wake:
+			_, err := c.input.Handle(context.Background(), r)
+			if err != nil {
+				c.log.Errorf("po/pgnotify handle %s: %s", c.group, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package pgnotify
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-po/po/internal/broker"
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/streams"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+const databaseUrl = "postgres://po:po@localhost:5431/po?sslmode=disable"
+
+func TestChannelName(t *testing.T) {
+	t.Run("short group", func(t *testing.T) {
+		assert.Equal(t, "po.users", channelName("users"))
+	})
+	t.Run("long group", func(t *testing.T) {
+		// execute
+		got := channelName(strings.Repeat("a", 100))
+		// verify
+		assert.True(t, len(got) <= maxChannelLength, "length %d", len(got))
+		assert.NotEqual(t, got, channelName(strings.Repeat("b", 100)))
+	})
+}
+
+func TestToRecord(t *testing.T) {
+	// setup
+	r := record.Record{
+		Number:       3,
+		Stream:       streams.ParseId("users-1"),
+		GlobalNumber: 42,
+	}
+	// execute
+	got, err := toRecord(broker.ToMessageId(r))
+	// verify
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), got.Number)
+	assert.Equal(t, int64(42), got.GlobalNumber)
+	assert.Equal(t, "users-1", got.Stream.String())
+	assert.Equal(t, "users", got.Group)
+}
+
+func TestConsumer_Offer(t *testing.T) {
+	// setup
+	handling := make(chan struct{})
+	release := make(chan struct{})
+	var handled []int64
+	c := &consumer{
+		group: "users",
+		log:   noopLogger{},
+		wake:  make(chan record.Record, 1),
+		input: broker.RecordHandlerFunc(func(ctx context.Context, r record.Record) (bool, error) {
+			handled = append(handled, r.GlobalNumber)
+			handling <- struct{}{}
+			<-release
+			return true, nil
+		}),
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go c.run(done)
+
+	// execute
+	c.offer(record.Record{GlobalNumber: 1})
+	<-handling
+	c.offer(record.Record{GlobalNumber: 2})
+	c.offer(record.Record{GlobalNumber: 3}) // coalesced with the pending one
+	release <- struct{}{}
+	<-handling
+	release <- struct{}{}
+
+	// verify
+	select {
+	case <-handling:
+		t.Fatal("handled more than the pending record")
+	case <-time.After(20 * time.Millisecond):
+	}
+	assert.Equal(t, []int64{1, 2}, handled)
+}
+
+func databaseConnection(t *testing.T) *sql.DB {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Helper()
+	db, err := sql.Open("postgres", databaseUrl)
+	if !assert.NoError(t, err, "database connection") {
+		t.FailNow()
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+// registers a group of its own, handing the records received to the channel
+func register(t *testing.T, p *Protocol) (string, broker.RecordHandler, chan record.Record) {
+	t.Helper()
+	group := "notify" + strings.ReplaceAll(time.Now().Format("150405.000000"), ".", "")
+	received := make(chan record.Record, 10)
+	publish, err := p.Register(context.Background(), group, broker.RecordHandlerFunc(func(ctx context.Context, r record.Record) (bool, error) {
+		received <- r
+		return true, nil
+	}))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return group, publish, received
+}
+
+// waits for the record numbered globalNumber, skipping the wake-ups
+func receive(t *testing.T, received chan record.Record, globalNumber int64) record.Record {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case r := <-received:
+			if r.GlobalNumber == globalNumber {
+				return r
+			}
+		case <-timeout:
+			t.Fatalf("notification %d not received", globalNumber)
+		}
+	}
+}
+
+func TestProtocol_Register(t *testing.T) {
+	t.Run("unreachable database", func(t *testing.T) {
+		// setup
+		db, err := sql.Open("postgres", databaseUrl)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		_ = db.Close()
+		p := New(db, databaseUrl)
+		// execute
+		_, err = p.Register(context.Background(), "users", broker.RecordHandlerFunc(func(ctx context.Context, r record.Record) (bool, error) {
+			return true, nil
+		}))
+		// verify
+		assert.Error(t, err)
+		assert.Empty(t, p.consumers, "no consumer left running")
+		assert.Nil(t, p.listener, "no listener started")
+		assert.NoError(t, p.Close())
+	})
+}
+
+func TestProtocol_Notify(t *testing.T) {
+	// setup
+	ctx := context.Background()
+	p := New(databaseConnection(t), databaseUrl)
+	defer func() {
+		_ = p.Close()
+	}()
+	group, publish, received := register(t, p)
+
+	// execute
+	sent, err := publish.Handle(ctx, record.Record{
+		Number:       0,
+		Stream:       streams.ParseId(group + "-a"),
+		GlobalNumber: 7,
+	})
+
+	// verify
+	assert.NoError(t, err)
+	assert.True(t, sent)
+	r := receive(t, received, 7)
+	assert.Equal(t, group+"-a", r.Stream.String())
+}
+
+func TestProtocol_Reconnect(t *testing.T) {
+	// setup
+	ctx := context.Background()
+	db := databaseConnection(t)
+	p := New(db, databaseUrl, WithReconnectInterval(10*time.Millisecond, 10*time.Millisecond))
+	defer func() {
+		_ = p.Close()
+	}()
+	group, publish, received := register(t, p)
+
+	// execute
+	_, err := db.ExecContext(ctx, "SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE query = $1",
+		"LISTEN "+pq.QuoteIdentifier(channelName(group)))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// verify
+	receive(t, received, 0) // woken as notifications might have been missed
+	_, err = publish.Handle(ctx, record.Record{
+		Number:       0,
+		Stream:       streams.ParseId(group + "-a"),
+		GlobalNumber: 8,
+	})
+	assert.NoError(t, err)
+	receive(t, received, 8)
+}
+
+func TestProtocol_CancelledRegistration(t *testing.T) {
+	// setup
+	ctx := context.Background()
+	p := New(databaseConnection(t), databaseUrl)
+	defer func() {
+		_ = p.Close()
+	}()
+	registration, cancel := context.WithCancel(ctx)
+	group := "notify" + strings.ReplaceAll(time.Now().Format("150405.000000"), ".", "")
+	received := make(chan record.Record, 10)
+	publish, err := p.Register(registration, group, broker.RecordHandlerFunc(func(ctx context.Context, r record.Record) (bool, error) {
+		received <- r
+		return true, nil
+	}))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// execute
+	cancel()
+	_, err = publish.Handle(ctx, record.Record{
+		Number:       0,
+		Stream:       streams.ParseId(group + "-a"),
+		GlobalNumber: 9,
+	})
+
+	// verify
+	assert.NoError(t, err)
+	receive(t, received, 9)
+}
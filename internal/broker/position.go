@@ -0,0 +1,207 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
+)
+
+var ErrNoPositions = errors.New("store cannot locate positions")
+
+// Implemented by stores able to locate the positions subscribers start from, and to move them.
+// Positions are global numbers for groups, and numbers in the stream for entity streams.
+type PositionStore interface {
+	// Position of the last record of the stream, -1 if it is empty
+	HeadPosition(ctx context.Context, id streams.Id) (int64, error)
+	// Position of the last record of the stream written before the time, -1 if there is none
+	PositionBefore(ctx context.Context, id streams.Id, t time.Time) (int64, error)
+	// Sets the position of the subscription, even if lower than its current position
+	MoveSubscriptionPosition(tx store.Tx, id streams.Id, position store.SubscriptionPosition) error
+}
+
+// Positions located for subscribers starting after the beginning of their stream
+func WithPositions(positions PositionStore) Option {
+	return func(broker *Broker) {
+		broker.positions = positions
+	}
+}
+
+// Position a subscriber starts after, nil for the beginning of the stream
+type Start func(ctx context.Context, positions PositionStore, id streams.Id) (int64, error)
+
+// Starts after the last record of the stream, handling new records only
+func StartAtHead() Start {
+	return func(ctx context.Context, positions PositionStore, id streams.Id) (int64, error) {
+		return positions.HeadPosition(ctx, id)
+	}
+}
+
+// Starts at the record with the number, the global number for groups
+func StartAtNumber(number int64) Start {
+	return func(ctx context.Context, positions PositionStore, id streams.Id) (int64, error) {
+		return number - 1, nil
+	}
+}
+
+// Starts at the first record written at or after the time
+func StartAtTime(t time.Time) Start {
+	return func(ctx context.Context, positions PositionStore, id streams.Id) (int64, error) {
+		return positions.PositionBefore(ctx, id, t)
+	}
+}
+
+// Starts a new subscriber at the start, instead of the beginning of the stream.
+// Subscribers with a stored position resume from it.
+func StartingAt(start Start) SubscriberOption {
+	return func(sh *streamHandler) {
+		sh.start = start
+	}
+}
+
+// start set with StartingAt, nil for the beginning
+func startOf(opts []SubscriberOption) Start {
+	sh := &streamHandler{}
+	for _, opt := range opts {
+		opt(sh)
+	}
+	return sh.start
+}
+
+// Stores the position of the subscribers without one.
+// Without a start, rely on SetSubscriptionPosition to ignore this command if the new position is lower
+func (broker *Broker) initPositions(ctx context.Context, streamId streams.Id, start Start, subscriberIds ...string) error {
+	if start != nil && broker.positions == nil {
+		return fmt.Errorf("start of %s: %w", streamId, ErrNoPositions)
+	}
+	tx, err := broker.store.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if start == nil {
+		for _, subscriberId := range subscriberIds {
+			err = broker.store.SetSubscriptionPosition(tx, streamId, store.SubscriptionPosition{
+				SubscriptionId: subscriberId,
+				Position:       -1,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Commit()
+	}
+
+	stored, err := broker.store.SubscriptionPositionLock(tx, streamId, subscriberIds...)
+	if err != nil {
+		return err
+	}
+	if len(stored) == len(subscriberIds) {
+		return tx.Commit()
+	}
+	position, err := start(ctx, broker.positions, streamId)
+	if err != nil {
+		return fmt.Errorf("start of %s: %w", streamId, err)
+	}
+	for _, subscriberId := range subscriberIds {
+		if hasPosition(stored, subscriberId) {
+			continue
+		}
+		err = broker.positions.MoveSubscriptionPosition(tx, streamId, store.SubscriptionPosition{
+			SubscriptionId: subscriberId,
+			Position:       position,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func hasPosition(positions []store.SubscriptionPosition, subscriberId string) bool {
+	for _, position := range positions {
+		if position.SubscriptionId == subscriberId {
+			return true
+		}
+	}
+	return false
+}
+
+// Moves the subscriber to the start, forwards or backwards, for replays and recovery.
+// The move waits for the batch in flight, and a subscriber registered with this broker
+// is woken to continue from the new position. Partitioned subscribers are moved in each of their partitions.
+func (broker *Broker) MoveSubscriber(ctx context.Context, subscriberId string, streamId streams.Id, partitions int, start Start) error {
+	if broker.positions == nil {
+		return fmt.Errorf("move subscriber %s: %w", subscriberId, ErrNoPositions)
+	}
+	ids := []string{subscriberId}
+	if partitions > 0 {
+		ids = nil
+		for partition := 0; partition < partitions; partition++ {
+			ids = append(ids, partitionSubscriberId(subscriberId, partition))
+		}
+	}
+
+	position := int64(-1)
+	if start != nil {
+		var err error
+		position, err = start(ctx, broker.positions, streamId)
+		if err != nil {
+			return fmt.Errorf("move subscriber %s: %w", subscriberId, err)
+		}
+	}
+
+	tx, err := broker.store.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	_, err = broker.store.SubscriptionPositionLock(tx, streamId, ids...)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		err = broker.positions.MoveSubscriptionPosition(tx, streamId, store.SubscriptionPosition{
+			SubscriptionId: id,
+			Position:       position,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	broker.wake(streamId.Group)
+	return nil
+}
+
+// Handles the subscription of the group in the background, if registered.
+// Failures are observed as failed catch-ups.
+func (broker *Broker) wake(group string) {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	sub, found := broker.subscribers[group]
+	if !found || broker.closed {
+		return
+	}
+	broker.background.Add(1)
+	go func() {
+		defer broker.background.Done()
+		ctx := context.Background()
+		_, err := sub.Handle(ctx, record.Record{Stream: streams.ParseId(group), Group: group})
+		if err != nil {
+			broker.onCatchUpError.Observe(ctx, group, err.Error())()
+		}
+	}()
+}
@@ -0,0 +1,179 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store/inmemory"
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+// records the global numbers handled
+type mockNumberLog struct {
+	mu      sync.Mutex
+	handled []int64
+}
+
+func (log *mockNumberLog) Handle(ctx context.Context, msg streams.Message) error {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	log.handled = append(log.handled, msg.GlobalNumber)
+	return nil
+}
+
+func (log *mockNumberLog) numbers() []int64 {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	return append([]int64(nil), log.handled...)
+}
+
+func TestBroker_RegisterStartingAt(t *testing.T) {
+	ctx := context.Background()
+	group := streams.ParseId("starting")
+
+	write := func(t *testing.T, mem *inmemory.InMemory, count int) []record.Record {
+		var written []record.Record
+		for i := 0; i < count; i++ {
+			r, err := mem.WriteRecords(ctx, group.WithEntity("1"), record.Data{
+				ContentType: "application/json",
+				Data:        []byte(`{}`),
+			})
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			written = append(written, r...)
+			time.Sleep(time.Millisecond)
+		}
+		return written
+	}
+
+	t.Run("no positions", func(t *testing.T) {
+		// setup
+		broker := New(newMockStore(t, nil, nil), &mockRegistry{}, &mockProtocol{})
+		// execute
+		err := broker.Register(ctx, "S", group, newCountingHandler(), StartingAt(StartAtHead()))
+		// verify
+		assert.True(t, errors.Is(err, ErrNoPositions))
+	})
+
+	tests := []struct {
+		name  string
+		start func(written []record.Record) Start
+		want  []int64
+	}{
+		{
+			name:  "beginning",
+			start: func(written []record.Record) Start { return nil },
+			want:  []int64{1, 2, 3, 4, 5},
+		},
+		{
+			name:  "head",
+			start: func(written []record.Record) Start { return StartAtHead() },
+			want:  []int64{4, 5},
+		},
+		{
+			name:  "number",
+			start: func(written []record.Record) Start { return StartAtNumber(2) },
+			want:  []int64{2, 3, 4, 5},
+		},
+		{
+			name:  "time",
+			start: func(written []record.Record) Start { return StartAtTime(written[2].Time) },
+			want:  []int64{3, 4, 5},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// setup
+			mem := inmemory.New()
+			broker := New(mem, &mockRegistry{}, &mockProtocol{}, WithPositions(mem))
+			log := &mockNumberLog{}
+			written := write(t, mem, 3)
+			// execute
+			err := broker.Register(ctx, "S", group, log, StartingAt(test.start(written)))
+			write(t, mem, 2)
+			broker.wake(group.Group)
+			// verify
+			assert.NoError(t, err)
+			assert.Eventually(t, func() bool { return len(log.numbers()) >= len(test.want) }, time.Second, 10*time.Millisecond)
+			assert.Equal(t, test.want, log.numbers())
+		})
+	}
+
+	t.Run("stored position kept", func(t *testing.T) {
+		// setup
+		mem := inmemory.New()
+		broker := New(mem, &mockRegistry{}, &mockProtocol{}, WithPositions(mem))
+		write(t, mem, 3)
+		assert.NoError(t, broker.Register(ctx, "S", group, newCountingHandler()))
+		assert.NoError(t, broker.Unregister("S", group))
+		// execute
+		restarted := New(mem, &mockRegistry{}, &mockProtocol{}, WithPositions(mem))
+		log := &mockNumberLog{}
+		err := restarted.Register(ctx, "S", group, log, StartingAt(StartAtHead()))
+		restarted.wake(group.Group)
+		// verify
+		assert.NoError(t, err)
+		assert.Eventually(t, func() bool { return len(log.numbers()) == 3 }, time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestBroker_MoveSubscriber(t *testing.T) {
+	ctx := context.Background()
+	group := streams.ParseId("moving")
+
+	t.Run("no positions", func(t *testing.T) {
+		// setup
+		broker := New(newMockStore(t, nil, nil), &mockRegistry{}, &mockProtocol{})
+		// execute
+		err := broker.MoveSubscriber(ctx, "M", group, 0, nil)
+		// verify
+		assert.True(t, errors.Is(err, ErrNoPositions))
+	})
+
+	t.Run("replays after moving backwards", func(t *testing.T) {
+		// setup
+		mem := inmemory.New()
+		broker := New(mem, &mockRegistry{}, &mockProtocol{}, WithPositions(mem))
+		for i := 0; i < 4; i++ {
+			_, err := mem.WriteRecords(ctx, group.WithEntity("1"), record.Data{ContentType: "application/json", Data: []byte(`{}`)})
+			assert.NoError(t, err)
+		}
+		log := &mockNumberLog{}
+		assert.NoError(t, broker.Register(ctx, "M", group, log))
+		broker.wake(group.Group)
+		assert.Eventually(t, func() bool { return len(log.numbers()) == 4 }, time.Second, 10*time.Millisecond)
+		// execute
+		err := broker.MoveSubscriber(ctx, "M", group, 0, StartAtNumber(3))
+		// verify
+		assert.NoError(t, err)
+		assert.Eventually(t, func() bool { return len(log.numbers()) == 6 }, time.Second, 10*time.Millisecond)
+		assert.Equal(t, []int64{1, 2, 3, 4, 3, 4}, log.numbers())
+	})
+
+	t.Run("moves each partition", func(t *testing.T) {
+		// setup
+		mem := inmemory.New()
+		broker := New(mem, &mockRegistry{}, &mockProtocol{}, WithPositions(mem))
+		// execute
+		err := broker.MoveSubscriber(ctx, "P", group, 2, StartAtNumber(8))
+		// verify
+		assert.NoError(t, err)
+		tx, err := mem.Begin(ctx)
+		assert.NoError(t, err)
+		defer func() {
+			_ = tx.Rollback()
+		}()
+		positions, err := mem.SubscriptionPositionLock(tx, group, "P/0", "P/1")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(positions))
+		for _, position := range positions {
+			assert.Equal(t, int64(7), position.Position)
+		}
+	})
+}
@@ -66,6 +66,7 @@ func (c *Connection) Channel() (*Channel, error) {
 
 	channel := &Channel{
 		Channel: ch,
+		cfg:     c.cfg,
 	}
 
 	go func() {
@@ -13,6 +13,43 @@ import (
 	"github.com/streadway/amqp"
 )
 
+const (
+	headerMessageId      = "po-message-id"
+	headerCausationId    = "po-causation-id"
+	headerMetadataPrefix = "po-metadata-"
+)
+
+func toHeaders(r record.Record) amqp.Table {
+	headers := amqp.Table{
+		headerMessageId:   r.MessageId,
+		headerCausationId: r.CausationId,
+	}
+	for key, value := range r.Metadata {
+		headers[headerMetadataPrefix+key] = []byte(value)
+	}
+	return headers
+}
+
+func fromHeaders(headers amqp.Table) (string, string, streams.Metadata) {
+	messageId, _ := headers[headerMessageId].(string)
+	causationId, _ := headers[headerCausationId].(string)
+	var metadata streams.Metadata
+	for key, value := range headers {
+		if !strings.HasPrefix(key, headerMetadataPrefix) {
+			continue
+		}
+		b, ok := value.([]byte)
+		if !ok {
+			continue
+		}
+		if metadata == nil {
+			metadata = make(streams.Metadata)
+		}
+		metadata[strings.TrimPrefix(key, headerMetadataPrefix)] = b
+	}
+	return messageId, causationId, metadata
+}
+
 func New(amqpUrl string, exchange string, instanceId string, opts ...Option) *Transport {
 	var defaultConfig = config{
 		Log:             noopLogger{},
@@ -34,12 +71,20 @@ func New(amqpUrl string, exchange string, instanceId string, opts ...Option) *Tr
 	}
 
 	return &Transport{
-		cfg: defaultConfig,
+		cfg:  defaultConfig,
+		done: make(chan struct{}),
 	}
 }
 
 type Transport struct {
 	cfg config
+
+	mu        sync.Mutex // guards the fields below
+	conns     []*Connection
+	channels  []*Channel
+	closed    bool
+	done      chan struct{}  // closed with the transport
+	consumers sync.WaitGroup // consumer goroutines running
 }
 
 func (t *Transport) Register(ctx context.Context, group string, consumer broker.RecordHandler) (broker.RecordHandler, error) {
@@ -47,16 +92,52 @@ func (t *Transport) Register(ctx context.Context, group string, consumer broker.
 	if err != nil {
 		return nil, err
 	}
-	publisher, err := newPublish(t.cfg, group)
+	ch, err := t.connect()
 	if err != nil {
 		return nil, err
 	}
+	return newPublish(t.cfg, ch, group), nil
+}
 
-	return publisher, nil
+func (t *Transport) Publisher(ctx context.Context, group string) (broker.RecordHandler, error) {
+	ch, err := t.connect()
+	if err != nil {
+		return nil, err
+	}
+	return newPublish(t.cfg, ch, group), nil
+}
+
+// Stops the consumers once the messages in flight are handled, and closes the connections
+func (t *Transport) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	close(t.done)
+	t.mu.Unlock()
+
+	t.consumers.Wait()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var err error
+	for _, ch := range t.channels {
+		if chErr := ch.Close(); chErr != nil && chErr != amqp.ErrClosed && err == nil {
+			err = chErr
+		}
+	}
+	for _, conn := range t.conns {
+		if connErr := conn.Close(); connErr != nil && connErr != amqp.ErrClosed && err == nil {
+			err = connErr
+		}
+	}
+	return err
 }
 
 func (t *Transport) newConsume(ctx context.Context, group string, input broker.RecordHandler) error {
-	ch, err := connect(t.cfg)
+	ch, err := t.connect()
 	if err != nil {
 		return err
 	}
@@ -98,12 +179,19 @@ func (t *Transport) newConsume(ctx context.Context, group string, input broker.R
 		return err
 	}
 
+	t.consumers.Add(1)
 	go func() {
+		defer t.consumers.Done()
 		for {
 			select {
 			case <-ctx.Done():
-				break
-			case msg := <-deliveries:
+				return
+			case <-t.done:
+				return
+			case msg, ok := <-deliveries:
+				if !ok {
+					return
+				}
 				t.consumeMessage(context.Background(), msg, input)
 			}
 		}
@@ -145,7 +233,9 @@ func (t *Transport) deliverMessage(ctx context.Context, msg amqp.Delivery, input
 	}
 	stream := streams.ParseId(streamId)
 
+	messageId, causationId, metadata := fromHeaders(msg.Headers)
 	ack, err := input.Handle(ctx, record.Record{
+		MessageId:     messageId,
 		Number:        number,
 		Stream:        stream,
 		Data:          msg.Body,
@@ -154,6 +244,8 @@ func (t *Transport) deliverMessage(ctx context.Context, msg amqp.Delivery, input
 		GlobalNumber:  globalNumber,
 		Time:          msg.Timestamp,
 		CorrelationId: msg.CorrelationId,
+		CausationId:   causationId,
+		Metadata:      metadata,
 	})
 	if err != nil {
 		return err
@@ -168,16 +260,12 @@ func (t *Transport) deliverMessage(ctx context.Context, msg amqp.Delivery, input
 	return nil
 }
 
-func newPublish(cfg config, group string) (broker.RecordHandlerFunc, error) {
-	ch, err := connect(cfg)
-	if err != nil {
-		return nil, err
-	}
+func newPublish(cfg config, ch *Channel, group string) broker.RecordHandlerFunc {
 	return func(ctx context.Context, r record.Record) (bool, error) {
 		var wg sync.WaitGroup
 		wg.Add(1)
 		err := cfg.MiddlewarePublish(ctx, amqp.Publishing{
-			Headers:         amqp.Table{},
+			Headers:         toHeaders(r),
 			ContentType:     r.ContentType,
 			ContentEncoding: "",
 			DeliveryMode:    amqp.Transient,
@@ -200,27 +288,35 @@ func newPublish(cfg config, group string) (broker.RecordHandlerFunc, error) {
 		})
 		wg.Wait()
 		return true, err
-	}, nil
+	}
 }
 
-func connect(cfg config) (*Channel, error) {
-	conn, err := dial(cfg)
+// opens a channel on a connection of its own, both closed with the transport
+func (t *Transport) connect() (*Channel, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil, fmt.Errorf("po/rabbit transport closed")
+	}
+	conn, err := dial(t.cfg)
 	if err != nil {
 		return nil, err
 	}
+	t.conns = append(t.conns, conn)
 
 	channel, err := conn.Channel()
 	if err != nil {
 		return nil, err
 	}
+	t.channels = append(t.channels, channel)
 	return channel, channel.ExchangeDeclare(
-		cfg.Exchange, // name
-		"direct",     // type
-		true,         // durable
-		false,        // auto-deleted
-		false,        // internal
-		false,        // noWait
-		nil,          // arguments
+		t.cfg.Exchange, // name
+		"direct",       // type
+		true,           // durable
+		false,          // auto-deleted
+		false,          // internal
+		false,          // noWait
+		nil,            // arguments
 	)
 }
 
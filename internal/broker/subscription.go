@@ -4,9 +4,7 @@ import (
 	"context"
 	"sync"
 
-	"github.com/go-po/po/internal/pager"
 	"github.com/go-po/po/internal/record"
-	"github.com/go-po/po/internal/store"
 	"github.com/go-po/po/streams"
 )
 
@@ -28,130 +26,138 @@ type subscription struct {
 	stream        streams.Id
 	store         Store
 	registry      Registry
+	heldBack      HeldBackStore // nil if the store holds back no records
+	onHeldBack    func()        // called when records are held back from a subscriber
 }
 
-// called when messages are received from the protocol transport
+// Called when messages are received from the protocol transport.
+// Each subscriber processes the records after its own position, in batches of its own transactions.
+// Subscribers still busy with an earlier call are not waited for, they process the records again once done.
 func (sub *subscription) Handle(ctx context.Context, record record.Record) (bool, error) {
-	sub.mu.Lock()
-	defer sub.mu.Unlock()
-
-	if len(sub.ids) == 0 {
-		return true, nil
-	}
-
-	tx, err := sub.store.Begin(ctx)
-	if err != nil {
-		return false, err
-	}
-	defer func() {
-		_ = tx.Rollback()
-	}()
+	return sub.CatchUp(ctx, func(id streams.Id, subscriptionId string) bool {
+		return true
+	})
+}
 
-	min, err := updatePosition(sub.store, tx, sub.stream, sub.subscriptions)
-	if err != nil {
-		return false, err
+// Runs the subscribers behind the head of their stream, as told by behind, like Handle does
+func (sub *subscription) CatchUp(ctx context.Context, behind func(id streams.Id, subscriptionId string) bool) (bool, error) {
+	sub.mu.Lock()
+	var handlers []*streamHandler
+	for _, subscriberId := range sub.ids {
+		handler := sub.subscriptions[subscriberId]
+		if behind(handler.stream, handler.id) {
+			handlers = append(handlers, handler)
+		}
 	}
+	sub.mu.Unlock()
 
-	var to int64 = record.GlobalNumber
-	if sub.stream.HasEntity() {
-		to = record.Number
+	if len(handlers) == 0 {
+		return true, nil
 	}
 
-	boxes, wg := sub.startSubscriptionProcessors(ctx, tx)
-
-	err = pager.FromTo(min, to, 50, sub.readRecordsPaged(ctx, boxes))
-
-	// Done writing, now close the inboxes
-	for _, box := range boxes {
-		close(box)
+	wg := &sync.WaitGroup{}
+	errs := make([]error, len(handlers))
+	for i, handler := range handlers {
+		if !handler.claim() {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, handler *streamHandler) {
+			defer wg.Done()
+			errs[i] = handler.run(ctx)
+		}(i, handler)
 	}
-
 	wg.Wait()
 
-	if err != nil {
-		return false, err
+	for i, handler := range handlers {
+		if errs[i] == nil && handler.isHeldBack() {
+			sub.onHeldBack()
+			break
+		}
 	}
-
-	err = tx.Commit()
-	if err != nil {
-		return false, err
+	for _, err := range errs {
+		if err != nil {
+			return false, err
+		}
 	}
-
 	return true, nil
 }
 
-func (sub *subscription) AddSubscriber(id streams.Id, subscriberId string, subscriber streams.Handler) {
+func (sub *subscription) AddSubscriber(id streams.Id, subscriberId string, subscriber streams.Handler, opts ...SubscriberOption) {
 	sub.mu.Lock()
 	defer sub.mu.Unlock()
-	sub.subscriptions[subscriberId] = newStreamHandler(id, subscriberId, sub.store, subscriber)
+	handler := newStreamHandler(id, subscriberId, sub.store, sub.registry, subscriber)
+	handler.heldBack = sub.heldBack
+	for _, opt := range opts {
+		opt(handler)
+	}
+	sub.subscriptions[subscriberId] = handler
 	sub.ids = append(sub.ids, subscriberId)
 }
 
-func (sub *subscription) readRecordsPaged(ctx context.Context, boxes []chan streams.Message) pager.Func {
-	return func(from, to, limit int64) (int, error) {
-		records, err := sub.store.ReadRecords(ctx, sub.stream, from, to, limit)
-		if err != nil {
-			return 0, err
-		}
-
-		for _, r := range records {
-			msg, err := sub.registry.ToMessage(r)
-			if err != nil {
-				return 0, err
-			}
-			for _, box := range boxes {
-				box <- msg
-			}
+// Reports true if the subscriber is part of the subscription, for any stream of the group
+func (sub *subscription) HasSubscriber(subscriberId string) bool {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	for _, handler := range sub.subscriptions {
+		if handler.subscriberId == subscriberId {
+			return true
 		}
-		return len(records), nil
 	}
+	return false
 }
 
-func (sub *subscription) startSubscriptionProcessors(ctx context.Context, tx store.Tx) ([]chan streams.Message, *sync.WaitGroup) {
-	var boxes []chan streams.Message
-	wg := &sync.WaitGroup{}
-	for _, s := range sub.subscriptions {
-		inbox := make(chan streams.Message)
-
-		wg.Add(1)
-		go func(handler *streamHandler) {
-			handler.Process(ctx, tx, inbox)
-			wg.Done()
-		}(s)
+// Removes the handlers of the subscriber, once their batches in flight are committed.
+// Reports false if the subscriber is not part of the subscription.
+func (sub *subscription) RemoveSubscriber(subscriberId string) bool {
+	sub.mu.Lock()
+	var removed []*streamHandler
+	var ids []string
+	for _, id := range sub.ids {
+		handler := sub.subscriptions[id]
+		if handler.subscriberId != subscriberId {
+			ids = append(ids, id)
+			continue
+		}
+		removed = append(removed, handler)
+		delete(sub.subscriptions, id)
+	}
+	sub.ids = ids
+	sub.mu.Unlock()
 
-		boxes = append(boxes, inbox)
+	for _, handler := range removed {
+		handler.stop()
 	}
-	return boxes, wg
+	return len(removed) > 0
 }
 
-func updatePosition(dao Store, tx store.Tx, id streams.Id, subs map[string]*streamHandler) (int64, error) {
-	var ids []string
-	for subId, _ := range subs {
-		ids = append(ids, subId)
-	}
-	positions, err := dao.SubscriptionPositionLock(tx, id, ids...)
-	if err != nil {
-		return -1, err
+// Stops every handler, once their batches in flight are committed
+func (sub *subscription) Close() {
+	sub.mu.Lock()
+	var handlers []*streamHandler
+	for _, id := range sub.ids {
+		handlers = append(handlers, sub.subscriptions[id])
 	}
+	sub.mu.Unlock()
 
-	if len(positions) == 0 {
-		for id, _ := range subs {
-			positions = append(positions, store.SubscriptionPosition{
-				SubscriptionId: id,
-				Position:       -1,
-			})
-		}
+	for _, handler := range handlers {
+		handler.stop()
 	}
+}
 
-	var leastPosition int64 = positions[0].Position
-	for _, p := range positions {
-		if p.Position < leastPosition {
-			leastPosition = p.Position
-		}
-		subscriber, found := subs[p.SubscriptionId]
-		if found {
-			subscriber.position = p.Position
+// Hands the message to the handler of the subscriber, reporting false if it is not part of the subscription
+func (sub *subscription) Replay(ctx context.Context, subscriberId string, msg streams.Message) (bool, error) {
+	sub.mu.Lock()
+	var handler *streamHandler
+	for _, id := range sub.ids {
+		if sub.subscriptions[id].subscriberId == subscriberId {
+			handler = sub.subscriptions[id]
+			break
 		}
 	}
-	return leastPosition, nil
+	sub.mu.Unlock()
+	if handler == nil {
+		return false, nil
+	}
+	return true, handler.handler.Handle(streams.ContextWithMessage(ctx, msg), msg)
 }
@@ -2,122 +2,136 @@ package broker
 
 import (
 	"context"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/go-po/po/internal/record"
 	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/internal/store/inmemory"
 	"github.com/go-po/po/streams"
 	"github.com/stretchr/testify/assert"
 )
 
-type mockUpdatePositionStore struct {
-	Store
-	positions []store.SubscriptionPosition
-	records   []record.Record
-}
-
-func (mock *mockUpdatePositionStore) SubscriptionPositionLock(tx store.Tx, id streams.Id, subscriptionIds ...string) ([]store.SubscriptionPosition, error) {
-	return mock.positions, nil
-}
-
-func (mock *mockUpdatePositionStore) ReadRecords(ctx context.Context, id streams.Id, from, to, limit int64) ([]record.Record, error) {
-	return mock.records, nil
-}
-
 type mockBrokerTx struct {
+	mu       sync.Mutex
 	commit   bool
 	rollback bool
 }
 
 func (mock *mockBrokerTx) Commit() error {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
 	mock.commit = true
 	return nil
 }
 
 func (mock *mockBrokerTx) Rollback() error {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
 	mock.rollback = true
 	return nil
 }
 
-func TestUpdatePosition(t *testing.T) {
-	tx := &mockBrokerTx{}
-	id := streams.ParseId("updateposition")
-
-	mockStreamHandler := func(p int64) *streamHandler {
-		return &streamHandler{
-			handler: streams.HandlerFunc(func(ctx context.Context, msg streams.Message) error {
-				return nil
-			}),
-			stream:   id,
-			position: p,
-		}
-	}
+// counts the transactions begun
+type mockCountingStore struct {
+	*inmemory.InMemory
+	mu    sync.Mutex
+	begun int
+}
 
-	t.Run("one match", func(t *testing.T) {
-		// setup
-		store := &mockUpdatePositionStore{positions: []store.SubscriptionPosition{
-			{
-				SubscriptionId: "a",
-				Position:       5,
-			},
-		}}
-		data := map[string]*streamHandler{
-			"a": mockStreamHandler(0),
+func (mock *mockCountingStore) Begin(ctx context.Context) (store.Tx, error) {
+	mock.mu.Lock()
+	mock.begun = mock.begun + 1
+	mock.mu.Unlock()
+	return mock.InMemory.Begin(ctx)
+}
+
+func writeRecords(t *testing.T, mem *inmemory.InMemory, id streams.Id, count int) {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		_, err := mem.WriteRecords(context.Background(), id, record.Data{
+			ContentType: "application/json",
+			Data:        []byte(`{}`),
+		})
+		if !assert.NoError(t, err) {
+			t.FailNow()
 		}
-		least, err := updatePosition(store, tx, id, data)
+	}
+}
 
-		// verify
-		assert.NoError(t, err)
-		assert.Equal(t, 5, int(data["a"].position))
-		assert.Equal(t, 5, int(least))
-	})
+func TestSubscription_Handle(t *testing.T) {
+	ctx := context.Background()
 
-	t.Run("one mismatch", func(t *testing.T) {
+	t.Run("batches", func(t *testing.T) {
 		// setup
-		store := &mockUpdatePositionStore{positions: []store.SubscriptionPosition{}}
-		data := map[string]*streamHandler{
-			"a": mockStreamHandler(0),
-		}
-		least, err := updatePosition(store, tx, id, data)
+		mem := &mockCountingStore{InMemory: inmemory.New()}
+		writeRecords(t, mem.InMemory, streams.ParseId("batches-a"), 5)
+		sub := newSub(&mockRegistry{}, mem, "batches")
+		handler := newCountingHandler()
+		sub.AddSubscriber(streams.ParseId("batches"), "A", handler, WithBatchSize(2))
 
-		// verify
-		assert.NoError(t, err)
-		assert.Equal(t, -1, int(data["a"].position))
-		assert.Equal(t, -1, int(least))
-	})
-	t.Run("multiple", func(t *testing.T) {
-		// setup
-		store := &mockUpdatePositionStore{positions: []store.SubscriptionPosition{
-			{"a", 5},
-			{"b", 8},
-		}}
-		data := map[string]*streamHandler{
-			"a": mockStreamHandler(0),
-			"b": mockStreamHandler(2),
-		}
-		least, err := updatePosition(store, tx, id, data)
+		// execute
+		_, err := sub.Handle(ctx, record.Record{})
 
 		// verify
 		assert.NoError(t, err)
-		assert.Equal(t, 5, int(data["a"].position))
-		assert.Equal(t, 8, int(data["b"].position))
-		assert.Equal(t, 5, int(least))
+		assert.Equal(t, 5, handler.count)
+		assert.Equal(t, 3, mem.begun, "transactions")
+		tx, _ := mem.Begin(ctx)
+		positions, err := mem.SubscriptionPositionLock(tx, streams.ParseId("batches"), "A")
+		_ = tx.Rollback()
+		assert.NoError(t, err)
+		assert.Equal(t, []store.SubscriptionPosition{{SubscriptionId: "A", Position: 5}}, positions)
 	})
 
-	t.Run("missing sub", func(t *testing.T) {
+	t.Run("slow subscriber", func(t *testing.T) {
 		// setup
-		store := &mockUpdatePositionStore{positions: []store.SubscriptionPosition{
-			{"a", 5},
-			{"b", 8},
-		}}
-		data := map[string]*streamHandler{
-			"a": mockStreamHandler(0),
-		}
-		least, err := updatePosition(store, tx, id, data)
+		mem := inmemory.New()
+		id := streams.ParseId("slow-a")
+		writeRecords(t, mem, id, 1)
+		sub := newSub(&mockRegistry{}, mem, "slow")
+
+		blocked := make(chan struct{})
+		release := make(chan struct{})
+		mu := sync.Mutex{}
+		var slow []int64
+		sub.AddSubscriber(streams.ParseId("slow"), "slow", streams.HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+			if msg.GlobalNumber == 1 {
+				close(blocked)
+				<-release
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			slow = append(slow, msg.GlobalNumber)
+			return nil
+		}))
+		fast := newCountingHandler()
+		sub.AddSubscriber(streams.ParseId("slow"), "fast", fast)
+
+		first := make(chan error)
+		go func() {
+			_, err := sub.Handle(ctx, record.Record{})
+			first <- err
+		}()
+		<-blocked
+		writeRecords(t, mem, id, 1)
+
+		// execute
+		_, err := sub.Handle(ctx, record.Record{})
 
 		// verify
 		assert.NoError(t, err)
-		assert.Equal(t, 5, int(data["a"].position))
-		assert.Equal(t, 5, int(least))
+		assert.Equal(t, 2, fast.count, "fast not held back")
+		close(release)
+		select {
+		case err := <-first:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("slow subscriber never done")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, []int64{1, 2}, slow, "slow caught up")
 	})
 }
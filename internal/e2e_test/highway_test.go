@@ -40,10 +40,12 @@ func TestHighwayApp(t *testing.T) {
 			store: pg(), protocol: rabbit(), apps: 5, subs: 2, cars: 10, timeout: time.Second * 5},
 		{name: "channel broker",
 			store: pg(), protocol: channel(), apps: 1, subs: 2, cars: 10, timeout: time.Second * 2},
-		/*{name: "inmemory/channel",
+		{name: "postgres notify",
+			store: pg(), protocol: pgNotify(), apps: 3, subs: 2, cars: 10, timeout: time.Second * 5},
+		{name: "inmemory/channel",
 			store: inmem(), protocol: channel(), apps: 1, subs: 5, cars: 10, timeout: time.Second},
 		{name: "inmemory/rabbit",
-			store: inmem(), protocol: rabbit(), apps: 1, subs: 5, cars: 10, timeout: time.Second * 5},*/
+			store: inmem(), protocol: rabbit(), apps: 1, subs: 5, cars: 10, timeout: time.Second * 5},
 	}
 
 	for _, test := range tests {
@@ -107,7 +109,7 @@ func (app *highwayApp) start(t *testing.T) {
 	}
 
 	for subId, counter := range app.counters {
-		err = app.es.Subscribe(context.Background(), subId, app.streamId, counter)
+		_, err = app.es.Subscribe(context.Background(), subId, app.streamId, counter)
 		if !assert.NoError(t, err, "setup subscriber [%d].[%s]", app.id, subId) {
 			t.Fail()
 		}
@@ -0,0 +1,183 @@
+package e2e_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-po/po"
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+type Tick struct {
+	Writer int
+	Tick   int
+}
+
+func TestConcurrentAppenders(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	po.RegisterMessages(func(b []byte) (interface{}, error) {
+		msg := Tick{}
+		err := json.Unmarshal(b, &msg)
+		return msg, err
+	})
+
+	tests := []struct {
+		name    string
+		store   StoreBuilder
+		writers int // concurrent appenders
+		ticks   int // messages appended by each writer
+		timeout time.Duration
+	}{
+		{name: "postgres", store: pg(), writers: 20, ticks: 25, timeout: time.Second * 20},
+		{name: "mysql", store: maria(), writers: 20, ticks: 25, timeout: time.Second * 20},
+		{name: "inmemory", store: inmem(), writers: 20, ticks: 25, timeout: time.Second * 5},
+		{name: "sqlite", store: lite(), writers: 20, ticks: 25, timeout: time.Second * 20},
+		{name: "filelog", store: files(), writers: 20, ticks: 25, timeout: time.Second * 20},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// setup
+			ctx := context.Background()
+			store, err := test.store()
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			es, err := po.NewFromOptions(
+				po.WithStore(store),
+				po.WithProtocolChannels(),
+			)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			group := randStreamId("ticks", "")
+
+			expected := test.writers * test.ticks
+			mu := sync.Mutex{}
+			seen := make(map[string]int)
+			var last int64
+			outOfOrder := 0
+			done := make(chan struct{})
+			_, err = es.Subscribe(ctx, "ticks", group, po.HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+				mu.Lock()
+				defer mu.Unlock()
+				if msg.GlobalNumber <= last {
+					outOfOrder = outOfOrder + 1
+				}
+				last = msg.GlobalNumber
+				tick := msg.Data.(Tick)
+				key := fmt.Sprintf("%d:%d", tick.Writer, tick.Tick)
+				seen[key]++
+				if seen[key] == 1 && len(seen) == expected {
+					close(done)
+				}
+				return nil
+			}))
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+
+			// execute
+			wg := sync.WaitGroup{}
+			for writer := 0; writer < test.writers; writer++ {
+				wg.Add(1)
+				go func(writer int) {
+					defer wg.Done()
+					id := group.WithEntity(fmt.Sprintf("writer-%d", writer))
+					for tick := 0; tick < test.ticks; tick++ {
+						_, err := es.Append(ctx, id, Tick{Writer: writer, Tick: tick})
+						assert.NoError(t, err)
+					}
+				}(writer)
+			}
+			wg.Wait()
+
+			// verify
+			select {
+			case <-done:
+			case <-time.After(test.timeout):
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(t, expected, len(seen), "messages received")
+			for key, count := range seen {
+				assert.Equal(t, 1, count, "message %s received once", key)
+			}
+			assert.Equal(t, 0, outOfOrder, "messages received out of global order")
+		})
+	}
+}
+
+func TestHeldBackByOpenTransaction(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	// setup
+	ctx := context.Background()
+	po.RegisterMessages(func(b []byte) (interface{}, error) {
+		msg := Tick{}
+		err := json.Unmarshal(b, &msg)
+		return msg, err
+	})
+	conn, err := sql.Open("postgres", postgresUrl)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	es, err := po.NewFromOptions(
+		po.WithStorePostgresDB(conn),
+		po.WithProtocolChannels(),
+		po.WithCatchUp(po.CatchUpPolicy{}),
+	)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	group := randStreamId("held", "")
+	mu := sync.Mutex{}
+	received := 0
+	_, err = es.Subscribe(ctx, "held", group, po.HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		received = received + 1
+		return nil
+	}))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return received
+	}
+
+	// a transaction older than the append, writing elsewhere
+	open, err := conn.BeginTx(ctx, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer func() {
+		_ = open.Rollback()
+	}()
+	_, err = open.ExecContext(ctx, "SELECT txid_current()")
+	assert.NoError(t, err)
+
+	// execute
+	_, err = es.Append(ctx, group.WithEntity("1"), Tick{Writer: 1})
+	assert.NoError(t, err)
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 0, count(), "held back while the older transaction is open")
+	assert.NoError(t, open.Commit())
+
+	// verify
+	assert.Eventually(t, func() bool { return count() == 1 }, 5*time.Second, 10*time.Millisecond, "read again once released")
+}
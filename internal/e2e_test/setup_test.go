@@ -1,23 +1,31 @@
 package e2e_test
 
 import (
+	"database/sql"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
+	"path/filepath"
 	"strconv"
 	"time"
 
 	"github.com/go-po/po"
 	"github.com/go-po/po/internal/broker"
 	"github.com/go-po/po/internal/broker/channels"
+	"github.com/go-po/po/internal/broker/pgnotify"
 	"github.com/go-po/po/internal/broker/rabbitmq"
 	"github.com/go-po/po/internal/logger"
+	"github.com/go-po/po/internal/store/filelog"
 	"github.com/go-po/po/internal/store/inmemory"
+	"github.com/go-po/po/internal/store/mysql"
 	"github.com/go-po/po/internal/store/postgres"
+	"github.com/go-po/po/internal/store/sqlite"
 	"github.com/go-po/po/streams"
 )
 
 const (
 	postgresUrl = "postgres://po:po@localhost:5431/po?sslmode=disable"
+	mysqlUrl    = "po:po@tcp(localhost:3305)/po"
 	rabbitmqUrl = "amqp://po:po@localhost:5671/"
 )
 
@@ -29,12 +37,38 @@ func pg() StoreBuilder {
 	}
 }
 
+func maria() StoreBuilder {
+	return func() (po.Store, error) {
+		return mysql.NewFromUrl(mysqlUrl)
+	}
+}
+
 func inmem() StoreBuilder {
 	return func() (store po.Store, err error) {
 		return inmemory.New(), nil
 	}
 }
 
+func lite() StoreBuilder {
+	return func() (po.Store, error) {
+		dir, err := ioutil.TempDir("", "po-e2e")
+		if err != nil {
+			return nil, err
+		}
+		return sqlite.New(filepath.Join(dir, "po.db"))
+	}
+}
+
+func files() StoreBuilder {
+	return func() (po.Store, error) {
+		dir, err := ioutil.TempDir("", "po-e2e")
+		if err != nil {
+			return nil, err
+		}
+		return filelog.New(dir)
+	}
+}
+
 type ProtocolBuilder func(id int) broker.Protocol
 
 func rabbit() ProtocolBuilder {
@@ -52,6 +86,16 @@ func channel() ProtocolBuilder {
 	}
 }
 
+func pgNotify() ProtocolBuilder {
+	return func(id int) broker.Protocol {
+		db, err := sql.Open("postgres", postgresUrl)
+		if err != nil {
+			panic(err)
+		}
+		return pgnotify.New(db, postgresUrl)
+	}
+}
+
 func init() {
 	rand.Seed(time.Now().UnixNano())
 }
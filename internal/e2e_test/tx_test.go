@@ -0,0 +1,173 @@
+package e2e_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-po/po"
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+type txMessage struct {
+	Name string
+}
+
+func TestApplicationTx(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	ctx := context.Background()
+	po.RegisterMessages(func(b []byte) (interface{}, error) {
+		msg := txMessage{}
+		err := json.Unmarshal(b, &msg)
+		return msg, err
+	})
+
+	conn, err := sql.Open("postgres", postgresUrl)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	// later options replace the defaults
+	setup := func(t *testing.T, opts ...po.Option) (*po.Po, streams.Id, func() int) {
+		es, err := po.NewFromOptions(append([]po.Option{
+			po.WithStorePostgresDB(conn),
+			po.WithProtocolChannels(),
+		}, opts...)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		id := randStreamId("tx", "1")
+		mu := sync.Mutex{}
+		received := 0
+		_, err = es.Subscribe(ctx, "tx", streams.ParseId(id.Group), po.HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			received = received + 1
+			return nil
+		}))
+		assert.NoError(t, err)
+		return es, id, func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return received
+		}
+	}
+
+	t.Run("commit", func(t *testing.T) {
+		// setup
+		es, id, received := setup(t)
+		sqlTx, err := conn.BeginTx(ctx, nil)
+		assert.NoError(t, err)
+		tx, err := es.WithTx(ctx, sqlTx)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		_, err = tx.Append(id, txMessage{Name: "committed"})
+		assert.NoError(t, err)
+		// verify
+		inside := 0
+		err = tx.Project(id, po.HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+			inside = inside + 1
+			return nil
+		}))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, inside, "read own writes")
+		assert.Equal(t, 0, received(), "notified before commit")
+
+		assert.NoError(t, tx.Commit())
+		assert.Eventually(t, func() bool { return received() == 1 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("postgres notify after commit", func(t *testing.T) {
+		// setup
+		es, id, received := setup(t, po.WithProtocolPostgres(conn, postgresUrl))
+		defer func() {
+			_ = es.Close(ctx)
+		}()
+		sqlTx, err := conn.BeginTx(ctx, nil)
+		assert.NoError(t, err)
+		tx, err := es.WithTx(ctx, sqlTx)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		_, err = tx.Append(id, txMessage{Name: "committed"})
+		// verify
+		assert.NoError(t, err)
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, 0, received(), "notified before commit")
+		assert.NoError(t, tx.Commit())
+		assert.Eventually(t, func() bool { return received() == 1 }, 5*time.Second, time.Millisecond)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		// setup
+		es, id, received := setup(t)
+		sqlTx, err := conn.BeginTx(ctx, nil)
+		assert.NoError(t, err)
+		tx, err := es.WithTx(ctx, sqlTx)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		_, err = tx.Append(id, txMessage{Name: "discarded"})
+		assert.NoError(t, err)
+		// execute
+		err = tx.Rollback()
+		// verify
+		assert.NoError(t, err)
+		stored := 0
+		err = es.Project(ctx, id, po.HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+			stored = stored + 1
+			return nil
+		}))
+		assert.NoError(t, err)
+		assert.Equal(t, 0, stored)
+		assert.Equal(t, 0, received())
+	})
+
+	t.Run("subscription position on a connection", func(t *testing.T) {
+		// setup
+		es, id, _ := setup(t)
+		sqlConn, err := conn.Conn(ctx)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer func() {
+			_ = sqlConn.Close()
+		}()
+		_, err = sqlConn.ExecContext(ctx, "BEGIN")
+		assert.NoError(t, err)
+		tx, err := es.WithTx(ctx, sqlConn)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		group := streams.ParseId(id.Group)
+		// execute
+		err = tx.SetSubscriptionPosition(group, "tx-read-model", 4)
+		// verify
+		assert.NoError(t, err)
+		_, err = sqlConn.ExecContext(ctx, "COMMIT")
+		assert.NoError(t, err)
+		assert.NoError(t, tx.Commit())
+		states, err := es.Subscriptions(ctx)
+		assert.NoError(t, err)
+		found := false
+		for _, state := range states {
+			if state.Stream == group && state.SubscriptionId == "tx-read-model" {
+				found = true
+				assert.Equal(t, int64(4), state.Position, "first position as given")
+			}
+		}
+		assert.True(t, found, "position stored")
+	})
+}
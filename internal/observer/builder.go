@@ -5,6 +5,7 @@ import (
 	"github.com/go-po/po/internal/observer/binary"
 	"github.com/go-po/po/internal/observer/nullary"
 	"github.com/go-po/po/internal/observer/unary"
+	"github.com/go-po/po/internal/observer/value"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -58,3 +59,7 @@ func (builder *Builder) Nullary() *nullary.Builder {
 func (builder *Builder) Binary() *binary.Builder {
 	return binary.NewBuilder(builder.Logger, builder.metrics)
 }
+
+func (builder *Builder) Value() *value.Builder {
+	return value.NewBuilder(builder.Logger, builder.metrics)
+}
@@ -0,0 +1,82 @@
+package value
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Logger interface {
+	Debugf(template string, args ...interface{})
+	Errorf(template string, args ...interface{})
+	Infof(template string, args ...interface{})
+	Errf(err error, template string, args ...interface{})
+}
+
+// Observes measured values, like sizes or ages
+type ClientTrace interface {
+	Observe(ctx context.Context, value float64)
+}
+
+type ClientTraceFunc func(ctx context.Context, value float64)
+
+func (fn ClientTraceFunc) Observe(ctx context.Context, value float64) {
+	fn(ctx, value)
+}
+
+func Combine(traces ...ClientTrace) ClientTrace {
+	return ClientTraceFunc(func(ctx context.Context, value float64) {
+		for _, trace := range traces {
+			trace.Observe(ctx, value)
+		}
+	})
+}
+
+func Noop() ClientTrace {
+	return ClientTraceFunc(func(ctx context.Context, value float64) {})
+}
+
+func LogDebugf(logger Logger, format string, args ...interface{}) ClientTrace {
+	return ClientTraceFunc(func(ctx context.Context, value float64) {
+		logger.Debugf(format, append(args, value)...)
+	})
+}
+
+func NewBuilder(logger Logger, metrics prometheus.Registerer) *Builder {
+	return &Builder{
+		logger:  logger,
+		metrics: metrics,
+	}
+}
+
+type Builder struct {
+	logger  Logger
+	metrics prometheus.Registerer
+	traces  []ClientTrace
+}
+
+func (builder *Builder) Build() ClientTrace {
+	return Combine(builder.traces...)
+}
+
+func (builder *Builder) LogDebugf(format string, args ...interface{}) *Builder {
+	builder.traces = append(builder.traces, LogDebugf(builder.logger, format, args...))
+	return builder
+}
+
+// Sets the gauge to the value
+func (builder *Builder) Gauge(gauge prometheus.Gauge) *Builder {
+	builder.metrics.MustRegister(gauge)
+	builder.traces = append(builder.traces, ClientTraceFunc(func(ctx context.Context, value float64) {
+		gauge.Set(value)
+	}))
+	return builder
+}
+
+func (builder *Builder) Histogram(histogram prometheus.Histogram) *Builder {
+	builder.metrics.MustRegister(histogram)
+	builder.traces = append(builder.traces, ClientTraceFunc(func(ctx context.Context, value float64) {
+		histogram.Observe(value)
+	}))
+	return builder
+}
@@ -9,14 +9,17 @@ import (
 // Internal data structure to pass between
 // the components that make up Po
 type Record struct {
-	Number        int64      // strictly sequential number for all messages in a specific stream
-	Stream        streams.Id // identifier of a stream, see id.go
-	Data          []byte     // raw data for the message
-	Group         string     // message type, used to marshal tye Data correct
-	ContentType   string     // type of the data
-	GlobalNumber  int64      // Number across all records in the Event Source
-	Time          time.Time  // when this message was first recorded
-	CorrelationId string     // connect actions between components
+	MessageId     string           // unique id of the message
+	Number        int64            // strictly sequential number for all messages in a specific stream
+	Stream        streams.Id       // identifier of a stream, see id.go
+	Data          []byte           // raw data for the message
+	Group         string           // message type, used to marshal tye Data correct
+	ContentType   string           // type of the data
+	GlobalNumber  int64            // Number across all records in the Event Source
+	Time          time.Time        // when this message was first recorded
+	CorrelationId string           // connect actions between components
+	CausationId   string           // what caused this record
+	Metadata      streams.Metadata // values stored along with the record
 }
 
 type Snapshot struct {
@@ -26,6 +29,10 @@ type Snapshot struct {
 }
 
 type Data struct {
-	ContentType string
-	Data        []byte
+	MessageId     string
+	ContentType   string
+	Data          []byte
+	CorrelationId string
+	CausationId   string
+	Metadata      streams.Metadata
 }
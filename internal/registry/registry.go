@@ -2,6 +2,7 @@ package registry
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"mime"
@@ -12,6 +13,21 @@ import (
 
 var DefaultRegistry = New()
 
+var ErrUnknownMessageType = errors.New("unknown message type")
+
+// Returned when no message is registered under the type name
+type UnknownMessageTypeError struct {
+	Type string
+}
+
+func (err UnknownMessageTypeError) Error() string {
+	return fmt.Sprintf("unknown message type: %s", err.Type)
+}
+
+func (err UnknownMessageTypeError) Is(target error) bool {
+	return target == ErrUnknownMessageType
+}
+
 const paramNameType = "type"
 
 type Named interface {
@@ -52,13 +68,14 @@ func (reg *Registry) ToMessage(r record.Record) (streams.Message, error) {
 
 	typeName, ok := params[paramNameType]
 	if !ok {
-		return streams.Message{}, fmt.Errorf("registry: field '%s' not in '%s'", paramNameType, r.ContentType)
+		return streams.Message{}, fmt.Errorf("registry: field '%s' not in '%s': %w", paramNameType, r.ContentType, ErrUnknownMessageType)
 	}
 	data, err := reg.Unmarshal(typeName, r.Data)
 	if err != nil {
 		return streams.Message{}, err
 	}
 	return streams.Message{
+		MessageId:     r.MessageId,
 		Number:        r.Number,
 		Stream:        r.Stream,
 		Data:          data,
@@ -66,6 +83,8 @@ func (reg *Registry) ToMessage(r record.Record) (streams.Message, error) {
 		GlobalNumber:  r.GlobalNumber,
 		Time:          r.Time,
 		CorrelationId: r.CorrelationId,
+		CausationId:   r.CausationId,
+		Metadata:      r.Metadata,
 	}, nil
 }
 
@@ -99,7 +118,7 @@ func (reg *Registry) Unmarshal(typeName string, b []byte) (interface{}, error) {
 		for t := range reg.types {
 			log.Printf("%s - %s", typeName, t)
 		}
-		return nil, fmt.Errorf("unknown message type: %s", typeName) // TODO error type
+		return nil, UnknownMessageTypeError{Type: typeName}
 	}
 	return unmarshal(b)
 }
@@ -2,6 +2,7 @@ package registry
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -41,3 +42,16 @@ func TestRegistry_RoundtripMarshal(t *testing.T) {
 		assert.Equal(t, 42, gotA.A)
 	}
 }
+
+func TestRegistry_UnknownMessageType(t *testing.T) {
+	// setup
+	reg := New()
+	// execute
+	_, err := reg.Unmarshal("registry.Unknown", []byte(`{}`))
+	// verify
+	assert.True(t, errors.Is(err, ErrUnknownMessageType))
+	unknown := UnknownMessageTypeError{}
+	if assert.True(t, errors.As(err, &unknown)) {
+		assert.Equal(t, "registry.Unknown", unknown.Type)
+	}
+}
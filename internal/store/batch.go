@@ -0,0 +1,48 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/streams"
+)
+
+const (
+	AnyPosition    int64 = -2 // append at the end of the stream
+	ExistingStream int64 = -3 // append at the end of a stream holding at least one message
+)
+
+// Appends to a single stream as part of a batch written in one transaction
+type StreamWrite struct {
+	Id       streams.Id
+	Position int64 // last position expected in the stream, or AnyPosition / ExistingStream
+	Data     []record.Data
+}
+
+// Verifies a stream with its last message at current can be appended to
+// by a writer expecting position.
+func CheckPosition(id streams.Id, position, current int64) error {
+	switch position {
+	case AnyPosition:
+		return nil
+	case ExistingStream:
+		if current >= 0 {
+			return nil
+		}
+		return WriteConflictError{
+			StreamId: id,
+			Position: current + 1,
+			Current:  current,
+			Err:      ErrStreamNotFound,
+		}
+	}
+	if position == current {
+		return nil
+	}
+	return WriteConflictError{
+		StreamId: id,
+		Position: position + 1,
+		Current:  current,
+		Err:      fmt.Errorf("stream at position %d", current),
+	}
+}
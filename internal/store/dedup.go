@@ -0,0 +1,49 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/streams"
+)
+
+// Decides the outcome of appending data, given the already stored records
+// carrying any of the message ids in data.
+// If none of the messages are stored, nil is returned and the data should be written.
+// If all of them are stored in the stream, the stored records are returned
+// and the append is a no-op.
+// Anything in between is a WriteConflictError.
+func Deduplicate(id streams.Id, current int64, data []record.Data, stored []record.Record) ([]record.Record, error) {
+	if len(stored) == 0 {
+		return nil, nil
+	}
+	byId := make(map[string]record.Record, len(stored))
+	for _, r := range stored {
+		byId[r.MessageId] = r
+	}
+	var result []record.Record
+	for _, d := range data {
+		r, found := byId[d.MessageId]
+		if !found || r.Stream.String() != id.String() {
+			return nil, WriteConflictError{
+				StreamId: id,
+				Position: current + 1,
+				Current:  current,
+				Err:      fmt.Errorf("messages partially written"),
+			}
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+// Message ids of the data, skipping blanks
+func MessageIds(data []record.Data) []string {
+	var ids []string
+	for _, d := range data {
+		if d.MessageId != "" {
+			ids = append(ids, d.MessageId)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,76 @@
+package filelog
+
+import (
+	"bufio"
+	"context"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+const crashDirEnv = "PO_FILELOG_CRASH_DIR"
+
+// Writes until killed, printing the number of every returned write
+func TestFileLog_CrashWriter(t *testing.T) {
+	dir := os.Getenv(crashDirEnv)
+	if dir == "" {
+		t.SkipNow()
+	}
+	log, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := streams.ParseId("crash-a")
+	for i := 0; ; i++ {
+		records, err := log.WriteRecords(context.Background(), id, data(1+i%3)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		last := records[len(records)-1]
+		_, _ = os.Stdout.WriteString(strconv.FormatInt(last.Number, 10) + "\n")
+	}
+}
+
+func TestFileLog_KilledDuringWrite(t *testing.T) {
+	// setup
+	dir := tempDir(t)
+	cmd := exec.Command(os.Args[0], "-test.run", "^TestFileLog_CrashWriter$")
+	cmd.Env = append(os.Environ(), crashDirEnv+"="+dir)
+	out, err := cmd.StdoutPipe()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.NoError(t, cmd.Start()) {
+		t.FailNow()
+	}
+	var acknowledged int64 = -1
+	lines := bufio.NewScanner(out)
+	deadline := time.Now().Add(200 * time.Millisecond)
+	for time.Now().Before(deadline) && lines.Scan() {
+		number, err := strconv.ParseInt(lines.Text(), 10, 64)
+		if err == nil {
+			acknowledged = number
+		}
+	}
+
+	// execute
+	assert.NoError(t, cmd.Process.Kill())
+	_ = cmd.Wait()
+
+	// verify
+	log := open(t, dir)
+	records, err := log.ReadRecords(context.Background(), streams.ParseId("crash-a"), -1, math.MaxInt64, math.MaxInt32)
+	assert.NoError(t, err)
+	assert.True(t, int64(len(records))-1 >= acknowledged, "acknowledged writes survive")
+	for i, r := range records {
+		assert.Equal(t, int64(i), r.Number)
+	}
+	_, err = log.WriteRecords(context.Background(), streams.ParseId("crash-a"), data(1)...)
+	assert.NoError(t, err)
+}
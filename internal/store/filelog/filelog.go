@@ -0,0 +1,586 @@
+package filelog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
+	"github.com/google/uuid"
+)
+
+var ErrClosed = errors.New("file log closed")
+
+// Returned by New when another process uses the directory
+var ErrLocked = errors.New("file log used by another process")
+
+// Opens the log stored in dir, creating it if missing.
+// A write torn by the process dying is dropped when opening,
+// so the log holds whole writes only.
+// Only one process can use the directory at a time, others get ErrLocked.
+func New(dir string, opts ...Option) (*FileLog, error) {
+	cfg := config{
+		Sync:         SyncAlways,
+		SyncInterval: time.Second,
+		SegmentSize:  64 << 20,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.SegmentSize < 1 {
+		return nil, fmt.Errorf("segment size: %d", cfg.SegmentSize)
+	}
+	if cfg.Sync == SyncInterval && cfg.SyncInterval <= 0 {
+		return nil, fmt.Errorf("sync interval: %s", cfg.SyncInterval)
+	}
+	for _, sub := range []string{segmentsDir, snapshotsDir} {
+		err := os.MkdirAll(filepath.Join(dir, sub), 0755)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	lock, err := lockDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	log := &FileLog{
+		dir:         dir,
+		lock:        lock,
+		cfg:         cfg,
+		streams:     make(map[string][]location),
+		groups:      make(map[string][]location),
+		messages:    make(map[string]location),
+		updated:     make(map[string]map[string]time.Time),
+		locks:       make(map[string]*sync.Mutex),
+		streamLocks: make(map[string]chan struct{}),
+		stop:        make(chan struct{}),
+	}
+	err = log.recover()
+	if err != nil {
+		log.closeSegments()
+		_ = lock.Close()
+		return nil, err
+	}
+	log.positions, err = readPositions(filepath.Join(dir, positionsFile))
+	if err != nil {
+		log.closeSegments()
+		_ = lock.Close()
+		return nil, err
+	}
+	if cfg.Sync == SyncInterval {
+		log.wg.Add(1)
+		go log.syncLoop()
+	}
+	return log, nil
+}
+
+type FileLog struct {
+	dir  string
+	lock *os.File // holds the directory for this process until closed
+	cfg  config
+
+	mu       sync.RWMutex          // guards the segments and indexes
+	closed   bool                  // no longer usable
+	segments []*segment            // oldest first, the last one is appended to
+	global   int64                 // last assigned global number
+	streams  map[string][]location // per stream index, the record numbered n is at n
+	groups   map[string][]location // per group index, ordered by global number
+	messages map[string]location   // records by message id
+
+	positionsMu sync.Mutex                      // guards the positions and their file
+	positions   map[string]map[string]int64     // subscriber positions by stream id
+	updated     map[string]map[string]time.Time // when positions last moved in this process, by stream id
+	snapshotMu  sync.Mutex                      // guards the snapshot files
+
+	locksMu     sync.Mutex               // guards the lock maps
+	locks       map[string]*sync.Mutex   // subscriber position locks by stream and subscriber id
+	streamLocks map[string]chan struct{} // stream locks by stream id, held while full
+
+	stop chan struct{}  // ends the sync loop
+	wg   sync.WaitGroup // sync loop running
+}
+
+// where a record is found in the segments
+type location struct {
+	segment *segment
+	offset  int64 // start of the frame
+	item    int   // index of the record in the frame
+	global  int64 // global number of the record
+
+	contentType string // of the record, to filter without reading it
+}
+
+const segmentsDir = "segments"
+
+// Reads all segments, rebuilding the indexes.
+// Only the last segment can hold a torn write, which is truncated.
+func (log *FileLog) recover() error {
+	dir := filepath.Join(log.dir, segmentsDir)
+	paths, err := segmentPaths(dir)
+	if err != nil {
+		return err
+	}
+	for i, path := range paths {
+		seg, err := openSegment(path)
+		if err != nil {
+			return err
+		}
+		log.segments = append(log.segments, seg)
+		last := i == len(paths)-1
+		err = seg.recover(last, func(offset int64, entries []entry) {
+			log.index(seg, offset, entries)
+		})
+		if err != nil {
+			return fmt.Errorf("recover %s: %w", path, err)
+		}
+	}
+	if len(log.segments) == 0 {
+		return log.roll()
+	}
+	return nil
+}
+
+// Starts a new segment taking the following writes
+func (log *FileLog) roll() error {
+	dir := filepath.Join(log.dir, segmentsDir)
+	seg, err := openSegment(segmentPath(dir, log.global+1))
+	if err != nil {
+		return err
+	}
+	err = syncDir(dir)
+	if err != nil {
+		_ = seg.close()
+		return err
+	}
+	log.segments = append(log.segments, seg)
+	return nil
+}
+
+// must be called while holding the write lock
+func (log *FileLog) index(seg *segment, offset int64, entries []entry) {
+	for i, e := range entries {
+		loc := location{segment: seg, offset: offset, item: i, global: e.GlobalNumber, contentType: e.ContentType}
+		group := streams.ParseId(e.Stream).Group
+		log.streams[e.Stream] = append(log.streams[e.Stream], loc)
+		log.groups[group] = append(log.groups[group], loc)
+		if e.MessageId != "" {
+			log.messages[e.MessageId] = loc
+		}
+		if e.GlobalNumber > log.global {
+			log.global = e.GlobalNumber
+		}
+	}
+}
+
+func (log *FileLog) WriteRecords(ctx context.Context, id streams.Id, data ...record.Data) ([]record.Record, error) {
+	return log.WriteRecordsFrom(ctx, id, store.AnyPosition, data...)
+}
+
+func (log *FileLog) WriteRecordsFrom(ctx context.Context, id streams.Id, position int64, data ...record.Data) ([]record.Record, error) {
+	return log.WriteBatch(ctx, store.StreamWrite{Id: id, Position: position, Data: data})
+}
+
+// Writes to all the streams or none of them.
+// The records of the batch are written as a single frame.
+func (log *FileLog) WriteBatch(ctx context.Context, writes ...store.StreamWrite) ([]record.Record, error) {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if log.closed {
+		return nil, ErrClosed
+	}
+
+	// verify all writes before applying any of them
+	written := make([][]record.Record, len(writes))
+	pending := make(map[string]int64) // positions after the verified writes
+	for i, write := range writes {
+		current, found := pending[write.Id.String()]
+		if !found {
+			current = log.streamPosition(write.Id)
+		}
+		stored, err := log.deduplicate(write.Id, current, write.Data)
+		if err != nil {
+			return nil, err
+		}
+		if stored != nil {
+			written[i] = stored
+			continue
+		}
+		err = store.CheckPosition(write.Id, write.Position, current)
+		if err != nil {
+			return nil, err
+		}
+		pending[write.Id.String()] = current + int64(len(write.Data))
+	}
+
+	now := time.Now().UTC()
+	global := log.global
+	positions := make(map[string]int64)
+	var entries []entry
+	for i, write := range writes {
+		if written[i] != nil {
+			continue
+		}
+		position, found := positions[write.Id.String()]
+		if !found {
+			position = log.streamPosition(write.Id)
+		}
+		for _, d := range write.Data {
+			if d.MessageId == "" {
+				d.MessageId = uuid.New().String()
+			}
+			position = position + 1
+			global = global + 1
+			entries = append(entries, entry{
+				GlobalNumber:  global,
+				Stream:        write.Id.String(),
+				Number:        position,
+				MessageId:     d.MessageId,
+				ContentType:   d.ContentType,
+				Data:          d.Data,
+				Time:          now,
+				CorrelationId: d.CorrelationId,
+				CausationId:   d.CausationId,
+				Metadata:      d.Metadata,
+			})
+		}
+		positions[write.Id.String()] = position
+	}
+	if len(entries) > 0 {
+		err := log.append(entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var records []record.Record
+	next := 0
+	for i, write := range writes {
+		if written[i] == nil {
+			for range write.Data {
+				written[i] = append(written[i], entries[next].toRecord())
+				next = next + 1
+			}
+		}
+		records = append(records, written[i]...)
+	}
+	return records, nil
+}
+
+// Writes the entries to the last segment and indexes them.
+// must be called while holding the write lock
+func (log *FileLog) append(entries []entry) error {
+	frame, err := encodeFrame(entries)
+	if err != nil {
+		return err
+	}
+	seg := log.segments[len(log.segments)-1]
+	if seg.size > 0 && seg.size+int64(len(frame)) > log.cfg.SegmentSize {
+		// whatever the policy, a full segment is on disk before the next one starts
+		err = seg.sync()
+		if err != nil {
+			return err
+		}
+		err = log.roll()
+		if err != nil {
+			return err
+		}
+		seg = log.segments[len(log.segments)-1]
+	}
+	offset, err := seg.append(frame)
+	if err != nil {
+		return err
+	}
+	if log.cfg.Sync == SyncAlways {
+		err = seg.sync()
+		if err != nil {
+			// the write might not be on disk, so it did not happen
+			_ = seg.file.Truncate(offset)
+			seg.size = offset
+			return err
+		}
+	}
+	log.index(seg, offset, entries)
+	return nil
+}
+
+// last number written to the stream, or -1 if empty.
+// must be called while holding the lock
+func (log *FileLog) streamPosition(id streams.Id) int64 {
+	return int64(len(log.streams[id.String()])) - 1
+}
+
+// records already written with the message ids of data.
+// must be called while holding the lock
+func (log *FileLog) deduplicate(id streams.Id, current int64, data []record.Data) ([]record.Record, error) {
+	var locations []location
+	for _, messageId := range store.MessageIds(data) {
+		if loc, found := log.messages[messageId]; found {
+			locations = append(locations, loc)
+		}
+	}
+	stored, err := log.records(locations)
+	if err != nil {
+		return nil, err
+	}
+	return store.Deduplicate(id, current, data, stored)
+}
+
+func (log *FileLog) ReadRecords(ctx context.Context, id streams.Id, from, to, limit int64) ([]record.Record, error) {
+	if limit < 1 {
+		return nil, fmt.Errorf("limit cap: %d", limit)
+	}
+	log.mu.RLock()
+	defer log.mu.RUnlock()
+	if log.closed {
+		return nil, ErrClosed
+	}
+
+	var locations []location
+	if id.HasEntity() {
+		index := log.streams[id.String()]
+		for number := from + 1; number <= to && number < int64(len(index)); number++ {
+			if number < 0 {
+				continue
+			}
+			if int64(len(locations)) >= limit {
+				break
+			}
+			locations = append(locations, index[number])
+		}
+	} else {
+		index := log.groups[id.Group]
+		start := sort.Search(len(index), func(i int) bool {
+			return index[i].global > from
+		})
+		for _, loc := range index[start:] {
+			if loc.global > to || int64(len(locations)) >= limit {
+				break
+			}
+			locations = append(locations, loc)
+		}
+	}
+	return log.records(locations)
+}
+
+// Records of the content types only, and the position the read got to, past the records of other types.
+// Content types are indexed, so the records of other types are never read from the segments.
+func (log *FileLog) ReadRecordsOfTypes(ctx context.Context, id streams.Id, from, to, limit int64, contentTypes []string) ([]record.Record, int64, error) {
+	if limit < 1 {
+		return nil, from, fmt.Errorf("limit cap: %d", limit)
+	}
+	log.mu.RLock()
+	defer log.mu.RUnlock()
+	if log.closed {
+		return nil, from, ErrClosed
+	}
+
+	position := from
+	var locations []location
+	if id.HasEntity() {
+		index := log.streams[id.String()]
+		for number := from + 1; number <= to && number < int64(len(index)); number++ {
+			if number < 0 {
+				continue
+			}
+			if int64(len(locations)) >= limit {
+				break
+			}
+			position = number
+			if store.IsContentType(index[number].contentType, contentTypes) {
+				locations = append(locations, index[number])
+			}
+		}
+	} else {
+		index := log.groups[id.Group]
+		start := sort.Search(len(index), func(i int) bool {
+			return index[i].global > from
+		})
+		for _, loc := range index[start:] {
+			if loc.global > to || int64(len(locations)) >= limit {
+				break
+			}
+			position = loc.global
+			if store.IsContentType(loc.contentType, contentTypes) {
+				locations = append(locations, loc)
+			}
+		}
+	}
+	records, err := log.records(locations)
+	if err != nil {
+		return nil, from, err
+	}
+	return records, position, nil
+}
+
+// Position of the last record of the stream, -1 if it is empty
+func (log *FileLog) HeadPosition(ctx context.Context, id streams.Id) (int64, error) {
+	log.mu.RLock()
+	defer log.mu.RUnlock()
+	if log.closed {
+		return 0, ErrClosed
+	}
+	if id.HasEntity() {
+		return log.streamPosition(id), nil
+	}
+	index := log.groups[id.Group]
+	if len(index) == 0 {
+		return -1, nil
+	}
+	return index[len(index)-1].global, nil
+}
+
+// Position of the last record of the stream written before the time, -1 if there is none.
+// Records are appended in the order they are written, so their times are searched in the index.
+func (log *FileLog) PositionBefore(ctx context.Context, id streams.Id, t time.Time) (int64, error) {
+	log.mu.RLock()
+	defer log.mu.RUnlock()
+	if log.closed {
+		return 0, ErrClosed
+	}
+	index := log.groups[id.Group]
+	if id.HasEntity() {
+		index = log.streams[id.String()]
+	}
+	var err error
+	n := sort.Search(len(index), func(i int) bool {
+		records, readErr := log.records(index[i : i+1])
+		if readErr != nil {
+			err = readErr
+			return true
+		}
+		return !records[0].Time.Before(t)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return -1, nil
+	}
+	if id.HasEntity() {
+		return int64(n - 1), nil
+	}
+	return index[n-1].global, nil
+}
+
+// Number of records of the stream after the position
+func (log *FileLog) CountRecords(ctx context.Context, id streams.Id, after int64) (int64, error) {
+	log.mu.RLock()
+	defer log.mu.RUnlock()
+	if log.closed {
+		return 0, ErrClosed
+	}
+	if id.HasEntity() {
+		count := log.streamPosition(id) - after
+		if count < 0 {
+			return 0, nil
+		}
+		return count, nil
+	}
+	index := log.groups[id.Group]
+	start := sort.Search(len(index), func(i int) bool {
+		return index[i].global > after
+	})
+	return int64(len(index) - start), nil
+}
+
+// Reads the records at the locations, decoding each frame once.
+// must be called while holding the lock
+func (log *FileLog) records(locations []location) ([]record.Record, error) {
+	var records []record.Record
+	var frame []entry
+	var frameSegment *segment
+	var frameOffset int64 = -1
+	for _, loc := range locations {
+		if loc.segment != frameSegment || loc.offset != frameOffset {
+			entries, _, err := loc.segment.read(loc.offset, loc.segment.size)
+			if err != nil {
+				return nil, err
+			}
+			frame, frameSegment, frameOffset = entries, loc.segment, loc.offset
+		}
+		records = append(records, frame[loc.item].toRecord())
+	}
+	return records, nil
+}
+
+func (e entry) toRecord() record.Record {
+	id := streams.ParseId(e.Stream)
+	return record.Record{
+		MessageId:     e.MessageId,
+		Number:        e.Number,
+		Stream:        id,
+		Data:          e.Data,
+		Group:         id.Group,
+		ContentType:   e.ContentType,
+		GlobalNumber:  e.GlobalNumber,
+		Time:          e.Time,
+		CorrelationId: e.CorrelationId,
+		CausationId:   e.CausationId,
+		Metadata:      e.Metadata,
+	}
+}
+
+func (log *FileLog) syncLoop() {
+	defer log.wg.Done()
+	ticker := time.NewTicker(log.cfg.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			log.mu.RLock()
+			if !log.closed {
+				_ = log.segments[len(log.segments)-1].sync()
+			}
+			log.mu.RUnlock()
+		case <-log.stop:
+			return
+		}
+	}
+}
+
+// Flushes and closes the segment files
+func (log *FileLog) Close() error {
+	log.mu.Lock()
+	if log.closed {
+		log.mu.Unlock()
+		return nil
+	}
+	log.closed = true
+	close(log.stop)
+	seg := log.segments[len(log.segments)-1]
+	err := seg.sync()
+	log.closeSegments()
+	log.mu.Unlock()
+	log.wg.Wait()
+	if lockErr := log.lock.Close(); err == nil {
+		err = lockErr
+	}
+	return err
+}
+
+func (log *FileLog) closeSegments() {
+	for _, seg := range log.segments {
+		_ = seg.close()
+	}
+}
+
+// makes created, renamed and removed files in the directory durable
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return f.Sync()
+}
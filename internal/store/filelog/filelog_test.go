@@ -0,0 +1,423 @@
+package filelog
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/internal/store/storetest"
+	"github.com/go-po/po/streams"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "po-filelog")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() {
+		_ = os.RemoveAll(dir)
+	})
+	return dir
+}
+
+func open(t *testing.T, dir string, opts ...Option) *FileLog {
+	t.Helper()
+	log, err := New(dir, opts...)
+	if !assert.NoError(t, err, "open") {
+		t.FailNow()
+	}
+	t.Cleanup(func() {
+		_ = log.Close()
+	})
+	return log
+}
+
+func data(c int) []record.Data {
+	var result []record.Data
+	for i := 0; i < c; i++ {
+		result = append(result, record.Data{
+			ContentType: "application/json",
+			Data:        []byte("{}"),
+		})
+	}
+	return result
+}
+
+func TestFileLog_WriteRecords(t *testing.T) {
+	// setup
+	ctx := context.Background()
+	log := open(t, tempDir(t))
+
+	t.Run("numbering", func(t *testing.T) {
+		// setup
+		a := streams.ParseId("numbering-a")
+		b := streams.ParseId("numbering-b")
+		// execute
+		first, err := log.WriteRecords(ctx, a, data(2)...)
+		assert.NoError(t, err)
+		second, err := log.WriteRecords(ctx, b, data(1)...)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 2, len(first)) && assert.Equal(t, 1, len(second)) {
+			assert.Equal(t, 1, int(first[1].Number))
+			assert.Equal(t, 0, int(second[0].Number))
+			assert.Equal(t, first[1].GlobalNumber+1, second[0].GlobalNumber)
+			assert.Equal(t, "numbering", second[0].Group)
+			assert.NotEmpty(t, second[0].MessageId)
+		}
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		// setup
+		id := streams.ParseId("conflict-a")
+		_, err := log.WriteRecordsFrom(ctx, id, -1, data(5)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		_, err = log.WriteRecordsFrom(ctx, id, 3, data(2)...)
+		// verify
+		conflict := store.WriteConflictError{}
+		if assert.True(t, errors.As(err, &conflict), "write conflict error") {
+			assert.Equal(t, 4, int(conflict.Position))
+			assert.Equal(t, 4, int(conflict.Current))
+		}
+	})
+
+	t.Run("batch conflict writes nothing", func(t *testing.T) {
+		// setup
+		a, b := streams.ParseId("batch-a"), streams.ParseId("batch-b")
+		_, err := log.WriteRecords(ctx, b, data(1)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		_, err = log.WriteBatch(ctx,
+			store.StreamWrite{Id: a, Position: -1, Data: data(1)},
+			store.StreamWrite{Id: b, Position: -1, Data: data(1)},
+		)
+		// verify
+		assert.True(t, errors.Is(err, store.ErrWrongExpectedVersion), "write conflict error")
+		records, err := log.ReadRecords(ctx, a, -1, math.MaxInt64, 100)
+		assert.NoError(t, err)
+		assert.Empty(t, records)
+	})
+
+	t.Run("same messages", func(t *testing.T) {
+		// setup
+		id := streams.ParseId("dedup-a")
+		input := data(2)
+		input[0].MessageId = uuid.New().String()
+		input[1].MessageId = uuid.New().String()
+		first, err := log.WriteRecords(ctx, id, input...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		got, err := log.WriteRecords(ctx, id, input...)
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, first, got)
+		records, err := log.ReadRecords(ctx, id, -1, math.MaxInt64, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(records))
+	})
+}
+
+func TestFileLog_ReadRecords(t *testing.T) {
+	// setup
+	ctx := context.Background()
+	log := open(t, tempDir(t))
+	group := streams.ParseId("read")
+	var middle int64
+	for i := 0; i < 5; i++ {
+		r, err := log.WriteRecords(ctx, group.WithEntity("a"), data(1)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		if i == 2 {
+			middle = r[0].GlobalNumber
+		}
+		_, err = log.WriteRecords(ctx, group.WithEntity("b"), data(1)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+	}
+
+	t.Run("mid stream", func(t *testing.T) {
+		// execute
+		records, err := log.ReadRecords(ctx, group.WithEntity("a"), 1, 3, 100)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 2, len(records)) {
+			assert.Equal(t, 2, int(records[0].Number))
+			assert.Equal(t, 3, int(records[1].Number))
+		}
+	})
+
+	t.Run("mid group", func(t *testing.T) {
+		// execute
+		records, err := log.ReadRecords(ctx, group, middle, math.MaxInt64, 100)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 5, len(records)) {
+			assert.Equal(t, middle+1, records[0].GlobalNumber)
+			assert.Equal(t, 4, int(records[4].Number))
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		// execute
+		records, err := log.ReadRecords(ctx, group, 0, math.MaxInt64, 3)
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(records))
+	})
+}
+
+func TestFileLog_Position(t *testing.T) {
+	storetest.Positions(t, open(t, tempDir(t)))
+}
+
+func TestFileLog_MoveSubscriptionPosition(t *testing.T) {
+	// setup
+	ctx := context.Background()
+	log := open(t, tempDir(t))
+	group := streams.ParseId("position")
+	tx, err := log.Begin(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, log.SetSubscriptionPosition(tx, group, store.SubscriptionPosition{SubscriptionId: "sub", Position: 7}))
+	assert.NoError(t, tx.Commit())
+	tx, err = log.Begin(ctx)
+	assert.NoError(t, err)
+	// execute
+	err = log.MoveSubscriptionPosition(tx, group, store.SubscriptionPosition{SubscriptionId: "sub", Position: 3})
+	// verify
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	tx, err = log.Begin(ctx)
+	assert.NoError(t, err)
+	positions, err := log.SubscriptionPositionLock(tx, group, "sub")
+	assert.NoError(t, err)
+	assert.Equal(t, []store.SubscriptionPosition{{SubscriptionId: "sub", Position: 3}}, positions)
+	assert.NoError(t, tx.Rollback())
+	subscriptions, err := log.ListSubscriptions(ctx)
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(subscriptions)) {
+		assert.Equal(t, int64(3), subscriptions[0].Position)
+		assert.False(t, subscriptions[0].Updated.IsZero(), "updated")
+	}
+}
+
+func TestFileLog_Reopen(t *testing.T) {
+	// setup
+	ctx := context.Background()
+	dir := tempDir(t)
+	id := streams.ParseId("reopen-a")
+	log, err := New(dir, WithSegmentSize(256))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var written []record.Record
+	for i := 0; i < 10; i++ {
+		input := data(1)
+		input[0].Metadata = streams.Metadata{"i": []byte(`1`)}
+		r, err := log.WriteRecords(ctx, id, input...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		written = append(written, r...)
+	}
+	tx, err := log.Begin(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, log.SetSubscriptionPosition(tx, id, store.SubscriptionPosition{SubscriptionId: "sub", Position: 7}))
+	assert.NoError(t, tx.Commit())
+	assert.NoError(t, log.UpdateSnapshot(ctx, id, "snap", record.Snapshot{Data: []byte(`{"A":1}`), Position: 4, ContentType: "application/json"}))
+	assert.NoError(t, log.Close())
+
+	// execute
+	log = open(t, dir, WithSegmentSize(256))
+
+	// verify
+	segments, err := segmentPaths(filepath.Join(dir, segmentsDir))
+	assert.NoError(t, err)
+	assert.True(t, len(segments) > 1, "rolled segments")
+
+	records, err := log.ReadRecords(ctx, id, -1, math.MaxInt64, 100)
+	assert.NoError(t, err)
+	if assert.Equal(t, len(written), len(records)) {
+		for i := range written {
+			assert.Equal(t, written[i].GlobalNumber, records[i].GlobalNumber)
+			assert.Equal(t, written[i].MessageId, records[i].MessageId)
+			assert.True(t, written[i].Time.Equal(records[i].Time), "time")
+			assert.Equal(t, written[i].Metadata, records[i].Metadata)
+		}
+	}
+	next, err := log.WriteRecords(ctx, streams.ParseId("reopen-b"), data(1)...)
+	if assert.NoError(t, err) {
+		assert.Equal(t, written[9].GlobalNumber+1, next[0].GlobalNumber)
+	}
+
+	tx, err = log.Begin(ctx)
+	assert.NoError(t, err)
+	positions, err := log.SubscriptionPositionLock(tx, id, "sub")
+	assert.NoError(t, err)
+	assert.Equal(t, []store.SubscriptionPosition{{SubscriptionId: "sub", Position: 7}}, positions)
+	assert.NoError(t, tx.Rollback())
+
+	snapshot, err := log.ReadSnapshot(ctx, id, "snap")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, int(snapshot.Position))
+	assert.Equal(t, []byte(`{"A":1}`), snapshot.Data)
+}
+
+func TestFileLog_Lock(t *testing.T) {
+	// setup
+	dir := tempDir(t)
+	log, err := New(dir)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	t.Run("used by another", func(t *testing.T) {
+		// execute
+		_, err := New(dir)
+		// verify
+		assert.Equal(t, ErrLocked, err)
+	})
+
+	t.Run("released on close", func(t *testing.T) {
+		// setup
+		assert.NoError(t, log.Close())
+		// execute
+		reopened, err := New(dir)
+		// verify
+		if assert.NoError(t, err) {
+			assert.NoError(t, reopened.Close())
+		}
+	})
+}
+
+func TestFileLog_TornWrite(t *testing.T) {
+	tests := []struct {
+		name string
+		tail []byte
+	}{
+		{name: "partial header", tail: []byte{0x10, 0x00}},
+		{name: "partial payload", tail: []byte{0x10, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04, '[', '{'}},
+		{name: "checksum mismatch", tail: []byte{0x02, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04, '[', ']'}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// setup
+			ctx := context.Background()
+			dir := tempDir(t)
+			id := streams.ParseId("torn-a")
+			log, err := New(dir)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			_, err = log.WriteRecords(ctx, id, data(3)...)
+			assert.NoError(t, err)
+			assert.NoError(t, log.Close())
+			segments, err := segmentPaths(filepath.Join(dir, segmentsDir))
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			last := segments[len(segments)-1]
+			before, err := os.Stat(last)
+			assert.NoError(t, err)
+			f, err := os.OpenFile(last, os.O_WRONLY|os.O_APPEND, 0644)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			_, err = f.Write(test.tail)
+			assert.NoError(t, err)
+			assert.NoError(t, f.Close())
+
+			// execute
+			log = open(t, dir)
+
+			// verify
+			after, err := os.Stat(last)
+			assert.NoError(t, err)
+			assert.Equal(t, before.Size(), after.Size(), "truncated")
+			records, err := log.ReadRecords(ctx, id, -1, math.MaxInt64, 100)
+			assert.NoError(t, err)
+			assert.Equal(t, 3, len(records))
+			got, err := log.WriteRecordsFrom(ctx, id, 2, data(1)...)
+			if assert.NoError(t, err) {
+				assert.Equal(t, 3, int(got[0].Number))
+			}
+		})
+	}
+}
+
+func TestFileLog_ReadRecordsOfTypes(t *testing.T) {
+	// setup
+	log := open(t, tempDir(t))
+	ctx := context.Background()
+	group := streams.ParseId("typed")
+	id := group.WithEntity("entity")
+	var written []record.Record
+	for _, contentType := range []string{"a", "b", "a", "b", "b"} {
+		r, err := log.WriteRecords(ctx, id, record.Data{ContentType: contentType, Data: []byte("{}")})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		written = append(written, r...)
+	}
+
+	t.Run("stream", func(t *testing.T) {
+		// execute
+		records, position, err := log.ReadRecordsOfTypes(ctx, id, -1, math.MaxInt64, 100, []string{"a"})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), position)
+		if assert.Equal(t, 2, len(records)) {
+			assert.Equal(t, int64(0), records[0].Number)
+			assert.Equal(t, int64(2), records[1].Number)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		// execute
+		records, position, err := log.ReadRecordsOfTypes(ctx, id, -1, math.MaxInt64, 2, []string{"b"})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), position)
+		if assert.Equal(t, 2, len(records)) {
+			assert.Equal(t, int64(1), records[0].Number)
+			assert.Equal(t, int64(3), records[1].Number)
+		}
+	})
+
+	t.Run("group", func(t *testing.T) {
+		// execute
+		records, position, err := log.ReadRecordsOfTypes(ctx, group, written[0].GlobalNumber, math.MaxInt64, 100, []string{"a"})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, written[4].GlobalNumber, position)
+		if assert.Equal(t, 1, len(records)) {
+			assert.Equal(t, written[2].GlobalNumber, records[0].GlobalNumber)
+		}
+	})
+
+	t.Run("no records of the types", func(t *testing.T) {
+		// execute
+		records, position, err := log.ReadRecordsOfTypes(ctx, id, 0, math.MaxInt64, 100, []string{"c"})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), position)
+		assert.Empty(t, records)
+	})
+}
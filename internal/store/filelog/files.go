@@ -0,0 +1,125 @@
+package filelog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/streams"
+)
+
+const (
+	snapshotsDir  = "snapshots"
+	positionsFile = "subscriptions.json"
+)
+
+var emptySnapshot = record.Snapshot{
+	Data:        []byte("{}"),
+	Position:    -1,
+	ContentType: "application/json",
+}
+
+type snapshotFile struct {
+	Stream     string
+	SnapshotId string
+	Snapshot   record.Snapshot
+}
+
+// stream ids can hold any character, so files are named by a hash
+func (log *FileLog) snapshotPath(id streams.Id, snapshotId string) string {
+	sum := sha256.Sum256([]byte(id.String() + "\x00" + snapshotId))
+	return filepath.Join(log.dir, snapshotsDir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (log *FileLog) ReadSnapshot(ctx context.Context, id streams.Id, snapshotId string) (record.Snapshot, error) {
+	log.snapshotMu.Lock()
+	defer log.snapshotMu.Unlock()
+	b, err := ioutil.ReadFile(log.snapshotPath(id, snapshotId))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return emptySnapshot, nil
+		}
+		return record.Snapshot{}, err
+	}
+	file := snapshotFile{}
+	err = json.Unmarshal(b, &file)
+	if err != nil {
+		return record.Snapshot{}, err
+	}
+	return file.Snapshot, nil
+}
+
+func (log *FileLog) UpdateSnapshot(ctx context.Context, id streams.Id, snapshotId string, snapshot record.Snapshot) error {
+	b, err := json.Marshal(snapshotFile{
+		Stream:     id.String(),
+		SnapshotId: snapshotId,
+		Snapshot:   snapshot,
+	})
+	if err != nil {
+		return err
+	}
+	log.snapshotMu.Lock()
+	defer log.snapshotMu.Unlock()
+	return writeFile(log.snapshotPath(id, snapshotId), b, log.cfg.Sync == SyncAlways)
+}
+
+func (log *FileLog) DeleteSnapshot(ctx context.Context, id streams.Id, snapshotId string) error {
+	log.snapshotMu.Lock()
+	defer log.snapshotMu.Unlock()
+	err := os.Remove(log.snapshotPath(id, snapshotId))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func readPositions(path string) (map[string]map[string]int64, error) {
+	positions := make(map[string]map[string]int64)
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return positions, nil
+		}
+		return nil, err
+	}
+	err = json.Unmarshal(b, &positions)
+	if err != nil {
+		return nil, err
+	}
+	return positions, nil
+}
+
+// Replaces the file by renaming a fully written temporary file over it,
+// so a crash leaves either the old or the new content.
+func writeFile(path string, data []byte, sync bool) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	_, err = tmp.Write(data)
+	if err == nil && sync {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return err
+	}
+	if sync {
+		return syncDir(filepath.Dir(path))
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package filelog
+
+import "time"
+
+// When written records are flushed to the disk
+type SyncPolicy int
+
+const (
+	SyncAlways   SyncPolicy = iota // fsync before a write returns
+	SyncInterval                   // fsync in the background at the sync interval
+	SyncNever                      // leave flushing to the operating system
+)
+
+type config struct {
+	Sync         SyncPolicy
+	SyncInterval time.Duration
+	SegmentSize  int64
+}
+
+type Option func(opt *config)
+
+// Flushes writes to the disk according to the policy. Defaults to SyncAlways.
+// Only SyncAlways guarantees a returned write survives a power loss.
+func WithSyncPolicy(policy SyncPolicy) Option {
+	return func(opt *config) {
+		opt.Sync = policy
+	}
+}
+
+// How often SyncInterval flushes to the disk
+func WithSyncInterval(interval time.Duration) Option {
+	return func(opt *config) {
+		opt.SyncInterval = interval
+	}
+}
+
+// Size in bytes after which a new segment file is started
+func WithSegmentSize(size int64) Option {
+	return func(opt *config) {
+		opt.SegmentSize = size
+	}
+}
@@ -0,0 +1,179 @@
+package filelog
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-po/po/streams"
+)
+
+const (
+	segmentExt = ".log"
+	headerSize = 8 // payload length and checksum
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Record as stored in a segment.
+// All the records of a write are stored in a single frame,
+// so a torn write loses the whole write and nothing else.
+type entry struct {
+	GlobalNumber  int64            `json:"g"`
+	Stream        string           `json:"s"`
+	Number        int64            `json:"n"`
+	MessageId     string           `json:"id"`
+	ContentType   string           `json:"ct"`
+	Data          []byte           `json:"d"`
+	Time          time.Time        `json:"t"`
+	CorrelationId string           `json:"cor,omitempty"`
+	CausationId   string           `json:"cau,omitempty"`
+	Metadata      streams.Metadata `json:"m,omitempty"`
+}
+
+// An append-only log file, named after the first global number in it
+type segment struct {
+	path string
+	file *os.File
+	size int64 // bytes of whole frames in the file
+}
+
+func segmentPath(dir string, first int64) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", first, segmentExt))
+}
+
+// paths of the segments in the directory, oldest first
+func segmentPaths(dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		if _, err := strconv.ParseInt(strings.TrimSuffix(name, segmentExt), 10, 64); err != nil {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func openSegment(path string) (*segment, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &segment{path: path, file: file}, nil
+}
+
+func encodeFrame(entries []entry) ([]byte, error) {
+	payload, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+	frame := make([]byte, headerSize+len(payload))
+	binary.LittleEndian.PutUint32(frame[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(frame[4:8], crc32.Checksum(payload, crcTable))
+	copy(frame[headerSize:], payload)
+	return frame, nil
+}
+
+// Appends the frame after the last whole frame.
+// On failure the segment is cut back, so a partial frame is not left behind.
+func (seg *segment) append(frame []byte) (int64, error) {
+	offset := seg.size
+	_, err := seg.file.WriteAt(frame, offset)
+	if err != nil {
+		_ = seg.file.Truncate(offset)
+		return 0, err
+	}
+	seg.size = offset + int64(len(frame))
+	return offset, nil
+}
+
+// Reads the entries of the frame at offset, which must end before limit.
+// Returns the size of the frame.
+func (seg *segment) read(offset, limit int64) ([]entry, int64, error) {
+	if offset+headerSize > limit {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	header := make([]byte, headerSize)
+	_, err := seg.file.ReadAt(header, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	length := int64(binary.LittleEndian.Uint32(header[0:4]))
+	if offset+headerSize+length > limit {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	payload := make([]byte, length)
+	_, err = seg.file.ReadAt(payload, offset+headerSize)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, 0, err
+	}
+	if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(header[4:8]) {
+		return nil, 0, fmt.Errorf("checksum mismatch at %s:%d", seg.path, offset)
+	}
+	var entries []entry
+	err = json.Unmarshal(payload, &entries)
+	if err != nil {
+		return nil, 0, fmt.Errorf("frame at %s:%d: %w", seg.path, offset, err)
+	}
+	return entries, headerSize + length, nil
+}
+
+// Reads every whole frame, calling fn for each of them.
+// A frame that can not be read ends the segment, and the segment is
+// truncated there when repair is set, dropping the torn write.
+func (seg *segment) recover(repair bool, fn func(offset int64, entries []entry)) error {
+	info, err := seg.file.Stat()
+	if err != nil {
+		return err
+	}
+	var offset int64
+	for offset < info.Size() {
+		entries, size, err := seg.read(offset, info.Size())
+		if err != nil {
+			if !repair {
+				return fmt.Errorf("corrupt segment: %w", err)
+			}
+			break
+		}
+		fn(offset, entries)
+		offset = offset + size
+	}
+	seg.size = offset
+	if offset < info.Size() {
+		err = seg.file.Truncate(offset)
+		if err != nil {
+			return fmt.Errorf("truncate torn write: %w", err)
+		}
+		return seg.file.Sync()
+	}
+	return nil
+}
+
+func (seg *segment) sync() error {
+	return seg.file.Sync()
+}
+
+func (seg *segment) close() error {
+	return seg.file.Close()
+}
@@ -0,0 +1,8 @@
+package filelog_test
+
+import (
+	"github.com/go-po/po"
+	"github.com/go-po/po/internal/store/filelog"
+)
+
+var _ po.Store = &filelog.FileLog{}
@@ -0,0 +1,270 @@
+package filelog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
+)
+
+func (log *FileLog) Begin(ctx context.Context) (store.Tx, error) {
+	return &fileTx{
+		log:       log,
+		positions: make(map[string]map[string]int64),
+		moved:     make(map[string]map[string]bool),
+	}, nil
+}
+
+func (log *FileLog) SubscriptionPositionLock(tx store.Tx, id streams.Id, subscriptionIds ...string) ([]store.SubscriptionPosition, error) {
+	fTx, ok := tx.(*fileTx)
+	if !ok {
+		return nil, fmt.Errorf("unknown tx type: %T", tx)
+	}
+	if len(subscriptionIds) == 0 {
+		return nil, nil
+	}
+
+	for _, subscriptionId := range store.LockOrder(subscriptionIds) {
+		fTx.lock(log.positionLock(id, subscriptionId))
+	}
+
+	log.positionsMu.Lock()
+	defer log.positionsMu.Unlock()
+	var result []store.SubscriptionPosition
+	for _, subscriptionId := range subscriptionIds {
+		position, found := log.positions[id.String()][subscriptionId]
+		if !found {
+			continue
+		}
+		result = append(result, store.SubscriptionPosition{
+			SubscriptionId: subscriptionId,
+			Position:       position,
+		})
+	}
+	return result, nil
+}
+
+func (log *FileLog) SetSubscriptionPosition(tx store.Tx, id streams.Id, position store.SubscriptionPosition) error {
+	fTx, ok := tx.(*fileTx)
+	if !ok {
+		return fmt.Errorf("unknown tx type: %T", tx)
+	}
+	fTx.setPosition(id, position)
+	return nil
+}
+
+// Sets the position of the subscription when the transaction commits, even if lower than its current position
+func (log *FileLog) MoveSubscriptionPosition(tx store.Tx, id streams.Id, position store.SubscriptionPosition) error {
+	fTx, ok := tx.(*fileTx)
+	if !ok {
+		return fmt.Errorf("unknown tx type: %T", tx)
+	}
+	fTx.movePosition(id, position)
+	return nil
+}
+
+func (log *FileLog) positionLock(id streams.Id, subscriptionId string) *sync.Mutex {
+	log.locksMu.Lock()
+	defer log.locksMu.Unlock()
+	key := id.String() + "\x00" + subscriptionId
+	lock, found := log.locks[key]
+	if !found {
+		lock = &sync.Mutex{}
+		log.locks[key] = lock
+	}
+	return lock
+}
+
+// Writes the positions, only moving them forward unless moved
+func (log *FileLog) commitPositions(pending map[string]map[string]int64, moved map[string]map[string]bool) error {
+	log.positionsMu.Lock()
+	defer log.positionsMu.Unlock()
+
+	positions := make(map[string]map[string]int64, len(log.positions))
+	for stream, subscribers := range log.positions {
+		positions[stream] = make(map[string]int64, len(subscribers))
+		for subscriptionId, position := range subscribers {
+			positions[stream][subscriptionId] = position
+		}
+	}
+	var changed []store.Subscription
+	for stream, subscribers := range pending {
+		if _, found := positions[stream]; !found {
+			positions[stream] = make(map[string]int64)
+		}
+		for subscriptionId, position := range subscribers {
+			current, found := positions[stream][subscriptionId]
+			if !found || current < position || (moved[stream][subscriptionId] && current != position) {
+				positions[stream][subscriptionId] = position
+				changed = append(changed, store.Subscription{SubscriptionId: subscriptionId, Stream: streams.ParseId(stream)})
+			}
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(positions)
+	if err != nil {
+		return err
+	}
+	err = writeFile(filepath.Join(log.dir, positionsFile), b, log.cfg.Sync == SyncAlways)
+	if err != nil {
+		return err
+	}
+	log.positions = positions
+	now := time.Now()
+	for _, subscription := range changed {
+		stream := subscription.Stream.String()
+		if _, found := log.updated[stream]; !found {
+			log.updated[stream] = make(map[string]time.Time)
+		}
+		log.updated[stream][subscription.SubscriptionId] = now
+	}
+	return nil
+}
+
+// Stored positions of every subscription, ordered by stream and subscription id.
+// Only positions moved since the log was opened know when they were updated.
+func (log *FileLog) ListSubscriptions(ctx context.Context) ([]store.Subscription, error) {
+	log.positionsMu.Lock()
+	defer log.positionsMu.Unlock()
+	var result []store.Subscription
+	for stream, subscribers := range log.positions {
+		for subscriptionId, position := range subscribers {
+			result = append(result, store.Subscription{
+				Stream:         streams.ParseId(stream),
+				SubscriptionId: subscriptionId,
+				Position:       position,
+				Updated:        log.updated[stream][subscriptionId],
+			})
+		}
+	}
+	store.SortSubscriptions(result)
+	return result, nil
+}
+
+// Locks the stream against other lockers until the returned transaction ends.
+// A zero timeout waits for as long as the context allows.
+func (log *FileLog) LockStream(ctx context.Context, id streams.Id, timeout time.Duration) (store.Tx, error) {
+	log.locksMu.Lock()
+	lock, found := log.streamLocks[id.String()]
+	if !found {
+		lock = make(chan struct{}, 1)
+		log.streamLocks[id.String()] = lock
+	}
+	log.locksMu.Unlock()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case lock <- struct{}{}:
+		return &streamLockTx{lock: lock}, nil
+	case <-expired:
+		return nil, store.LockTimeoutError{StreamId: id}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type streamLockTx struct {
+	lock chan struct{}
+	once sync.Once
+}
+
+func (tx *streamLockTx) Commit() error {
+	tx.once.Do(func() {
+		<-tx.lock
+	})
+	return nil
+}
+
+func (tx *streamLockTx) Rollback() error {
+	return tx.Commit()
+}
+
+type fileTx struct {
+	log *FileLog
+
+	mu        sync.Mutex                  // protects the fields below
+	done      bool                        // committed or rolled back
+	positions map[string]map[string]int64 // pending subscriber positions by stream id
+	moved     map[string]map[string]bool  // pending positions set even if lower, by stream id
+	held      []*sync.Mutex               // subscriber position locks taken
+}
+
+func (tx *fileTx) lock(lock *sync.Mutex) {
+	tx.mu.Lock()
+	for _, held := range tx.held {
+		if held == lock {
+			tx.mu.Unlock()
+			return
+		}
+	}
+	tx.mu.Unlock()
+
+	lock.Lock()
+
+	tx.mu.Lock()
+	tx.held = append(tx.held, lock)
+	tx.mu.Unlock()
+}
+
+func (tx *fileTx) setPosition(id streams.Id, position store.SubscriptionPosition) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if _, found := tx.positions[id.String()]; !found {
+		tx.positions[id.String()] = make(map[string]int64)
+	}
+	tx.positions[id.String()][position.SubscriptionId] = position.Position
+	delete(tx.moved[id.String()], position.SubscriptionId)
+}
+
+func (tx *fileTx) movePosition(id streams.Id, position store.SubscriptionPosition) {
+	tx.setPosition(id, position)
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if _, found := tx.moved[id.String()]; !found {
+		tx.moved[id.String()] = make(map[string]bool)
+	}
+	tx.moved[id.String()][position.SubscriptionId] = true
+}
+
+func (tx *fileTx) Commit() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.done {
+		return fmt.Errorf("transaction already done")
+	}
+	defer tx.release()
+	return tx.log.commitPositions(tx.positions, tx.moved)
+}
+
+func (tx *fileTx) Rollback() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.done {
+		return nil
+	}
+	tx.release() // discard the pending positions
+	return nil
+}
+
+// must be called while holding the tx lock
+func (tx *fileTx) release() {
+	tx.done = true
+	for _, lock := range tx.held {
+		lock.Unlock()
+	}
+	tx.held = nil
+}
+
+var _ store.Tx = &fileTx{}
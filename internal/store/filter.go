@@ -0,0 +1,34 @@
+package store
+
+import (
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/streams"
+)
+
+// Position of the record when reading the stream: its number in entity streams, its global number in groups
+func RecordPosition(id streams.Id, r record.Record) int64 {
+	if id.HasEntity() {
+		return r.Number
+	}
+	return r.GlobalNumber
+}
+
+// Reports true if the content type is one of the content types
+func IsContentType(contentType string, contentTypes []string) bool {
+	for _, candidate := range contentTypes {
+		if candidate == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// Position a read of some content types got to, given the records read and the end of the range read.
+// Reaching the limit, the read got to the last record, as the records after it are left for the next read.
+// Otherwise it covered the whole range, which must not go beyond the head of the stream.
+func FilteredPosition(id streams.Id, records []record.Record, limit, to int64) int64 {
+	if len(records) > 0 && int64(len(records)) >= limit {
+		return RecordPosition(id, records[len(records)-1])
+	}
+	return to
+}
@@ -9,195 +9,510 @@ import (
 	"github.com/go-po/po/internal/record"
 	"github.com/go-po/po/internal/store"
 	"github.com/go-po/po/streams"
+	"github.com/google/uuid"
 )
 
 func New() *InMemory {
 	return &InMemory{
 		mu:          sync.RWMutex{},
+		global:      0,
 		data:        make(map[string][]record.Record),
-		ptr:         make(map[string]int64),
-		snapshots:   make(map[streams.Id]map[string]record.Snapshot),
 		entityIndex: make(map[string]int64),
-		groupIndex:  make(map[string]int64),
+		idIndex:     make(map[string]record.Record),
+		positions:   make(map[string]map[string]int64),
+		updated:     make(map[string]map[string]time.Time),
+		locks:       make(map[string]*sync.Mutex),
+		streamLocks: make(map[string]chan struct{}),
+		snapshots:   make(map[streams.Id]map[string]record.Snapshot),
+		leases:      make(map[string]store.Lease),
+		claimed:     make(map[int64]bool),
 	}
 }
 
 type InMemory struct {
-	mu          sync.RWMutex               // guards the data
-	data        map[string][]record.Record // records by stream group id
-	ptr         map[string]int64           // subscriber positions
+	mu          sync.RWMutex                    // guards the data
+	global      int64                           // last assigned global number
+	data        map[string][]record.Record      // records by stream group id
+	entityIndex map[string]int64                // last number by stream id
+	idIndex     map[string]record.Record        // records by message id
+	positions   map[string]map[string]int64     // subscriber positions by stream id
+	updated     map[string]map[string]time.Time // when the subscriber positions last moved, by stream id
+	locks       map[string]*sync.Mutex          // subscriber position locks by stream and subscriber id
+	streamLocks map[string]chan struct{}        // stream locks by stream id, held while full
 	snapshots   map[streams.Id]map[string]record.Snapshot
-	entityIndex map[string]int64
-	groupIndex  map[string]int64
+	leases      map[string]store.Lease // by name
+
+	outbox        bool                 // writes notifications to the outbox
+	notifications []store.Notification // pending in the outbox, by id
+	notified      int64                // last assigned notification id
+	claimed       map[int64]bool       // notification ids being published by a relay
 }
 
 func (mem *InMemory) WriteRecords(ctx context.Context, id streams.Id, data ...record.Data) ([]record.Record, error) {
-	panic("implement me")
+	return mem.WriteRecordsFrom(ctx, id, store.AnyPosition, data...)
 }
 
 func (mem *InMemory) WriteRecordsFrom(ctx context.Context, id streams.Id, position int64, data ...record.Data) ([]record.Record, error) {
-	panic("implement me")
+	return mem.WriteBatch(ctx, store.StreamWrite{Id: id, Position: position, Data: data})
 }
 
-func (mem *InMemory) SubscriptionPositionLock(tx store.Tx, id streams.Id, subscriptionIds ...string) ([]store.SubscriptionPosition, error) {
-	panic("implement me")
+// Writes to all the streams or none of them
+func (mem *InMemory) WriteBatch(ctx context.Context, writes ...store.StreamWrite) ([]record.Record, error) {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+
+	// verify all writes before applying any of them
+	written := make([][]record.Record, len(writes))
+	pending := make(map[string]int64) // positions after the verified writes
+	for i, write := range writes {
+		current, found := pending[write.Id.String()]
+		if !found {
+			current = mem.streamPosition(write.Id)
+		}
+		stored, err := mem.deduplicate(write.Id, current, write.Data)
+		if err != nil {
+			return nil, err
+		}
+		if stored != nil {
+			written[i] = stored
+			continue
+		}
+		err = store.CheckPosition(write.Id, write.Position, current)
+		if err != nil {
+			return nil, err
+		}
+		pending[write.Id.String()] = current + int64(len(write.Data))
+	}
+
+	var records []record.Record
+	for i, write := range writes {
+		if written[i] == nil {
+			written[i] = mem.writeRecords(write.Id, mem.streamPosition(write.Id), write.Data...)
+			if mem.outbox {
+				mem.storeNotifications(written[i])
+			}
+		}
+		records = append(records, written[i]...)
+	}
+	return records, nil
 }
 
-func (mem *InMemory) ReadRecords(ctx context.Context, id streams.Id, from, to, limit int64) ([]record.Record, error) {
-	panic("implement me")
+// last number written to the stream, or -1 if empty.
+// must be called while holding the lock
+func (mem *InMemory) streamPosition(id streams.Id) int64 {
+	position, found := mem.entityIndex[id.String()]
+	if !found {
+		return -1
+	}
+	return position
 }
 
-func (mem *InMemory) SetSubscriptionPosition(tx store.Tx, id streams.Id, position store.SubscriptionPosition) error {
-	panic("implement me")
+// records already written with the message ids of data.
+// must be called while holding the lock
+func (mem *InMemory) deduplicate(id streams.Id, current int64, data []record.Data) ([]record.Record, error) {
+	var stored []record.Record
+	for _, messageId := range store.MessageIds(data) {
+		if r, found := mem.idIndex[messageId]; found {
+			stored = append(stored, r)
+		}
+	}
+	return store.Deduplicate(id, current, data, stored)
 }
 
-var emptySnapshot = record.Snapshot{
-	Data:        []byte("{}"),
-	Position:    0,
-	ContentType: "application/json",
+// must be called while holding the write lock
+func (mem *InMemory) writeRecords(id streams.Id, position int64, data ...record.Data) []record.Record {
+	if len(data) == 0 {
+		return nil
+	}
+	now := time.Now()
+	var records []record.Record
+	for _, d := range data {
+		if d.MessageId == "" {
+			d.MessageId = uuid.New().String()
+		}
+		position = position + 1
+		mem.global = mem.global + 1
+		records = append(records, record.Record{
+			MessageId:     d.MessageId,
+			Number:        position,
+			Stream:        id,
+			Data:          d.Data,
+			Group:         id.Group,
+			ContentType:   d.ContentType,
+			GlobalNumber:  mem.global,
+			Time:          now,
+			CorrelationId: d.CorrelationId,
+			CausationId:   d.CausationId,
+			Metadata:      d.Metadata,
+		})
+	}
+	mem.data[id.Group] = append(mem.data[id.Group], records...)
+	for _, r := range records {
+		if r.MessageId != "" {
+			mem.idIndex[r.MessageId] = r
+		}
+	}
+	mem.entityIndex[id.String()] = position
+	return records
 }
 
-func (mem *InMemory) ReadSnapshot(ctx context.Context, id streams.Id, snapshotId string) (record.Snapshot, error) {
-	streamSnaps, found := mem.snapshots[id]
-	if !found {
-		return emptySnapshot, nil
+func (mem *InMemory) ReadRecords(ctx context.Context, id streams.Id, from, to, limit int64) ([]record.Record, error) {
+	if limit < 1 {
+		return nil, fmt.Errorf("limit cap: %d", limit)
 	}
+	mem.mu.RLock()
+	defer mem.mu.RUnlock()
 
-	snapshot, found := streamSnaps[snapshotId]
-	if !found {
-		fmt.Printf("didn't find it 2\n")
-		return emptySnapshot, nil
+	var records []record.Record
+	for _, r := range mem.data[id.Group] {
+		if int64(len(records)) >= limit {
+			break
+		}
+		if id.HasEntity() {
+			if r.Stream.String() != id.String() || r.Number <= from || r.Number > to {
+				continue
+			}
+		} else {
+			if r.GlobalNumber <= from || r.GlobalNumber > to {
+				continue
+			}
+		}
+		records = append(records, r)
 	}
-	return snapshot, nil
+	return records, nil
+}
 
+// Records of the content types only, and the position the read got to, past the records of other types
+func (mem *InMemory) ReadRecordsOfTypes(ctx context.Context, id streams.Id, from, to, limit int64, contentTypes []string) ([]record.Record, int64, error) {
+	if limit < 1 {
+		return nil, from, fmt.Errorf("limit cap: %d", limit)
+	}
+	mem.mu.RLock()
+	defer mem.mu.RUnlock()
+
+	position := from
+	var records []record.Record
+	for _, r := range mem.data[id.Group] {
+		if int64(len(records)) >= limit {
+			break
+		}
+		if id.HasEntity() && r.Stream.String() != id.String() {
+			continue
+		}
+		at := store.RecordPosition(id, r)
+		if at <= from || at > to {
+			continue
+		}
+		position = at
+		if store.IsContentType(r.ContentType, contentTypes) {
+			records = append(records, r)
+		}
+	}
+	return records, position, nil
 }
 
-func (mem *InMemory) UpdateSnapshot(ctx context.Context, id streams.Id, snapshotId string, snapshot record.Snapshot) error {
-	if _, found := mem.snapshots[id]; !found {
-		mem.snapshots[id] = make(map[string]record.Snapshot)
+func (mem *InMemory) Begin(ctx context.Context) (store.Tx, error) {
+	return &inMemoryTx{
+		store:     mem,
+		positions: make(map[string]map[string]int64),
+		moved:     make(map[string]map[string]bool),
+	}, nil
+}
+
+func (mem *InMemory) SubscriptionPositionLock(tx store.Tx, id streams.Id, subscriptionIds ...string) ([]store.SubscriptionPosition, error) {
+	inTx, ok := tx.(*inMemoryTx)
+	if !ok {
+		return nil, fmt.Errorf("unknown tx type: %T", tx)
 	}
-	mem.snapshots[id][snapshotId] = snapshot
-	return nil
+	if len(subscriptionIds) == 0 {
+		return nil, nil
+	}
+
+	for _, subscriptionId := range store.LockOrder(subscriptionIds) {
+		inTx.lock(mem.positionLock(id, subscriptionId))
+	}
+
+	mem.mu.RLock()
+	defer mem.mu.RUnlock()
+	var result []store.SubscriptionPosition
+	for _, subscriptionId := range subscriptionIds {
+		position, found := mem.positions[id.String()][subscriptionId]
+		if !found {
+			continue
+		}
+		result = append(result, store.SubscriptionPosition{
+			SubscriptionId: subscriptionId,
+			Position:       position,
+		})
+	}
+	return result, nil
 }
 
-func (mem *InMemory) GetStreamPosition(ctx context.Context, id streams.Id) (int64, error) {
+func (mem *InMemory) positionLock(id streams.Id, subscriptionId string) *sync.Mutex {
 	mem.mu.Lock()
 	defer mem.mu.Unlock()
-	stream, found := mem.data[id.Group]
+	key := id.String() + "\x00" + subscriptionId
+	lock, found := mem.locks[key]
 	if !found {
-		return 0, nil
+		lock = &sync.Mutex{}
+		mem.locks[key] = lock
+	}
+	return lock
+}
+
+func (mem *InMemory) SetSubscriptionPosition(tx store.Tx, id streams.Id, position store.SubscriptionPosition) error {
+	inTx, ok := tx.(*inMemoryTx)
+	if !ok {
+		return fmt.Errorf("unknown tx type: %T", tx)
+	}
+	inTx.setPosition(id, position)
+	return nil
+}
+
+// Sets the position of the subscription when the transaction commits, even if lower than its current position
+func (mem *InMemory) MoveSubscriptionPosition(tx store.Tx, id streams.Id, position store.SubscriptionPosition) error {
+	inTx, ok := tx.(*inMemoryTx)
+	if !ok {
+		return fmt.Errorf("unknown tx type: %T", tx)
+	}
+	inTx.movePosition(id, position)
+	return nil
+}
+
+// Position of the last record of the stream, -1 if it is empty
+func (mem *InMemory) HeadPosition(ctx context.Context, id streams.Id) (int64, error) {
+	mem.mu.RLock()
+	defer mem.mu.RUnlock()
+	if id.HasEntity() {
+		return mem.streamPosition(id), nil
+	}
+	records := mem.data[id.Group]
+	if len(records) == 0 {
+		return -1, nil
 	}
-	var position int64 = 0
-	for _, r := range stream {
+	return records[len(records)-1].GlobalNumber, nil
+}
+
+// Position of the last record of the stream written before the time, -1 if there is none
+func (mem *InMemory) PositionBefore(ctx context.Context, id streams.Id, t time.Time) (int64, error) {
+	mem.mu.RLock()
+	defer mem.mu.RUnlock()
+	position := int64(-1)
+	for _, r := range mem.data[id.Group] {
+		if !r.Time.Before(t) {
+			break
+		}
+		if !id.HasEntity() {
+			position = r.GlobalNumber
+			continue
+		}
 		if r.Stream.String() == id.String() {
-			position = position + 1
+			position = r.Number
 		}
 	}
 	return position, nil
 }
 
-func (mem *InMemory) AssignGroup(ctx context.Context, id streams.Id, number int64) (record.Record, error) {
-	mem.mu.Lock()
-	defer mem.mu.Unlock()
-	groupData, found := mem.data[id.Group]
-	if !found {
-		return record.Record{}, fmt.Errorf("unknown stream group: %s", id.Group)
-	}
-	for i, item := range groupData {
-		if item.Stream.String() == id.String() && item.Number == number {
-			if item.GlobalNumber != 0 {
-				return record.Record{}, fmt.Errorf("already assigned")
-			}
-			groupNumber, found := mem.groupIndex[id.Group]
-			if !found {
-				groupNumber = 1
+// Number of records of the stream after the position
+func (mem *InMemory) CountRecords(ctx context.Context, id streams.Id, after int64) (int64, error) {
+	mem.mu.RLock()
+	defer mem.mu.RUnlock()
+	var count int64
+	for _, r := range mem.data[id.Group] {
+		if id.HasEntity() {
+			if r.Stream.String() == id.String() && r.Number > after {
+				count = count + 1
 			}
-			mem.groupIndex[id.Group] = groupNumber + 1
-			r := groupData[i]
-			r.GlobalNumber = groupNumber
-			groupData[i] = r
-			return r, nil
+			continue
+		}
+		if r.GlobalNumber > after {
+			count = count + 1
 		}
 	}
-	return record.Record{}, fmt.Errorf("number %d not found in stream %s", number, id.Group)
+	return count, nil
 }
 
-func (mem *InMemory) GetSubscriberPosition(tx store.Tx, subscriberId string, stream streams.Id) (int64, error) {
+// Stored positions of every subscription, ordered by stream and subscription id
+func (mem *InMemory) ListSubscriptions(ctx context.Context) ([]store.Subscription, error) {
 	mem.mu.RLock()
 	defer mem.mu.RUnlock()
-	pos, found := mem.ptr[subscriberId]
-	if found {
-		return pos, nil
+	var result []store.Subscription
+	for stream, positions := range mem.positions {
+		for subscriptionId, position := range positions {
+			result = append(result, store.Subscription{
+				Stream:         streams.ParseId(stream),
+				SubscriptionId: subscriptionId,
+				Position:       position,
+				Updated:        mem.updated[stream][subscriptionId],
+			})
+		}
 	}
-	return 0, nil
+	store.SortSubscriptions(result)
+	return result, nil
 }
 
-func (mem *InMemory) SetSubscriberPosition(tx store.Tx, subscriberId string, stream streams.Id, position int64) error {
+// Locks the stream against other lockers until the returned transaction ends.
+// A zero timeout waits for as long as the context allows.
+func (mem *InMemory) LockStream(ctx context.Context, id streams.Id, timeout time.Duration) (store.Tx, error) {
 	mem.mu.Lock()
-	defer mem.mu.Unlock()
-	mem.ptr[subscriberId] = position
+	lock, found := mem.streamLocks[id.String()]
+	if !found {
+		lock = make(chan struct{}, 1)
+		mem.streamLocks[id.String()] = lock
+	}
+	mem.mu.Unlock()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case lock <- struct{}{}:
+		return &streamLockTx{lock: lock}, nil
+	case <-expired:
+		return nil, store.LockTimeoutError{StreamId: id}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type streamLockTx struct {
+	lock chan struct{}
+	once sync.Once
+}
+
+func (tx *streamLockTx) Commit() error {
+	tx.once.Do(func() {
+		<-tx.lock
+	})
 	return nil
 }
 
-func (mem *InMemory) Begin(ctx context.Context) (store.Tx, error) {
-	return &inMemoryTx{
-		records: make([]record.Record, 0),
-		store:   mem,
-	}, nil
+func (tx *streamLockTx) Rollback() error {
+	return tx.Commit()
 }
 
-func (mem *InMemory) StoreRecord(tx store.Tx, id streams.Id, number int64, msgType string, data []byte) (record.Record, error) {
-	inTx, ok := tx.(*inMemoryTx)
-	if !ok {
-		return record.Record{}, fmt.Errorf("unknown tx type: %T", tx)
-	}
+var emptySnapshot = record.Snapshot{
+	Data:        []byte("{}"),
+	Position:    -1,
+	ContentType: "application/json",
+}
 
+func (mem *InMemory) ReadSnapshot(ctx context.Context, id streams.Id, snapshotId string) (record.Snapshot, error) {
 	mem.mu.RLock()
 	defer mem.mu.RUnlock()
-
-	current, found := mem.entityIndex[id.String()]
+	snapshot, found := mem.snapshots[id][snapshotId]
 	if !found {
-		current = 0
-	}
-	var next = current + int64(len(inTx.records)) + 1
-	if next != number {
-		return record.Record{}, fmt.Errorf("out of order: %d != %d", next, number)
+		return emptySnapshot, nil
 	}
+	return snapshot, nil
+}
 
-	r := record.Record{
-		Number:       number,
-		Stream:       id,
-		Data:         data,
-		Group:        id.Group,
-		ContentType:  msgType,
-		GlobalNumber: 0,
-		Time:         time.Now(),
+func (mem *InMemory) UpdateSnapshot(ctx context.Context, id streams.Id, snapshotId string, snapshot record.Snapshot) error {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	if _, found := mem.snapshots[id]; !found {
+		mem.snapshots[id] = make(map[string]record.Snapshot)
 	}
-	inTx.records = append(inTx.records, r)
-	return r, nil
+	mem.snapshots[id][snapshotId] = snapshot
+	return nil
 }
 
 type inMemoryTx struct {
-	records []record.Record
-	store   *InMemory
+	store *InMemory
+
+	mu        sync.Mutex                  // protects the fields below
+	done      bool                        // committed or rolled back
+	positions map[string]map[string]int64 // pending subscriber positions by stream id
+	moved     map[string]map[string]bool  // pending positions set even if lower, by stream id
+	held      []*sync.Mutex               // subscriber position locks taken
 }
 
-func (tx inMemoryTx) Commit() error {
+func (tx *inMemoryTx) lock(lock *sync.Mutex) {
+	tx.mu.Lock()
+	for _, held := range tx.held {
+		if held == lock {
+			tx.mu.Unlock()
+			return
+		}
+	}
+	tx.mu.Unlock()
+
+	lock.Lock()
+
+	tx.mu.Lock()
+	tx.held = append(tx.held, lock)
+	tx.mu.Unlock()
+}
+
+func (tx *inMemoryTx) setPosition(id streams.Id, position store.SubscriptionPosition) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if _, found := tx.positions[id.String()]; !found {
+		tx.positions[id.String()] = make(map[string]int64)
+	}
+	tx.positions[id.String()][position.SubscriptionId] = position.Position
+	delete(tx.moved[id.String()], position.SubscriptionId)
+}
+
+func (tx *inMemoryTx) movePosition(id streams.Id, position store.SubscriptionPosition) {
+	tx.setPosition(id, position)
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if _, found := tx.moved[id.String()]; !found {
+		tx.moved[id.String()] = make(map[string]bool)
+	}
+	tx.moved[id.String()][position.SubscriptionId] = true
+}
+
+func (tx *inMemoryTx) Commit() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.done {
+		return fmt.Errorf("transaction already done")
+	}
+
 	tx.store.mu.Lock()
-	defer tx.store.mu.Unlock()
-	for _, r := range tx.records {
-		_, hasStream := tx.store.data[r.Stream.Group]
-		if !hasStream {
-			tx.store.data[r.Stream.Group] = make([]record.Record, 0)
+	now := time.Now()
+	for stream, positions := range tx.positions {
+		if _, found := tx.store.positions[stream]; !found {
+			tx.store.positions[stream] = make(map[string]int64)
+			tx.store.updated[stream] = make(map[string]time.Time)
 		}
-		tx.store.data[r.Stream.Group] = append(tx.store.data[r.Stream.Group], r)
-		tx.store.entityIndex[r.Stream.String()] = r.Number
+		for subscriptionId, position := range positions {
+			current, found := tx.store.positions[stream][subscriptionId]
+			if !found || current < position || tx.moved[stream][subscriptionId] {
+				// positions only move forward, unless moved
+				tx.store.positions[stream][subscriptionId] = position
+				tx.store.updated[stream][subscriptionId] = now
+			}
+		}
+	}
+	tx.store.mu.Unlock()
+
+	tx.release()
+	return nil
+}
+
+func (tx *inMemoryTx) Rollback() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.done {
+		return nil
 	}
+	tx.release() // discard the pending positions
 	return nil
 }
 
-func (tx inMemoryTx) Rollback() error {
-	return nil // discard the records in the transaction
+// must be called while holding the tx lock
+func (tx *inMemoryTx) release() {
+	tx.done = true
+	for _, lock := range tx.held {
+		lock.Unlock()
+	}
+	tx.held = nil
 }
 
 var _ store.Tx = &inMemoryTx{}
@@ -0,0 +1,502 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/internal/store/storetest"
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+func data(c int) []record.Data {
+	var result []record.Data
+	for i := 0; i < c; i++ {
+		result = append(result, record.Data{
+			ContentType: "application/json",
+			Data:        []byte("{}"),
+		})
+	}
+	return result
+}
+
+func TestInMemory_WriteRecords(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("single record", func(t *testing.T) {
+		// setup
+		mem := New()
+		id := streams.ParseId("single-1")
+		// execute
+		got, err := mem.WriteRecords(ctx, id, data(1)...)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(got)) {
+			assert.Equal(t, id.String(), got[0].Stream.String())
+			assert.Equal(t, 0, int(got[0].Number))
+			assert.Equal(t, 1, int(got[0].GlobalNumber))
+		}
+	})
+
+	t.Run("global numbers across groups", func(t *testing.T) {
+		// setup
+		mem := New()
+		_, err := mem.WriteRecords(ctx, streams.ParseId("a-1"), data(2)...)
+		assert.NoError(t, err)
+		// execute
+		got, err := mem.WriteRecords(ctx, streams.ParseId("b-1"), data(2)...)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 2, len(got)) {
+			assert.Equal(t, 0, int(got[0].Number))
+			assert.Equal(t, 3, int(got[0].GlobalNumber))
+			assert.Equal(t, 4, int(got[1].GlobalNumber))
+		}
+	})
+
+	t.Run("from position", func(t *testing.T) {
+		// setup
+		mem := New()
+		id := streams.ParseId("from-1")
+		_, err := mem.WriteRecords(ctx, id, data(3)...)
+		assert.NoError(t, err)
+		// execute
+		got, err := mem.WriteRecordsFrom(ctx, id, 2, data(1)...)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(got)) {
+			assert.Equal(t, 3, int(got[0].Number))
+		}
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		// setup
+		mem := New()
+		id := streams.ParseId("conflict-1")
+		_, err := mem.WriteRecords(ctx, id, data(5)...)
+		assert.NoError(t, err)
+		// execute
+		_, err = mem.WriteRecordsFrom(ctx, id, 3, data(2)...)
+		// verify
+		conflict := store.WriteConflictError{}
+		if assert.True(t, errors.As(err, &conflict), "write conflict error") {
+			assert.Equal(t, id.String(), conflict.StreamId.String())
+			assert.Equal(t, 4, int(conflict.Position))
+			assert.Equal(t, 4, int(conflict.Current))
+		}
+	})
+}
+
+func TestInMemory_WriteBatch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("all streams", func(t *testing.T) {
+		// setup
+		mem := New()
+		a, b := streams.ParseId("batch-a"), streams.ParseId("batch-b")
+		// execute
+		got, err := mem.WriteBatch(ctx,
+			store.StreamWrite{Id: a, Position: -1, Data: data(1)},
+			store.StreamWrite{Id: b, Position: store.AnyPosition, Data: data(2)},
+			store.StreamWrite{Id: a, Position: 0, Data: data(1)},
+		)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 4, len(got)) {
+			assert.Equal(t, 0, int(got[0].Number))
+			assert.Equal(t, 1, int(got[2].Number))
+			assert.Equal(t, b.String(), got[2].Stream.String())
+			assert.Equal(t, 1, int(got[3].Number))
+			assert.Equal(t, a.String(), got[3].Stream.String())
+		}
+	})
+
+	t.Run("conflict writes nothing", func(t *testing.T) {
+		// setup
+		mem := New()
+		a, b := streams.ParseId("batch-a"), streams.ParseId("batch-b")
+		_, err := mem.WriteRecords(ctx, b, data(1)...)
+		assert.NoError(t, err)
+		// execute
+		_, err = mem.WriteBatch(ctx,
+			store.StreamWrite{Id: a, Position: -1, Data: data(1)},
+			store.StreamWrite{Id: b, Position: -1, Data: data(1)},
+		)
+		// verify
+		conflict := store.WriteConflictError{}
+		if assert.True(t, errors.As(err, &conflict), "write conflict error") {
+			assert.Equal(t, b.String(), conflict.StreamId.String())
+		}
+		records, err := mem.ReadRecords(ctx, streams.ParseId("batch"), 0, math.MaxInt64, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(records))
+	})
+
+	t.Run("existing stream", func(t *testing.T) {
+		// setup
+		mem := New()
+		// execute
+		_, err := mem.WriteBatch(ctx, store.StreamWrite{Id: streams.ParseId("batch-a"), Position: store.ExistingStream, Data: data(1)})
+		// verify
+		assert.True(t, errors.As(err, &store.WriteConflictError{}), "write conflict error")
+	})
+}
+
+func TestInMemory_Deduplicate(t *testing.T) {
+	ctx := context.Background()
+
+	withIds := func(ids ...string) []record.Data {
+		result := data(len(ids))
+		for i, id := range ids {
+			result[i].MessageId = id
+		}
+		return result
+	}
+
+	t.Run("same messages", func(t *testing.T) {
+		// setup
+		mem := New()
+		id := streams.ParseId("dedup-1")
+		first, err := mem.WriteRecords(ctx, id, withIds("a", "b")...)
+		assert.NoError(t, err)
+		// execute
+		got, err := mem.WriteRecordsFrom(ctx, id, -1, withIds("a", "b")...)
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, first, got)
+		records, err := mem.ReadRecords(ctx, id, -1, math.MaxInt64, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(records))
+	})
+
+	t.Run("partially written", func(t *testing.T) {
+		// setup
+		mem := New()
+		id := streams.ParseId("dedup-2")
+		_, err := mem.WriteRecords(ctx, id, withIds("a", "b")...)
+		assert.NoError(t, err)
+		// execute
+		_, err = mem.WriteRecords(ctx, id, withIds("b", "c")...)
+		// verify
+		assert.True(t, errors.As(err, &store.WriteConflictError{}), "write conflict error")
+	})
+
+	t.Run("generated ids", func(t *testing.T) {
+		// setup
+		mem := New()
+		id := streams.ParseId("dedup-3")
+		// execute
+		got, err := mem.WriteRecords(ctx, id, data(2)...)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 2, len(got)) {
+			assert.NotEmpty(t, got[0].MessageId)
+			assert.NotEqual(t, got[0].MessageId, got[1].MessageId)
+		}
+	})
+}
+
+func TestInMemory_ReadRecords(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("empty stream", func(t *testing.T) {
+		// setup
+		mem := New()
+		// execute
+		records, err := mem.ReadRecords(ctx, streams.ParseId("empty-1"), -1, math.MaxInt64, 100)
+		// verify
+		assert.NoError(t, err)
+		assert.Empty(t, records)
+	})
+
+	t.Run("mid stream", func(t *testing.T) {
+		// setup
+		mem := New()
+		id := streams.ParseId("mid-1")
+		_, err := mem.WriteRecords(ctx, id, data(10)...)
+		assert.NoError(t, err)
+		// execute
+		records, err := mem.ReadRecords(ctx, id, 4, 8, 3)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 3, len(records)) {
+			assert.Equal(t, 5, int(records[0].Number))
+			assert.Equal(t, 7, int(records[2].Number))
+		}
+	})
+
+	t.Run("mid group", func(t *testing.T) {
+		// setup
+		mem := New()
+		group := streams.ParseId("group")
+		_, err := mem.WriteRecords(ctx, streams.ParseId("other-1"), data(3)...)
+		assert.NoError(t, err)
+		for i := 0; i < 5; i++ {
+			_, err = mem.WriteRecords(ctx, group.WithEntity("entity-1"), data(1)...)
+			assert.NoError(t, err)
+			_, err = mem.WriteRecords(ctx, group.WithEntity("entity-2"), data(1)...)
+			assert.NoError(t, err)
+		}
+		// execute
+		records, err := mem.ReadRecords(ctx, group, 8, math.MaxInt64, 100)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 5, len(records)) {
+			assert.Equal(t, 9, int(records[0].GlobalNumber))
+			assert.Equal(t, 13, int(records[4].GlobalNumber))
+		}
+	})
+}
+
+func TestInMemory_SubscriptionPosition(t *testing.T) {
+	ctx := context.Background()
+	id := streams.ParseId("subscriptions")
+
+	setPosition := func(t *testing.T, mem *InMemory, position int64, commit bool) {
+		tx, err := mem.Begin(ctx)
+		assert.NoError(t, err)
+		_, err = mem.SubscriptionPositionLock(tx, id, "A")
+		assert.NoError(t, err)
+		err = mem.SetSubscriptionPosition(tx, id, store.SubscriptionPosition{SubscriptionId: "A", Position: position})
+		assert.NoError(t, err)
+		if commit {
+			assert.NoError(t, tx.Commit())
+		}
+		assert.NoError(t, tx.Rollback())
+	}
+
+	getPosition := func(t *testing.T, mem *InMemory) []store.SubscriptionPosition {
+		tx, err := mem.Begin(ctx)
+		assert.NoError(t, err)
+		defer func() {
+			_ = tx.Rollback()
+		}()
+		positions, err := mem.SubscriptionPositionLock(tx, id, "A", "B")
+		assert.NoError(t, err)
+		return positions
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		assert.Empty(t, getPosition(t, New()))
+	})
+
+	t.Run("commit", func(t *testing.T) {
+		// setup
+		mem := New()
+		// execute
+		setPosition(t, mem, 5, true)
+		// verify
+		assert.Equal(t, []store.SubscriptionPosition{{SubscriptionId: "A", Position: 5}}, getPosition(t, mem))
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		// setup
+		mem := New()
+		setPosition(t, mem, 5, true)
+		// execute
+		setPosition(t, mem, 7, false)
+		// verify
+		assert.Equal(t, []store.SubscriptionPosition{{SubscriptionId: "A", Position: 5}}, getPosition(t, mem))
+	})
+
+	t.Run("never backwards", func(t *testing.T) {
+		// setup
+		mem := New()
+		setPosition(t, mem, 5, true)
+		// execute
+		setPosition(t, mem, -1, true)
+		// verify
+		assert.Equal(t, []store.SubscriptionPosition{{SubscriptionId: "A", Position: 5}}, getPosition(t, mem))
+	})
+
+	t.Run("listed", func(t *testing.T) {
+		// setup
+		mem := New()
+		setPosition(t, mem, 5, true)
+		// execute
+		subscriptions, err := mem.ListSubscriptions(ctx)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(subscriptions)) {
+			assert.Equal(t, id, subscriptions[0].Stream)
+			assert.Equal(t, "A", subscriptions[0].SubscriptionId)
+			assert.Equal(t, int64(5), subscriptions[0].Position)
+			assert.False(t, subscriptions[0].Updated.IsZero(), "updated")
+		}
+	})
+
+	t.Run("moved backwards", func(t *testing.T) {
+		// setup
+		mem := New()
+		setPosition(t, mem, 5, true)
+		tx, err := mem.Begin(ctx)
+		assert.NoError(t, err)
+		_, err = mem.SubscriptionPositionLock(tx, id, "A")
+		assert.NoError(t, err)
+		// execute
+		err = mem.MoveSubscriptionPosition(tx, id, store.SubscriptionPosition{SubscriptionId: "A", Position: 2})
+		// verify
+		assert.NoError(t, err)
+		assert.NoError(t, tx.Commit())
+		assert.Equal(t, []store.SubscriptionPosition{{SubscriptionId: "A", Position: 2}}, getPosition(t, mem))
+	})
+
+	t.Run("other subscriber", func(t *testing.T) {
+		// setup
+		mem := New()
+		held, err := mem.Begin(ctx)
+		assert.NoError(t, err)
+		defer func() {
+			_ = held.Rollback()
+		}()
+		_, err = mem.SubscriptionPositionLock(held, id, "A")
+		assert.NoError(t, err)
+		locked := make(chan struct{})
+		// execute
+		go func() {
+			tx, _ := mem.Begin(ctx)
+			_, _ = mem.SubscriptionPositionLock(tx, id, "B")
+			_ = tx.Rollback()
+			close(locked)
+		}()
+		// verify
+		select {
+		case <-locked:
+		case <-time.After(time.Second):
+			t.Fatal("held back by the lock of A")
+		}
+	})
+}
+
+func TestInMemory_Position(t *testing.T) {
+	storetest.Positions(t, New())
+}
+
+func TestInMemory_LockStream(t *testing.T) {
+	ctx := context.Background()
+	id := streams.ParseId("lock-1")
+
+	t.Run("timeout", func(t *testing.T) {
+		// setup
+		mem := New()
+		held, err := mem.LockStream(ctx, id, 0)
+		assert.NoError(t, err)
+		// execute
+		_, err = mem.LockStream(ctx, id, time.Millisecond)
+		// verify
+		assert.True(t, errors.Is(err, store.LockTimeoutError{}), "lock timeout error")
+		assert.NoError(t, held.Rollback())
+	})
+
+	t.Run("released", func(t *testing.T) {
+		// setup
+		mem := New()
+		held, err := mem.LockStream(ctx, id, 0)
+		assert.NoError(t, err)
+		assert.NoError(t, held.Commit())
+		assert.NoError(t, held.Rollback(), "release only once")
+		// execute
+		lock, err := mem.LockStream(ctx, id, time.Millisecond)
+		// verify
+		assert.NoError(t, err)
+		assert.NoError(t, lock.Commit())
+	})
+
+	t.Run("other stream", func(t *testing.T) {
+		// setup
+		mem := New()
+		held, err := mem.LockStream(ctx, id, 0)
+		assert.NoError(t, err)
+		defer func() {
+			_ = held.Rollback()
+		}()
+		// execute
+		lock, err := mem.LockStream(ctx, streams.ParseId("lock-2"), time.Millisecond)
+		// verify
+		assert.NoError(t, err)
+		assert.NoError(t, lock.Commit())
+	})
+}
+
+func TestInMemory_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	id := streams.ParseId("snapshot-1")
+	mem := New()
+
+	snapshot, err := mem.ReadSnapshot(ctx, id, "snap")
+	assert.NoError(t, err)
+	assert.Equal(t, -1, int(snapshot.Position))
+
+	err = mem.UpdateSnapshot(ctx, id, "snap", record.Snapshot{Data: []byte(`{"a":1}`), Position: 4, ContentType: "application/json"})
+	assert.NoError(t, err)
+
+	snapshot, err = mem.ReadSnapshot(ctx, id, "snap")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, int(snapshot.Position))
+	assert.Equal(t, `{"a":1}`, string(snapshot.Data))
+}
+
+func TestInMemory_ReadRecordsOfTypes(t *testing.T) {
+	// setup
+	mem := New()
+	ctx := context.Background()
+	group := streams.ParseId("typed")
+	id := group.WithEntity("entity")
+	var written []record.Record
+	for _, contentType := range []string{"a", "b", "a", "b", "b"} {
+		r, err := mem.WriteRecords(ctx, id, record.Data{ContentType: contentType, Data: []byte("{}")})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		written = append(written, r...)
+	}
+
+	t.Run("stream", func(t *testing.T) {
+		// execute
+		records, position, err := mem.ReadRecordsOfTypes(ctx, id, -1, math.MaxInt64, 100, []string{"a"})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), position)
+		if assert.Equal(t, 2, len(records)) {
+			assert.Equal(t, int64(0), records[0].Number)
+			assert.Equal(t, int64(2), records[1].Number)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		// execute
+		records, position, err := mem.ReadRecordsOfTypes(ctx, id, -1, math.MaxInt64, 2, []string{"b"})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), position)
+		if assert.Equal(t, 2, len(records)) {
+			assert.Equal(t, int64(1), records[0].Number)
+			assert.Equal(t, int64(3), records[1].Number)
+		}
+	})
+
+	t.Run("group", func(t *testing.T) {
+		// execute
+		records, position, err := mem.ReadRecordsOfTypes(ctx, group, written[0].GlobalNumber, math.MaxInt64, 100, []string{"a"})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, written[4].GlobalNumber, position)
+		if assert.Equal(t, 1, len(records)) {
+			assert.Equal(t, written[2].GlobalNumber, records[0].GlobalNumber)
+		}
+	})
+
+	t.Run("no records of the types", func(t *testing.T) {
+		// execute
+		records, position, err := mem.ReadRecordsOfTypes(ctx, id, 0, math.MaxInt64, 100, []string{"c"})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), position)
+		assert.Empty(t, records)
+	})
+}
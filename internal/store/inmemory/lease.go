@@ -0,0 +1,54 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-po/po/internal/store"
+)
+
+// Takes or renews the lease for the owner, reporting false if another owner holds it
+func (mem *InMemory) AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	now := time.Now()
+	lease, found := mem.leases[name]
+	if found && lease.Owner != owner && lease.Expires.After(now) {
+		return false, nil
+	}
+	mem.leases[name] = store.Lease{
+		Name:    name,
+		Owner:   owner,
+		Expires: now.Add(ttl),
+	}
+	return true, nil
+}
+
+// Gives up the lease, if held by the owner
+func (mem *InMemory) ReleaseLease(ctx context.Context, name, owner string) error {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	if lease, found := mem.leases[name]; found && lease.Owner == owner {
+		delete(mem.leases, name)
+	}
+	return nil
+}
+
+// Unexpired leases with names starting with the prefix
+func (mem *InMemory) Leases(ctx context.Context, prefix string) ([]store.Lease, error) {
+	mem.mu.RLock()
+	defer mem.mu.RUnlock()
+	now := time.Now()
+	var result []store.Lease
+	for name, lease := range mem.leases {
+		if strings.HasPrefix(name, prefix) && lease.Expires.After(now) {
+			result = append(result, lease)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
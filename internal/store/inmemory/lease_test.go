@@ -0,0 +1,11 @@
+package inmemory
+
+import (
+	"testing"
+
+	"github.com/go-po/po/internal/store/storetest"
+)
+
+func TestInMemory_Lease(t *testing.T) {
+	storetest.Leases(t, New())
+}
@@ -0,0 +1,82 @@
+package inmemory
+
+import (
+	"context"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
+)
+
+// Writes the notifications of records to the outbox along with them, from now on
+func (mem *InMemory) EnableOutbox() {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	mem.outbox = true
+}
+
+// must be called while holding the write lock
+func (mem *InMemory) storeNotifications(records []record.Record) {
+	for _, r := range records {
+		mem.notified = mem.notified + 1
+		mem.notifications = append(mem.notifications, store.Notification{
+			Id:           mem.notified,
+			Stream:       r.Stream,
+			Number:       r.Number,
+			GlobalNumber: r.GlobalNumber,
+			Time:         r.Time,
+		})
+	}
+}
+
+// Claims the oldest notifications not claimed by another relay, up to the limit, and publishes them in order.
+// The published are removed from the outbox, the rest are kept after a failure.
+func (mem *InMemory) RelayNotifications(ctx context.Context, limit int, publish func(n store.Notification) error) (int, error) {
+	claimed := mem.claimNotifications(limit)
+
+	completed := make(map[int64]bool)
+	var err error
+	for _, n := range claimed {
+		err = publish(n)
+		if err != nil {
+			break
+		}
+		completed[n.Id] = true
+	}
+
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	for _, n := range claimed {
+		delete(mem.claimed, n.Id)
+	}
+	var pending []store.Notification
+	for _, n := range mem.notifications {
+		if !completed[n.Id] {
+			pending = append(pending, n)
+		}
+	}
+	mem.notifications = pending
+	return len(claimed), err
+}
+
+func (mem *InMemory) claimNotifications(limit int) []store.Notification {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	var claimed []store.Notification
+	for _, n := range mem.notifications {
+		if len(claimed) == limit {
+			break
+		}
+		if !mem.claimed[n.Id] {
+			mem.claimed[n.Id] = true
+			claimed = append(claimed, n)
+		}
+	}
+	return claimed
+}
+
+// Number of notifications in the outbox
+func (mem *InMemory) CountNotifications(ctx context.Context) (int64, error) {
+	mem.mu.RLock()
+	defer mem.mu.RUnlock()
+	return int64(len(mem.notifications)), nil
+}
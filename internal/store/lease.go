@@ -0,0 +1,12 @@
+package store
+
+import (
+	"time"
+)
+
+// Held by its owner until it expires, unless renewed before then
+type Lease struct {
+	Name    string
+	Owner   string
+	Expires time.Time
+}
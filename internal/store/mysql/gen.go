@@ -0,0 +1,3 @@
+package mysql
+
+//go:generate go run github.com/jteeuwen/go-bindata/go-bindata -prefix schema -o ./generated/db/migrations.go -ignore .go -pkg db schema
@@ -0,0 +1,161 @@
+package db
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func bindata_read(data []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewBuffer(data))
+	if err != nil {
+		return nil, fmt.Errorf("Read %q: %v", name, err)
+	}
+
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, gz)
+	gz.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("Read %q: %v", name, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+var __1_create_records_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\xc8\x8f\x2f\xce\x4b\x2c\x28\xce\xc8\x2f\x29\xb6\xe6\xc2\xa9\xa4\x34\xa9\x38\xb9\x28\xb3\xa0\x24\x33\x3f\x0f\x8f\xb2\xf4\xa2\xfc\xd2\x02\x3c\xf2\xb9\xa9\xc5\xc5\x89\xe9\xa9\xc5\xd6\x5c\x80\x01\x00\x62\xa3\x47\x7a\x8c\x00\x00\x00")
+
+func _1_create_records_down_sql() ([]byte, error) {
+	return bindata_read(
+		__1_create_records_down_sql,
+		"1_create_records.down.sql",
+	)
+}
+
+var __1_create_records_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x54\xcb\x6e\xdb\x30\x10\xbc\xeb\x2b\xe6\x68\x03\x16\xd0\x57\x82\x02\x45\x0e\xb2\xc3\x38\x6a\x6d\x39\x95\xe9\xa2\x39\x09\xb4\xc9\x28\x44\x23\x52\x20\x29\xb4\xf9\xfb\xc2\x7a\x58\x52\xe0\xa8\x4e\x61\x9f\xe4\xc5\xcc\xec\x72\x67\x77\x7d\x1f\x52\x49\x27\xd9\x13\xec\xee\x51\x64\x6c\x82\x4c\x1a\xa3\x8d\x54\x29\xdc\xa3\x40\xae\xad\x4b\x8d\xb0\xb0\x4e\x1b\xe1\xcd\x62\x12\x50\x02\x1a\x4c\x17\x04\xe1\x0d\xa2\x15\x05\xf9\x19\xae\xe9\x1a\xb9\x4e\x32\x61\x2d\x4b\x85\xf5\x46\x1e\x00\x48\x8e\xee\x6f\x1a\xce\xc3\x88\xd6\x7f\xf6\xc4\x68\xb3\x58\x20\xd8\xd0\x55\x12\x46\xb3\x98\x2c\x49\x44\x27\x25\x71\x67\x04\x73\xe2\xc0\xa6\xe1\x92\xac\x69\xb0\xbc\x1b\x5d\x8e\x5b\xe2\x35\xb9\x09\x36\x0b\x8a\xd9\x26\x8e\x49\x44\x93\x2e\xaa\x92\xb1\xce\x08\x96\x35\x2a\xf8\x11\xc4\xb3\xdb\x20\x1e\x7d\xb8\xb8\x68\x65\x2a\xa4\xd2\x27\x54\xda\x24\x7c\x57\x71\x52\x93\xd7\x88\x01\x75\xf8\x3e\x52\xa3\x8b\x1c\x52\x61\xae\x4b\xe2\x4e\x2b\x27\x94\x4b\xdc\x73\x2e\x5e\x25\x96\x48\xce\x1c\x6b\xf4\x01\x2c\x56\xd1\x7c\xba\x58\x4d\x7b\x65\xd5\x1d\xd3\xc6\x88\x27\xe6\xa4\x56\x89\xe4\x2f\x34\x5b\x14\x2b\xec\x01\x83\xd7\x50\x99\x70\xac\x93\xf9\xeb\x7a\x15\x01\x2f\x9a\xd1\x20\x4b\xbf\x93\xda\xe8\x32\xe5\xc7\xcb\xf1\xfe\xbb\x83\xba\x8b\xc3\x65\x10\xdf\xe3\x1b\xb9\xc7\x48\xf2\xda\x9c\x4d\x14\x7e\xdf\x90\x32\xd8\x99\x9c\xa4\xf2\x2c\x51\x45\xb6\x15\x26\x29\xa4\xe2\xe2\x0f\x46\x55\x74\x02\xa5\x87\xd9\xf5\x47\x22\xf9\x81\xda\x86\x6a\xea\x4b\x4e\x6a\xf2\xa4\xc6\xa6\x26\x9f\x40\xf2\xb1\x37\x06\x89\xe6\x61\x44\x70\x85\x50\x29\x7d\x3d\xf5\xd0\xce\xdb\x6d\x10\xaf\x09\xc5\x15\x0a\xf7\xf0\x39\xdb\x7e\xfa\xe2\x79\xbe\x8f\x27\xbd\xfb\x25\x38\xb6\xcf\xf8\x6d\xa4\x13\xc6\xc2\x69\xb0\xca\xfc\x09\xac\x6e\x9a\x65\xa1\x1f\x9a\x38\x76\x3a\xcb\xa4\xdb\xcf\x86\xe4\xd0\x86\x0b\xf3\x8f\x0d\x2b\xe5\x9a\xfd\xda\x4f\x60\xdf\xc3\x15\x7d\xad\xef\xa9\xc9\xdf\xfe\xac\xe1\x5a\x6c\xb1\xb5\x3b\x23\xf3\xfd\x40\x35\x25\xf5\x37\xf7\x7f\x17\xb7\xc8\xf9\x19\x54\xfa\xeb\x3f\xd0\xa8\xfa\x21\xfb\x81\x93\x7c\x08\xd8\x3f\x13\x47\xef\xd9\x91\xd6\x37\xc3\xdb\x4b\x73\x76\x33\x14\xcb\xed\xa3\x76\x47\x8d\x38\x8b\x0f\x67\xb1\x61\xc8\x85\xfa\x05\xe5\x35\x39\xd5\x84\xa3\x1e\x1c\x8a\xf2\xdf\x1f\xb9\xa3\x83\x57\xb4\x73\x99\x4f\xdd\xac\x83\xbd\x6d\xfd\x6f\x36\xf7\xef\x00\x85\x68\xe6\x66\x87\x07\x00\x00")
+
+func _1_create_records_up_sql() ([]byte, error) {
+	return bindata_read(
+		__1_create_records_up_sql,
+		"1_create_records.up.sql",
+	)
+}
+
+var __2_leases_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x20\x00\xdf\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x70\x6f\x5f\x6c\x65\x61\x73\x65\x73\x3b\x0a\x03\x00\xb5\x16\x64\xaf\x20\x00\x00\x00")
+
+func _2_leases_down_sql() ([]byte, error) {
+	return bindata_read(
+		__2_leases_down_sql,
+		"2_leases.down.sql",
+	)
+}
+
+var __2_leases_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x74\xcc\x31\x6b\x83\x40\x18\x87\xf1\xfd\x3e\xc5\x7f\x54\xe8\x54\x6a\x29\x14\x87\x53\x5f\xdb\xa3\xe7\x55\xee\x5e\x43\x9c\x82\x81\x0b\x04\xe2\x29\x9a\x90\x7c\xfc\xa0\x19\x32\x65\x7a\x96\x1f\x4f\x6e\x49\x32\x81\x65\xa6\x09\xaa\x84\xf9\x67\xd0\x56\x39\x76\x18\x87\xdd\xc9\x77\xb3\x9f\x45\x24\x00\x20\x74\xbd\x5f\xba\x91\x36\xff\x95\x36\x7a\x4f\x92\x78\xf5\xa6\xd1\x1a\xb5\x55\x95\xb4\x2d\xfe\xa8\x7d\x5b\xf9\x70\x0d\x7e\x7a\xc5\x1f\xc4\xdf\xc6\xe3\xe4\x67\xb0\xaa\xc8\xb1\xac\xea\xe8\xf3\x49\x44\x0c\x32\x3f\xca\x10\x52\xa8\x10\x86\x22\x13\x40\x41\xa5\x6c\x34\x63\x39\x3a\x62\xa4\xb8\x9c\x0f\x5f\xfd\xfe\xe3\x5b\xdc\x07\x00\x32\xf9\x30\x34\xca\x00\x00\x00")
+
+func _2_leases_up_sql() ([]byte, error) {
+	return bindata_read(
+		__2_leases_up_sql,
+		"2_leases.up.sql",
+	)
+}
+
+var __3_outbox_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x20\x00\xdf\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x70\x6f\x5f\x6f\x75\x74\x62\x6f\x78\x3b\x0a\x03\x00\x07\xaf\x46\x2b\x20\x00\x00\x00")
+
+func _3_outbox_down_sql() ([]byte, error) {
+	return bindata_read(
+		__3_outbox_down_sql,
+		"3_outbox.down.sql",
+	)
+}
+
+var __3_outbox_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x8e\x41\x4b\xc3\x40\x10\x46\xef\xfb\x2b\xbe\x63\x0a\x9e\xc4\x8a\x20\x3d\x4c\xd2\x69\x3b\x98\x6c\xcb\x64\x22\xf6\x14\x12\x8d\x22\xb4\x59\xa9\x5b\xf0\xe7\x4b\x6c\xb1\x3d\x65\x4e\x33\xf0\x1e\x6f\x32\x65\x32\x86\x51\x9a\x33\x64\x01\xbf\x36\xf0\x8b\x94\x56\xe2\x2b\xd4\xe1\x18\xdb\xf0\xe3\x12\x07\x00\x9f\x6f\x38\x4f\x2a\x4b\xf1\x76\x3e\x06\xc3\x57\x79\x0e\xaa\x6c\x5d\x8b\xcf\x94\x0b\xf6\x86\x8d\x4a\x41\xba\xc5\x13\x6f\x6f\xfe\xfc\xef\x78\xe8\x9a\xfd\xb0\x3d\x93\x66\x2b\xd2\xe4\x76\x3a\x9d\xfc\xfb\x27\xa8\x0f\xa3\x91\x13\xf4\xb1\x0b\x6d\xb3\xab\xfb\x30\x06\xbd\x1e\xba\x26\x76\xc3\xcf\x26\x05\x97\x46\xc5\x26\xb9\xbf\xe4\x30\xe7\x05\x55\xb9\x21\xab\x54\xd9\x5b\x7d\x4d\xb9\x09\xd8\x2f\xc5\x33\x66\x90\xbe\x0f\xf3\xd4\xe1\x22\xac\x48\x4b\x36\xcc\x70\x8c\xef\x0f\xfb\xf6\xee\xd1\xfd\x0e\x00\x9c\xcc\x4e\xba\x46\x01\x00\x00")
+
+func _3_outbox_up_sql() ([]byte, error) {
+	return bindata_read(
+		__3_outbox_up_sql,
+		"3_outbox.up.sql",
+	)
+}
+
+// Asset loads and returns the asset for the given name.
+// It returns an error if the asset could not be found or
+// could not be loaded.
+func Asset(name string) ([]byte, error) {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	if f, ok := _bindata[cannonicalName]; ok {
+		return f()
+	}
+	return nil, fmt.Errorf("Asset %s not found", name)
+}
+
+// AssetNames returns the names of the assets.
+func AssetNames() []string {
+	names := make([]string, 0, len(_bindata))
+	for name := range _bindata {
+		names = append(names, name)
+	}
+	return names
+}
+
+// _bindata is a table, holding each asset generator, mapped to its name.
+var _bindata = map[string]func() ([]byte, error){
+	"1_create_records.down.sql": _1_create_records_down_sql,
+	"1_create_records.up.sql":   _1_create_records_up_sql,
+	"2_leases.down.sql":         _2_leases_down_sql,
+	"2_leases.up.sql":           _2_leases_up_sql,
+	"3_outbox.down.sql":         _3_outbox_down_sql,
+	"3_outbox.up.sql":           _3_outbox_up_sql,
+}
+
+// AssetDir returns the file names below a certain
+// directory embedded in the file by go-bindata.
+// For example if you run go-bindata on data/... and data contains the
+// following hierarchy:
+//
+//	data/
+//	  foo.txt
+//	  img/
+//	    a.png
+//	    b.png
+//
+// then AssetDir("data") would return []string{"foo.txt", "img"}
+// AssetDir("data/img") would return []string{"a.png", "b.png"}
+// AssetDir("foo.txt") and AssetDir("notexist") would return an error
+// AssetDir("") will return []string{"data"}.
+func AssetDir(name string) ([]string, error) {
+	node := _bintree
+	if len(name) != 0 {
+		cannonicalName := strings.Replace(name, "\\", "/", -1)
+		pathList := strings.Split(cannonicalName, "/")
+		for _, p := range pathList {
+			node = node.Children[p]
+			if node == nil {
+				return nil, fmt.Errorf("Asset %s not found", name)
+			}
+		}
+	}
+	if node.Func != nil {
+		return nil, fmt.Errorf("Asset %s not found", name)
+	}
+	rv := make([]string, 0, len(node.Children))
+	for name := range node.Children {
+		rv = append(rv, name)
+	}
+	return rv, nil
+}
+
+type _bintree_t struct {
+	Func     func() ([]byte, error)
+	Children map[string]*_bintree_t
+}
+
+var _bintree = &_bintree_t{nil, map[string]*_bintree_t{
+	"1_create_records.down.sql": &_bintree_t{_1_create_records_down_sql, map[string]*_bintree_t{}},
+	"1_create_records.up.sql":   &_bintree_t{_1_create_records_up_sql, map[string]*_bintree_t{}},
+	"2_leases.down.sql":         &_bintree_t{_2_leases_down_sql, map[string]*_bintree_t{}},
+	"2_leases.up.sql":           &_bintree_t{_2_leases_up_sql, map[string]*_bintree_t{}},
+	"3_outbox.down.sql":         &_bintree_t{_3_outbox_down_sql, map[string]*_bintree_t{}},
+	"3_outbox.up.sql":           &_bintree_t{_3_outbox_up_sql, map[string]*_bintree_t{}},
+}}
@@ -0,0 +1,48 @@
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4/database/mysql"
+
+	"github.com/go-po/po/internal/store/mysql/generated/db"
+	"github.com/golang-migrate/migrate/v4"
+	bindata "github.com/golang-migrate/migrate/v4/source/go_bindata"
+)
+
+// conn must allow multiple statements per query
+func migrateDatabase(conn *sql.DB) error {
+
+	d, err := mysql.WithInstance(conn, &mysql.Config{
+		MigrationsTable: "po_migrations",
+	})
+	if err != nil {
+		return fmt.Errorf("driver: %w", err)
+	}
+
+	resource := bindata.Resource(db.AssetNames(),
+		func(name string) ([]byte, error) {
+			return db.Asset(name)
+		})
+	data, err := bindata.WithInstance(resource)
+	if err != nil {
+		return fmt.Errorf("bindata: %w", err)
+	}
+
+	migrates, err := migrate.NewWithInstance("migrations", data, "po", d)
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	err = migrates.Up()
+
+	switch err {
+	case migrate.ErrNoChange:
+		return nil
+	case nil:
+		return nil
+	default:
+		return fmt.Errorf("up: %w", err)
+	}
+}
@@ -0,0 +1,18 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateDatabase(t *testing.T) {
+	// setup
+	db := databaseConnection(t)
+
+	// execute
+	err := migrateDatabase(db)
+
+	// verify
+	assert.NoError(t, err, "migration failed")
+}
@@ -0,0 +1,170 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// implemented by *sql.DB, *sql.Conn and *sql.Tx
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+const messageColumns = `id, created, stream, no, grp, content_type, data, correlation_id, causation_id, metadata, message_id`
+
+// the lock is held until the transaction ends
+const lockGroup = `-- name: LockGroup
+INSERT INTO po_groups (grp) VALUES (?)
+ON DUPLICATE KEY UPDATE grp = grp`
+
+const getStreamPosition = `-- name: GetStreamPosition
+SELECT COALESCE(MAX(no), -1) FROM po_messages WHERE stream = ?`
+
+const getGroupPosition = `-- name: GetGroupPosition
+SELECT COALESCE(MAX(id), -1) FROM po_messages WHERE grp = ?`
+
+const getStreamPositionBefore = `-- name: GetStreamPositionBefore
+SELECT COALESCE(MAX(no), -1) FROM po_messages WHERE stream = ? AND created < ?`
+
+const getGroupPositionBefore = `-- name: GetGroupPositionBefore
+SELECT COALESCE(MAX(id), -1) FROM po_messages WHERE grp = ? AND created < ?`
+
+const countRecordsByStream = `-- name: CountRecordsByStream
+SELECT COUNT(*) FROM po_messages WHERE stream = ? AND no > ?`
+
+const countRecordsByGroup = `-- name: CountRecordsByGroup
+SELECT COUNT(*) FROM po_messages WHERE grp = ? AND id > ?`
+
+const storeRecord = `-- name: StoreRecord
+INSERT INTO po_messages (stream, no, grp, content_type, data, correlation_id, causation_id, metadata, message_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+const readRecordById = `-- name: ReadRecordById
+SELECT ` + messageColumns + ` FROM po_messages WHERE id = ?`
+
+const readRecordsByStream = `-- name: ReadRecordsByStream
+SELECT ` + messageColumns + ` FROM po_messages
+WHERE stream = ? AND no > ? AND no <= ?
+ORDER BY no
+LIMIT ?`
+
+const readRecordsByGroup = `-- name: ReadRecordsByGroup
+SELECT ` + messageColumns + ` FROM po_messages
+WHERE grp = ? AND id > ? AND id <= ?
+ORDER BY id
+LIMIT ?`
+
+func readRecordsByStreamOfTypes(count int) string {
+	return `-- name: ReadRecordsByStreamOfTypes
+SELECT ` + messageColumns + ` FROM po_messages
+WHERE stream = ? AND no > ? AND no <= ? AND content_type IN (` + placeholders(count) + `)
+ORDER BY no
+LIMIT ?`
+}
+
+func readRecordsByGroupOfTypes(count int) string {
+	return `-- name: ReadRecordsByGroupOfTypes
+SELECT ` + messageColumns + ` FROM po_messages
+WHERE grp = ? AND id > ? AND id <= ? AND content_type IN (` + placeholders(count) + `)
+ORDER BY id
+LIMIT ?`
+}
+
+func readRecordsByMessageId(count int) string {
+	return `-- name: ReadRecordsByMessageId
+SELECT ` + messageColumns + ` FROM po_messages
+WHERE message_id IN (` + placeholders(count) + `)
+ORDER BY id`
+}
+
+const getSnapshotPosition = `-- name: GetSnapshotPosition
+SELECT no, content_type, data FROM po_snapshots
+WHERE stream = ? AND snapshot_id = ?`
+
+const updateSnapshotQuery = `-- name: UpdateSnapshot
+INSERT INTO po_snapshots (stream, snapshot_id, no, content_type, data)
+VALUES (?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE
+    no           = VALUES(no),
+    content_type = VALUES(content_type),
+    data         = VALUES(data),
+    updated      = CURRENT_TIMESTAMP(6)`
+
+const deleteSnapshotQuery = `-- name: DeleteSnapshot
+DELETE FROM po_snapshots WHERE stream = ? AND snapshot_id = ?`
+
+func lockSubscriberPosition(count int) string {
+	return `-- name: LockSubscriberPosition
+SELECT subscriber_id, no FROM po_subscriptions
+WHERE stream = ? AND subscriber_id IN (` + placeholders(count) + `)
+ORDER BY stream DESC, subscriber_id DESC -- to avoid deadlock
+FOR UPDATE`
+}
+
+// positions only move forward
+const setSubscriberPosition = `-- name: SetSubscriberPosition
+INSERT INTO po_subscriptions (stream, subscriber_id, no)
+VALUES (?, ?, ?)
+ON DUPLICATE KEY UPDATE
+    updated = IF(no < VALUES(no), CURRENT_TIMESTAMP(6), updated),
+    no      = GREATEST(no, VALUES(no))`
+
+// moves backwards as well
+const moveSubscriberPositionQuery = `-- name: MoveSubscriberPosition
+INSERT INTO po_subscriptions (stream, subscriber_id, no)
+VALUES (?, ?, ?)
+ON DUPLICATE KEY UPDATE
+    updated = CURRENT_TIMESTAMP(6),
+    no      = VALUES(no)`
+
+const getSubscriptions = `-- name: GetSubscriptions
+SELECT stream, subscriber_id, no, updated FROM po_subscriptions
+ORDER BY stream, subscriber_id`
+
+// owner is assigned first, so expires is only moved by the owner of the lease
+const acquireLeaseQuery = `-- name: AcquireLease
+INSERT INTO po_leases (name, owner, expires)
+VALUES (?, ?, CURRENT_TIMESTAMP(6) + INTERVAL ? MICROSECOND)
+ON DUPLICATE KEY UPDATE
+    owner   = IF(owner = VALUES(owner) OR expires < CURRENT_TIMESTAMP(6), VALUES(owner), owner),
+    expires = IF(owner = VALUES(owner), VALUES(expires), expires)`
+
+const getLeaseOwner = `-- name: GetLeaseOwner
+SELECT owner FROM po_leases WHERE name = ?`
+
+const releaseLeaseQuery = `-- name: ReleaseLease
+DELETE FROM po_leases WHERE name = ? AND owner = ?`
+
+const getLeases = `-- name: GetLeases
+SELECT name, owner, expires FROM po_leases
+WHERE LEFT(name, CHAR_LENGTH(?)) = ? AND expires > CURRENT_TIMESTAMP(6)
+ORDER BY name`
+
+const storeNotification = `-- name: StoreNotification
+INSERT INTO po_outbox (stream, no, global_no) VALUES (?, ?, ?)`
+
+const claimNotifications = `-- name: ClaimNotifications
+SELECT id, stream, no, global_no, created FROM po_outbox
+ORDER BY id
+LIMIT ? FOR UPDATE SKIP LOCKED`
+
+func deleteNotifications(count int) string {
+	return `-- name: DeleteNotifications
+DELETE FROM po_outbox WHERE id IN (` + placeholders(count) + `)`
+}
+
+const countNotificationsQuery = `-- name: CountNotifications
+SELECT COUNT(*) FROM po_outbox`
+
+const getLock = `-- name: GetLock
+SELECT GET_LOCK(?, ?)`
+
+const releaseLock = `-- name: ReleaseLock
+SELECT RELEASE_LOCK(?)`
+
+func placeholders(count int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", count), ", ")
+}
@@ -0,0 +1,52 @@
+-- initial schema, mirroring the postgres store
+CREATE TABLE IF NOT EXISTS po_messages
+(
+    id             BIGINT       NOT NULL AUTO_INCREMENT,
+    created        TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    stream         VARCHAR(255) NOT NULL,
+    no             BIGINT       NOT NULL DEFAULT 0,
+    grp            VARCHAR(255) NOT NULL, -- group in Go
+    content_type   VARCHAR(255) NOT NULL,
+    data           LONGBLOB     NOT NULL,
+    correlation_id VARCHAR(255) NULL,
+    causation_id   VARCHAR(255) NULL,
+    metadata       JSON         NOT NULL,
+    message_id     CHAR(36)     NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY po_messages_stream_number_uindex (stream, no),
+    UNIQUE KEY po_messages_message_id_uindex (message_id),
+    KEY po_messages_grp_index (grp, id)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4;
+
+-- locked by writers to a group, so messages of a group commit in id order
+CREATE TABLE IF NOT EXISTS po_groups
+(
+    grp VARCHAR(255) NOT NULL,
+    PRIMARY KEY (grp)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS po_subscriptions
+(
+    created       TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated       TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    stream        VARCHAR(255) NOT NULL,
+    subscriber_id VARCHAR(255) NOT NULL,
+    no            BIGINT       NOT NULL,
+    PRIMARY KEY (stream, subscriber_id)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS po_snapshots
+(
+    created      TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated      TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    stream       VARCHAR(255) NOT NULL,
+    snapshot_id  VARCHAR(255) NOT NULL,
+    no           BIGINT       NOT NULL DEFAULT -1,
+    data         LONGBLOB     NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    PRIMARY KEY (stream, snapshot_id)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4;
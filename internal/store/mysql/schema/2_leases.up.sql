@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS po_leases
+(
+    name    VARCHAR(255) NOT NULL PRIMARY KEY,
+    owner   VARCHAR(255) NOT NULL,
+    expires TIMESTAMP(6) NOT NULL
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS po_outbox
+(
+    id        BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    stream    VARCHAR(255) NOT NULL,
+    no        BIGINT       NOT NULL,
+    global_no BIGINT       NOT NULL,
+    created   TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1,217 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
+	"github.com/go-sql-driver/mysql"
+)
+
+// Connects using a data source name as understood by github.com/go-sql-driver/mysql,
+// e.g. po:po@tcp(localhost:3305)/po
+func NewFromUrl(dataSourceName string) (*Storage, error) {
+	cfg, err := mysql.ParseDSN(dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ParseTime = true
+	cfg.MultiStatements = true
+	conn, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		return nil, err
+	}
+	storage, err := NewFromConn(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	storage.closeConn = true
+	return storage, nil
+}
+
+// The connection must be opened with parseTime=true and multiStatements=true
+func NewFromConn(conn *sql.DB) (*Storage, error) {
+	err := migrateDatabase(conn)
+	if err != nil {
+		return nil, err
+	}
+	return &Storage{
+		conn: conn,
+	}, nil
+}
+
+type Storage struct {
+	conn   *sql.DB
+	outbox bool // writes notifications to the outbox
+
+	closeConn bool // the database was opened by the storage, and is closed with it
+}
+
+// Closes the database if it was opened by the storage.
+// A database handed to NewFromConn is left to its owner.
+func (store *Storage) Close() error {
+	if !store.closeConn {
+		return nil
+	}
+	return store.conn.Close()
+}
+
+// database the records are written to, along with their notifications once the outbox is enabled
+func (store *Storage) writer() database {
+	if store.outbox {
+		return outboxDB{DB: store.conn}
+	}
+	return store.conn
+}
+
+func (store *Storage) Begin(ctx context.Context) (store.Tx, error) {
+	tx, err := store.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &storageTx{
+		ctx: ctx,
+		tx:  tx,
+	}, nil
+}
+
+func (store *Storage) SubscriptionPositionLock(storeTx store.Tx, id streams.Id, subscriptionIds ...string) ([]store.SubscriptionPosition, error) {
+	tx, isTx := storeTx.(*storageTx)
+	if !isTx {
+		return nil, ErrUnknownTx{tx: storeTx}
+	}
+	return subscriberPositionLock(tx.ctx, tx.tx, id, subscriptionIds...)
+}
+
+func (store *Storage) SetSubscriptionPosition(storeTx store.Tx, id streams.Id, position store.SubscriptionPosition) error {
+	tx, isTx := storeTx.(*storageTx)
+	if !isTx {
+		return ErrUnknownTx{tx: storeTx}
+	}
+	return updateSubscriberPosition(tx.ctx, tx.tx, id, position)
+}
+
+// Sets the position of the subscription, even if lower than its current position
+func (store *Storage) MoveSubscriptionPosition(storeTx store.Tx, id streams.Id, position store.SubscriptionPosition) error {
+	tx, isTx := storeTx.(*storageTx)
+	if !isTx {
+		return ErrUnknownTx{tx: storeTx}
+	}
+	return moveSubscriberPosition(tx.ctx, tx.tx, id, position)
+}
+
+func (store *Storage) WriteRecords(ctx context.Context, id streams.Id, data ...record.Data) ([]record.Record, error) {
+	return writeRecords(ctx, store.writer(), id, endOfStream, data...)
+}
+
+func (store *Storage) WriteRecordsFrom(ctx context.Context, id streams.Id, position int64, data ...record.Data) ([]record.Record, error) {
+	return writeRecords(ctx, store.writer(), id, position, data...)
+}
+
+// Writes to all the streams or none of them
+func (store *Storage) WriteBatch(ctx context.Context, writes ...store.StreamWrite) ([]record.Record, error) {
+	return writeBatch(ctx, store.writer(), writes...)
+}
+
+// Locks the stream against other lockers until the returned transaction ends
+func (store *Storage) LockStream(ctx context.Context, id streams.Id, timeout time.Duration) (store.Tx, error) {
+	return lockStream(ctx, store.conn, id, timeout)
+}
+
+func (store *Storage) ReadRecords(ctx context.Context, id streams.Id, from int64, to, limit int64) ([]record.Record, error) {
+	return readRecords(ctx, store.conn, id, from, to, limit)
+}
+
+// Records of the content types only, and the position the read got to, past the records of other types
+func (store *Storage) ReadRecordsOfTypes(ctx context.Context, id streams.Id, from, to, limit int64, contentTypes []string) ([]record.Record, int64, error) {
+	return readRecordsOfTypes(ctx, store.conn, id, from, to, limit, contentTypes)
+}
+
+// Position of the last record of the stream, -1 if it is empty
+func (store *Storage) HeadPosition(ctx context.Context, id streams.Id) (int64, error) {
+	return headPosition(ctx, store.conn, id)
+}
+
+// Position of the last record of the stream written before the time, -1 if there is none
+func (store *Storage) PositionBefore(ctx context.Context, id streams.Id, t time.Time) (int64, error) {
+	return positionBefore(ctx, store.conn, id, t)
+}
+
+// Number of records of the stream after the position
+func (store *Storage) CountRecords(ctx context.Context, id streams.Id, after int64) (int64, error) {
+	return countRecords(ctx, store.conn, id, after)
+}
+
+// Stored positions of every subscription
+func (store *Storage) ListSubscriptions(ctx context.Context) ([]store.Subscription, error) {
+	return subscriptions(ctx, store.conn)
+}
+
+func (store *Storage) ReadSnapshot(ctx context.Context, id streams.Id, snapshotId string) (record.Snapshot, error) {
+	return readSnapshot(ctx, store.conn, id, snapshotId)
+}
+
+func (store *Storage) UpdateSnapshot(ctx context.Context, id streams.Id, snapshotId string, snapshot record.Snapshot) error {
+	return updateSnapshot(ctx, store.conn, id, snapshotId, snapshot)
+}
+
+func (store *Storage) DeleteSnapshot(ctx context.Context, id streams.Id, snapshotId string) error {
+	return deleteSnapshot(ctx, store.conn, id, snapshotId)
+}
+
+type storageTx struct {
+	ctx context.Context
+	tx  *sql.Tx
+}
+
+func (facade *storageTx) Commit() error {
+	return facade.tx.Commit()
+}
+
+func (facade *storageTx) Rollback() error {
+	return facade.tx.Rollback()
+}
+
+type ErrUnknownTx struct {
+	tx store.Tx
+}
+
+func (err ErrUnknownTx) Error() string {
+	return fmt.Sprintf("unknown tx type: %T", err.tx)
+}
+
+// Takes or renews the lease for the owner, reporting false if another owner holds it
+func (store *Storage) AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	return acquireLease(ctx, store.conn, name, owner, ttl)
+}
+
+// Gives up the lease, if held by the owner
+func (store *Storage) ReleaseLease(ctx context.Context, name, owner string) error {
+	return releaseLease(ctx, store.conn, name, owner)
+}
+
+// Unexpired leases with names starting with the prefix
+func (store *Storage) Leases(ctx context.Context, prefix string) ([]store.Lease, error) {
+	return leases(ctx, store.conn, prefix)
+}
+
+// Writes the notifications of records to the outbox in the transaction writing them, from now on
+func (store *Storage) EnableOutbox() {
+	store.outbox = true
+}
+
+// Claims the oldest notifications not claimed by another relay, up to the limit, and publishes them in order.
+// The published are removed from the outbox, the rest are kept after a failure.
+func (store *Storage) RelayNotifications(ctx context.Context, limit int, publish func(n store.Notification) error) (int, error) {
+	return relayNotifications(ctx, store.conn, limit, publish)
+}
+
+// Number of notifications in the outbox
+func (store *Storage) CountNotifications(ctx context.Context) (int64, error) {
+	return countNotifications(ctx, store.conn)
+}
@@ -0,0 +1,56 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/go-po/po/internal/store"
+)
+
+// Takes or renews the lease, reporting false if another owner holds it.
+// Expiry uses the clock of the database, shared by every instance.
+func acquireLease(ctx context.Context, conn *sql.DB, name, owner string, ttl time.Duration) (bool, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	_, err = tx.ExecContext(ctx, acquireLeaseQuery, name, owner, ttl.Microseconds())
+	if err != nil {
+		return false, err
+	}
+	var current string
+	err = tx.QueryRowContext(ctx, getLeaseOwner, name).Scan(&current)
+	if err != nil {
+		return false, err
+	}
+	return current == owner, tx.Commit()
+}
+
+func releaseLease(ctx context.Context, conn dbtx, name, owner string) error {
+	_, err := conn.ExecContext(ctx, releaseLeaseQuery, name, owner)
+	return err
+}
+
+func leases(ctx context.Context, conn dbtx, prefix string) ([]store.Lease, error) {
+	rows, err := conn.QueryContext(ctx, getLeases, prefix, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var result []store.Lease
+	for rows.Next() {
+		lease := store.Lease{}
+		err = rows.Scan(&lease.Name, &lease.Owner, &lease.Expires)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, lease)
+	}
+	return result, rows.Err()
+}
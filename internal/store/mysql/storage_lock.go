@@ -0,0 +1,65 @@
+package mysql
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"time"
+
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
+)
+
+// Takes a named lock on the stream, held by a connection of its own
+// until the returned transaction ends.
+// A zero timeout waits for as long as the context allows.
+func lockStream(ctx context.Context, conn *sql.DB, id streams.Id, timeout time.Duration) (store.Tx, error) {
+	c, err := conn.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name := lockName(id)
+	seconds := -1.0 // no timeout
+	if timeout > 0 {
+		seconds = timeout.Seconds()
+	}
+	var locked sql.NullInt64
+	err = c.QueryRowContext(ctx, getLock, name, seconds).Scan(&locked)
+	if err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		_ = c.Close()
+		return nil, store.LockTimeoutError{StreamId: id}
+	}
+	return &streamLockTx{conn: c, name: name}, nil
+}
+
+// lock names are limited to 64 characters
+func lockName(id streams.Id) string {
+	sum := sha1.Sum([]byte(id.String()))
+	return "po:" + hex.EncodeToString(sum[:])
+}
+
+type streamLockTx struct {
+	conn *sql.Conn
+	name string
+}
+
+func (tx *streamLockTx) Commit() error {
+	if tx.conn == nil {
+		return nil
+	}
+	defer func() {
+		_ = tx.conn.Close()
+		tx.conn = nil
+	}()
+	_, err := tx.conn.ExecContext(context.Background(), releaseLock, tx.name)
+	return err
+}
+
+func (tx *streamLockTx) Rollback() error {
+	return tx.Commit()
+}
@@ -0,0 +1,98 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
+)
+
+// writes the notifications of the records in the transaction writing them
+func storeNotifications(ctx context.Context, tx dbtx, records []record.Record) error {
+	for _, r := range records {
+		_, err := tx.ExecContext(ctx, storeNotification, r.Stream.String(), r.Number, r.GlobalNumber)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pendingNotifications(ctx context.Context, conn dbtx, limit int) ([]store.Notification, error) {
+	rows, err := conn.QueryContext(ctx, claimNotifications, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var result []store.Notification
+	for rows.Next() {
+		var stream string
+		n := store.Notification{}
+		err = rows.Scan(&n.Id, &stream, &n.Number, &n.GlobalNumber, &n.Time)
+		if err != nil {
+			return nil, err
+		}
+		n.Stream = streams.ParseId(stream)
+		result = append(result, n)
+	}
+	return result, rows.Err()
+}
+
+// publishes the notifications in order until one fails, and returns the ids of the published
+func publishNotifications(notifications []store.Notification, publish func(n store.Notification) error) ([]int64, error) {
+	var published []int64
+	for _, n := range notifications {
+		err := publish(n)
+		if err != nil {
+			return published, err
+		}
+		published = append(published, n.Id)
+	}
+	return published, nil
+}
+
+// the claimed rows stay locked until the published are deleted, skipped by other relays meanwhile
+func relayNotifications(ctx context.Context, conn *sql.DB, limit int, publish func(n store.Notification) error) (int, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	notifications, err := pendingNotifications(ctx, tx, limit)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+	published, err := publishNotifications(notifications, publish)
+	deleteErr := completeNotifications(ctx, tx, published...)
+	if deleteErr != nil {
+		_ = tx.Rollback()
+		return len(notifications), deleteErr
+	}
+	commitErr := tx.Commit()
+	if err == nil {
+		err = commitErr
+	}
+	return len(notifications), err
+}
+
+func completeNotifications(ctx context.Context, conn dbtx, ids ...int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var args []interface{}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	_, err := conn.ExecContext(ctx, deleteNotifications(len(ids)), args...)
+	return err
+}
+
+func countNotifications(ctx context.Context, conn dbtx) (int64, error) {
+	var count int64
+	err := conn.QueryRowContext(ctx, countNotificationsQuery).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,11 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/go-po/po/internal/store/storetest"
+)
+
+func TestStorage_Outbox(t *testing.T) {
+	storetest.Outbox(t, &Storage{conn: databaseConnection(t)})
+}
@@ -0,0 +1,39 @@
+package mysql
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-po/po/streams"
+)
+
+func headPosition(ctx context.Context, conn dbtx, id streams.Id) (int64, error) {
+	if id.HasEntity() {
+		return streamPosition(ctx, conn, id)
+	}
+	var position int64
+	err := conn.QueryRowContext(ctx, getGroupPosition, id.Group).Scan(&position)
+	return position, err
+}
+
+func positionBefore(ctx context.Context, conn dbtx, id streams.Id, t time.Time) (int64, error) {
+	var position int64
+	var err error
+	if id.HasEntity() {
+		err = conn.QueryRowContext(ctx, getStreamPositionBefore, id.String(), t).Scan(&position)
+	} else {
+		err = conn.QueryRowContext(ctx, getGroupPositionBefore, id.Group, t).Scan(&position)
+	}
+	return position, err
+}
+
+func countRecords(ctx context.Context, conn dbtx, id streams.Id, after int64) (int64, error) {
+	var count int64
+	var err error
+	if id.HasEntity() {
+		err = conn.QueryRowContext(ctx, countRecordsByStream, id.String(), after).Scan(&count)
+	} else {
+		err = conn.QueryRowContext(ctx, countRecordsByGroup, id.Group, after).Scan(&count)
+	}
+	return count, err
+}
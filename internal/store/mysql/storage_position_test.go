@@ -0,0 +1,11 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/go-po/po/internal/store/storetest"
+)
+
+func TestStorage_Position(t *testing.T) {
+	storetest.Positions(t, &Storage{conn: databaseConnection(t)})
+}
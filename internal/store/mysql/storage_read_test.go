@@ -0,0 +1,150 @@
+package mysql
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorage_ReadRecords(t *testing.T) {
+	// setup
+	conn := databaseConnection(t)
+	ctx := context.Background()
+
+	t.Run("empty stream", func(t *testing.T) {
+		// setup
+		id := streamId("entity")
+		// execute
+		records, err := readRecords(ctx, conn, id, -1, math.MaxInt64, 100)
+		// verify
+		assert.NoError(t, err)
+		assert.Empty(t, records)
+	})
+
+	t.Run("empty group", func(t *testing.T) {
+		// setup
+		id := streamId("")
+		// execute
+		records, err := readRecords(ctx, conn, id, -1, math.MaxInt64, 100)
+		// verify
+		assert.NoError(t, err)
+		assert.Empty(t, records)
+	})
+
+	t.Run("mid stream", func(t *testing.T) {
+		// setup
+		id := streamId("entity")
+		_, err := writeRecords(ctx, conn, id, -1, data(10)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		records, err := readRecords(ctx, conn, id, 4, math.MaxInt64, 100)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 5, len(records)) {
+			assert.Equal(t, 5, int(records[0].Number))
+			assert.Equal(t, 6, int(records[1].Number))
+			assert.Equal(t, 7, int(records[2].Number))
+			assert.Equal(t, 8, int(records[3].Number))
+			assert.Equal(t, 9, int(records[4].Number))
+			assert.Equal(t, id.String(), records[0].Stream.String())
+		}
+	})
+
+	t.Run("mid group", func(t *testing.T) {
+		// setup
+		group := streamId("")
+		id1 := group.WithEntity("entity-1")
+		id2 := group.WithEntity("entity-2")
+		var i int64
+		var middle int64
+		for i = 0; i < 5; i++ {
+			r, err := writeRecords(ctx, conn, id1, i-1, data(1)...)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			if i == 2 {
+				// middle
+				middle = r[0].GlobalNumber
+			}
+			_, err = writeRecords(ctx, conn, id2, i-1, data(1)...)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+		}
+
+		t.Logf("MIDDLE: %d", middle)
+		// execute
+		records, err := readRecords(ctx, conn, group, middle, math.MaxInt64, 100)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 5, len(records)) {
+			assert.Equal(t, 4, int(records[3].Number))
+		}
+	})
+}
+
+func TestStorage_ReadRecordsOfTypes(t *testing.T) {
+	// setup
+	conn := databaseConnection(t)
+	ctx := context.Background()
+	group := streamId("")
+	id := group.WithEntity("entity")
+	var written []record.Record
+	for _, contentType := range []string{"a", "b", "a", "b", "b"} {
+		r, err := writeRecords(ctx, conn, id, store.AnyPosition, record.Data{ContentType: contentType, Data: []byte("{}")})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		written = append(written, r...)
+	}
+
+	t.Run("stream", func(t *testing.T) {
+		// execute
+		records, position, err := readRecordsOfTypes(ctx, conn, id, -1, math.MaxInt64, 100, []string{"a"})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), position)
+		if assert.Equal(t, 2, len(records)) {
+			assert.Equal(t, int64(0), records[0].Number)
+			assert.Equal(t, int64(2), records[1].Number)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		// execute
+		records, position, err := readRecordsOfTypes(ctx, conn, id, -1, math.MaxInt64, 2, []string{"b"})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), position)
+		if assert.Equal(t, 2, len(records)) {
+			assert.Equal(t, int64(1), records[0].Number)
+			assert.Equal(t, int64(3), records[1].Number)
+		}
+	})
+
+	t.Run("group", func(t *testing.T) {
+		// execute
+		records, position, err := readRecordsOfTypes(ctx, conn, group, written[0].GlobalNumber, math.MaxInt64, 100, []string{"a"})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, written[4].GlobalNumber, position)
+		if assert.Equal(t, 1, len(records)) {
+			assert.Equal(t, written[2].GlobalNumber, records[0].GlobalNumber)
+		}
+	})
+
+	t.Run("no records of the types", func(t *testing.T) {
+		// execute
+		records, position, err := readRecordsOfTypes(ctx, conn, id, 0, math.MaxInt64, 100, []string{"c"})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), position)
+		assert.Empty(t, records)
+	})
+}
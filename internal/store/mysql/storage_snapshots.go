@@ -0,0 +1,42 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/streams"
+)
+
+func readSnapshot(ctx context.Context, conn dbtx, id streams.Id, snapshotId string) (record.Snapshot, error) {
+	snapshot := record.Snapshot{}
+	err := conn.QueryRowContext(ctx, getSnapshotPosition, id.String(), snapshotId).
+		Scan(&snapshot.Position, &snapshot.ContentType, &snapshot.Data)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return record.Snapshot{
+				Data:        emptyJson,
+				Position:    -1,
+				ContentType: "application/json",
+			}, nil
+		}
+		return record.Snapshot{}, err
+	}
+	return snapshot, nil
+}
+
+func updateSnapshot(ctx context.Context, conn dbtx, id streams.Id, snapshotId string, snapshot record.Snapshot) error {
+	_, err := conn.ExecContext(ctx, updateSnapshotQuery,
+		id.String(),
+		snapshotId,
+		snapshot.Position,
+		snapshot.ContentType,
+		snapshot.Data,
+	)
+	return err
+}
+
+func deleteSnapshot(ctx context.Context, conn dbtx, id streams.Id, snapshotId string) error {
+	_, err := conn.ExecContext(ctx, deleteSnapshotQuery, id.String(), snapshotId)
+	return err
+}
@@ -0,0 +1,75 @@
+package mysql
+
+import (
+	"context"
+
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
+)
+
+func subscriberPositionLock(ctx context.Context, conn dbtx, id streams.Id, ids ...string) ([]store.SubscriptionPosition, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := []interface{}{id.String()}
+	for _, subscriptionId := range ids {
+		args = append(args, subscriptionId)
+	}
+	rows, err := conn.QueryContext(ctx, lockSubscriberPosition(len(ids)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var result []store.SubscriptionPosition
+	for rows.Next() {
+		pos := store.SubscriptionPosition{}
+		err = rows.Scan(&pos.SubscriptionId, &pos.Position)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, pos)
+	}
+	return result, rows.Err()
+}
+
+func updateSubscriberPosition(ctx context.Context, conn dbtx, id streams.Id, position store.SubscriptionPosition) error {
+	_, err := conn.ExecContext(ctx, setSubscriberPosition,
+		id.String(),
+		position.SubscriptionId,
+		position.Position,
+	)
+	return err
+}
+
+func moveSubscriberPosition(ctx context.Context, conn dbtx, id streams.Id, position store.SubscriptionPosition) error {
+	_, err := conn.ExecContext(ctx, moveSubscriberPositionQuery,
+		id.String(),
+		position.SubscriptionId,
+		position.Position,
+	)
+	return err
+}
+
+func subscriptions(ctx context.Context, conn dbtx) ([]store.Subscription, error) {
+	rows, err := conn.QueryContext(ctx, getSubscriptions)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var result []store.Subscription
+	for rows.Next() {
+		var stream string
+		subscription := store.Subscription{}
+		err = rows.Scan(&stream, &subscription.SubscriptionId, &subscription.Position, &subscription.Updated)
+		if err != nil {
+			return nil, err
+		}
+		subscription.Stream = streams.ParseId(stream)
+		result = append(result, subscription)
+	}
+	return result, rows.Err()
+}
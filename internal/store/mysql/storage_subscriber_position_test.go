@@ -0,0 +1,119 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-po/po/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorage_Subscriber(t *testing.T) {
+	// setup
+	conn := databaseConnection(t)
+	ctx := context.Background()
+
+	t.Run("update subscriber position", func(t *testing.T) {
+		// setup
+		tx, err := conn.Begin()
+		assert.NoError(t, err)
+		id := streamId("subscriberB")
+		// execute
+		err = updateSubscriberPosition(ctx, tx, id, store.SubscriptionPosition{
+			SubscriptionId: "B",
+			Position:       -1,
+		})
+		// verify
+		assert.NoError(t, tx.Commit())
+		assert.NoError(t, err)
+
+	})
+
+	t.Run("positions only move forward", func(t *testing.T) {
+		// setup
+		id := streamId("subscriberC")
+		for _, position := range []int64{-1, 5, 3} {
+			tx, err := conn.Begin()
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.NoError(t, updateSubscriberPosition(ctx, tx, id, store.SubscriptionPosition{
+				SubscriptionId: "C",
+				Position:       position,
+			}))
+			assert.NoError(t, tx.Commit())
+		}
+		tx, err := conn.Begin()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer func() {
+			_ = tx.Rollback()
+		}()
+		// execute
+		got, err := subscriberPositionLock(ctx, tx, id, "C", "D")
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, []store.SubscriptionPosition{{SubscriptionId: "C", Position: 5}}, got)
+	})
+
+	t.Run("list subscriptions", func(t *testing.T) {
+		// setup
+		id := streamId("subscriberL")
+		tx, err := conn.Begin()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.NoError(t, moveSubscriberPosition(ctx, tx, id, store.SubscriptionPosition{
+			SubscriptionId: "L",
+			Position:       3,
+		}))
+		assert.NoError(t, tx.Commit())
+		// execute
+		got, err := subscriptions(ctx, conn)
+		// verify
+		assert.NoError(t, err)
+		found := false
+		for _, subscription := range got {
+			if subscription.Stream == id && subscription.SubscriptionId == "L" {
+				found = true
+				assert.Equal(t, int64(3), subscription.Position)
+				assert.False(t, subscription.Updated.IsZero(), "updated")
+			}
+		}
+		assert.True(t, found, "listed")
+	})
+
+	t.Run("move subscriber position backwards", func(t *testing.T) {
+		// setup
+		id := streamId("subscriberM")
+		for _, position := range []int64{-1, 5} {
+			tx, err := conn.Begin()
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.NoError(t, updateSubscriberPosition(ctx, tx, id, store.SubscriptionPosition{
+				SubscriptionId: "M",
+				Position:       position,
+			}))
+			assert.NoError(t, tx.Commit())
+		}
+		tx, err := conn.Begin()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer func() {
+			_ = tx.Rollback()
+		}()
+		// execute
+		err = moveSubscriberPosition(ctx, tx, id, store.SubscriptionPosition{
+			SubscriptionId: "M",
+			Position:       2,
+		})
+		// verify
+		assert.NoError(t, err)
+		got, err := subscriberPositionLock(ctx, tx, id, "M")
+		assert.NoError(t, err)
+		assert.Equal(t, []store.SubscriptionPosition{{SubscriptionId: "M", Position: 2}}, got)
+	})
+}
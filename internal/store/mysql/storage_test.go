@@ -0,0 +1,67 @@
+package mysql
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+// the mysql service in docker-compose.yaml
+const databaseUrl = "po:po@tcp(localhost:3305)/po"
+
+func databaseConnection(t *testing.T) *sql.DB {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Helper()
+	cfg, err := mysql.ParseDSN(databaseUrl)
+	if !assert.NoError(t, err, "data source name") {
+		t.FailNow()
+	}
+	cfg.ParseTime = true
+	cfg.MultiStatements = true
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if !assert.NoError(t, err, "database connection") {
+		t.FailNow()
+	}
+	err = migrateDatabase(db)
+	if !assert.NoError(t, err, "database migration") {
+		t.FailNow()
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+func TestStorage_Close(t *testing.T) {
+	conn := databaseConnection(t)
+
+	t.Run("database of the caller", func(t *testing.T) {
+		// setup
+		s, err := NewFromConn(conn)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		err = s.Close()
+		// verify
+		assert.NoError(t, err)
+		assert.NoError(t, conn.Ping(), "left open")
+	})
+
+	t.Run("opened by the storage", func(t *testing.T) {
+		// setup
+		s, err := NewFromUrl(databaseUrl)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		err = s.Close()
+		// verify
+		assert.NoError(t, err)
+		assert.Error(t, s.conn.Ping(), "closed")
+	})
+}
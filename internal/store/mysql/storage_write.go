@@ -0,0 +1,235 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+)
+
+// used in place of a position to append to the end of the stream
+const endOfStream = store.AnyPosition
+
+// server error number of a duplicate key
+const errDuplicateEntry = 1062
+
+var emptyJson = []byte("{}")
+
+func writeRecords(ctx context.Context, conn database, id streams.Id, position int64, data ...record.Data) ([]record.Record, error) {
+	return writeBatch(ctx, conn, store.StreamWrite{Id: id, Position: position, Data: data})
+}
+
+// database written to, the *sql.DB of the storage or an outboxDB
+type database interface {
+	dbtx
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// database writing the notifications of the records to the outbox along with them
+type outboxDB struct {
+	*sql.DB
+}
+
+// writes the notifications of the records to the outbox along with them, if the database is an outboxDB
+func writeBatch(ctx context.Context, conn database, writes ...store.StreamWrite) ([]record.Record, error) {
+	records, err := writeBatchTx(ctx, conn, writes...)
+	if conflict, isConflict := err.(store.WriteConflictError); isConflict {
+		// look up the stream as committed, outside of the failed transaction
+		conflict.Current, err = streamPosition(ctx, conn, conflict.StreamId)
+		if err != nil {
+			return nil, err
+		}
+		if len(writes) == 1 {
+			// a concurrent append of the same messages might have won
+			records, err = deduplicate(ctx, conn, writes[0].Id, conflict.Current, writes[0].Data)
+			if records != nil || err != nil {
+				return records, err
+			}
+		}
+		return nil, conflict
+	}
+	return records, err
+}
+
+func writeBatchTx(ctx context.Context, conn database, writes ...store.StreamWrite) ([]record.Record, error) {
+	_, outbox := conn.(outboxDB)
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	err = lockGroups(ctx, tx, writes)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []record.Record
+	for _, write := range writes {
+		written, err := writeStream(ctx, tx, outbox, write)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, written...)
+	}
+
+	return records, tx.Commit()
+}
+
+// Ids are assigned when inserting, not when committing.
+// Serializing the writers of a group makes its messages commit in id order,
+// so a subscriber reading past an id never misses a lower one committed later.
+func lockGroups(ctx context.Context, tx dbtx, writes []store.StreamWrite) error {
+	var groups []string
+	seen := make(map[string]bool)
+	for _, write := range writes {
+		if len(write.Data) == 0 || seen[write.Id.Group] {
+			continue
+		}
+		seen[write.Id.Group] = true
+		groups = append(groups, write.Id.Group)
+	}
+	sort.Strings(groups) // to avoid deadlock
+	for _, group := range groups {
+		_, err := tx.ExecContext(ctx, lockGroup, group)
+		if err != nil {
+			return fmt.Errorf("lock group %s: %w", group, err)
+		}
+	}
+	return nil
+}
+
+func writeStream(ctx context.Context, tx dbtx, outbox bool, write store.StreamWrite) ([]record.Record, error) {
+	if len(write.Data) == 0 {
+		return nil, nil
+	}
+	current, err := streamPosition(ctx, tx, write.Id)
+	if err != nil {
+		return nil, err
+	}
+	written, err := deduplicate(ctx, tx, write.Id, current, write.Data)
+	if written != nil || err != nil {
+		return written, err
+	}
+	err = store.CheckPosition(write.Id, write.Position, current)
+	if err != nil {
+		return nil, err
+	}
+
+	position := current
+	var records []record.Record
+	for _, r := range write.Data {
+		stored, err := writeRecord(ctx, tx, write.Id, r, position+1)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, stored)
+		position = stored.Number
+	}
+	if outbox {
+		err = storeNotifications(ctx, tx, records)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+func streamPosition(ctx context.Context, conn dbtx, id streams.Id) (int64, error) {
+	var position int64
+	err := conn.QueryRowContext(ctx, getStreamPosition, id.String()).Scan(&position)
+	return position, err
+}
+
+// records already written with the message ids of data
+func deduplicate(ctx context.Context, conn dbtx, id streams.Id, current int64, data []record.Data) ([]record.Record, error) {
+	var args []interface{}
+	for _, messageId := range store.MessageIds(data) {
+		args = append(args, messageId)
+	}
+	if len(args) == 0 {
+		return nil, nil
+	}
+	rows, err := conn.QueryContext(ctx, readRecordsByMessageId(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := scanRecords(rows)
+	if err != nil {
+		return nil, err
+	}
+	return store.Deduplicate(id, current, data, stored)
+}
+
+func writeRecord(ctx context.Context, conn dbtx, id streams.Id, data record.Data, position int64) (record.Record, error) {
+	var err error
+	metadata := emptyJson
+	if len(data.Metadata) > 0 {
+		metadata, err = json.Marshal(data.Metadata)
+		if err != nil {
+			return record.Record{}, fmt.Errorf("metadata: %w", err)
+		}
+	}
+	messageId := uuid.New()
+	if data.MessageId != "" {
+		messageId, err = uuid.Parse(data.MessageId)
+		if err != nil {
+			return record.Record{}, fmt.Errorf("message id [%s]: %w", data.MessageId, err)
+		}
+	}
+	result, err := conn.ExecContext(ctx, storeRecord,
+		id.String(),
+		position,
+		id.Group,
+		data.ContentType,
+		data.Data,
+		nullString(data.CorrelationId),
+		nullString(data.CausationId),
+		metadata,
+		messageId.String(),
+	)
+	if err != nil {
+		if err, ok := err.(*mysql.MySQLError); ok {
+			if err.Number == errDuplicateEntry {
+				return record.Record{}, store.WriteConflictError{
+					StreamId: id,
+					Position: position,
+					Err:      err,
+				}
+			}
+		}
+		return record.Record{}, err
+	}
+	globalNumber, err := result.LastInsertId()
+	if err != nil {
+		return record.Record{}, err
+	}
+	rows, err := conn.QueryContext(ctx, readRecordById, globalNumber)
+	if err != nil {
+		return record.Record{}, err
+	}
+	stored, err := scanRecords(rows)
+	if err != nil {
+		return record.Record{}, err
+	}
+	if len(stored) != 1 {
+		return record.Record{}, fmt.Errorf("written record %d not found", globalNumber)
+	}
+	return stored[0], nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{
+		String: s,
+		Valid:  s != "",
+	}
+}
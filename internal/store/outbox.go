@@ -0,0 +1,16 @@
+package store
+
+import (
+	"time"
+
+	"github.com/go-po/po/streams"
+)
+
+// Announces a written record, kept in the outbox until published
+type Notification struct {
+	Id           int64
+	Stream       streams.Id
+	Number       int64
+	GlobalNumber int64
+	Time         time.Time // of the write
+}
@@ -0,0 +1,96 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: leases.sql
+
+package db
+
+import (
+	"context"
+)
+
+const acquireLease = `-- name: AcquireLease :many
+INSERT INTO po_leases (name, owner, expires)
+VALUES ($1, $2, NOW() + $3::bigint * INTERVAL '1 millisecond')
+ON CONFLICT (name) DO UPDATE
+    SET owner   = excluded.owner,
+        expires = excluded.expires
+WHERE po_leases.owner = excluded.owner
+   OR po_leases.expires < NOW()
+RETURNING owner
+`
+
+type AcquireLeaseParams struct {
+	Name    string `json:"name"`
+	Owner   string `json:"owner"`
+	Column3 int64  `json:""`
+}
+
+func (q *Queries) AcquireLease(ctx context.Context, arg AcquireLeaseParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, acquireLease, arg.Name, arg.Owner, arg.Column3)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var owner string
+		if err := rows.Scan(&owner); err != nil {
+			return nil, err
+		}
+		items = append(items, owner)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLeases = `-- name: GetLeases :many
+SELECT name, owner, expires
+FROM po_leases
+WHERE LEFT(name, LENGTH($1::varchar)) = $1::varchar
+  AND expires > NOW()
+ORDER BY name
+`
+
+func (q *Queries) GetLeases(ctx context.Context, prefix string) ([]PoLease, error) {
+	rows, err := q.db.QueryContext(ctx, getLeases, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PoLease
+	for rows.Next() {
+		var i PoLease
+		if err := rows.Scan(&i.Name, &i.Owner, &i.Expires); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const releaseLease = `-- name: ReleaseLease :exec
+DELETE
+FROM po_leases
+WHERE name = $1
+  AND owner = $2
+`
+
+type ReleaseLeaseParams struct {
+	Name  string `json:"name"`
+	Owner string `json:"owner"`
+}
+
+func (q *Queries) ReleaseLease(ctx context.Context, arg ReleaseLeaseParams) error {
+	_, err := q.db.ExecContext(ctx, releaseLease, arg.Name, arg.Owner)
+	return err
+}
@@ -0,0 +1,70 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: locks.sql
+
+package db
+
+import (
+	"context"
+)
+
+const getLockTimeout = `-- name: GetLockTimeout :one
+SELECT current_setting('lock_timeout')::text
+`
+
+func (q *Queries) GetLockTimeout(ctx context.Context) (string, error) {
+	row := q.db.QueryRowContext(ctx, getLockTimeout)
+	var column_1 string
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const lockStream = `-- name: LockStream :exec
+SELECT pg_advisory_xact_lock($1::int, hashtext($2::text))
+`
+
+type LockStreamParams struct {
+	Space  int32  `json:"space"`
+	Stream string `json:"stream"`
+}
+
+func (q *Queries) LockStream(ctx context.Context, arg LockStreamParams) error {
+	_, err := q.db.ExecContext(ctx, lockStream, arg.Space, arg.Stream)
+	return err
+}
+
+const lockStreamSession = `-- name: LockStreamSession :exec
+SELECT pg_advisory_lock($1::int, hashtext($2::text))
+`
+
+type LockStreamSessionParams struct {
+	Space  int32  `json:"space"`
+	Stream string `json:"stream"`
+}
+
+func (q *Queries) LockStreamSession(ctx context.Context, arg LockStreamSessionParams) error {
+	_, err := q.db.ExecContext(ctx, lockStreamSession, arg.Space, arg.Stream)
+	return err
+}
+
+const setLockTimeout = `-- name: SetLockTimeout :exec
+SELECT set_config('lock_timeout', $1::text, true)
+`
+
+func (q *Queries) SetLockTimeout(ctx context.Context, timeout string) error {
+	_, err := q.db.ExecContext(ctx, setLockTimeout, timeout)
+	return err
+}
+
+const unlockStreamSession = `-- name: UnlockStreamSession :exec
+SELECT pg_advisory_unlock($1::int, hashtext($2::text))
+`
+
+type UnlockStreamSessionParams struct {
+	Space  int32  `json:"space"`
+	Stream string `json:"stream"`
+}
+
+func (q *Queries) UnlockStreamSession(ctx context.Context, arg UnlockStreamSessionParams) error {
+	_, err := q.db.ExecContext(ctx, unlockStreamSession, arg.Space, arg.Stream)
+	return err
+}
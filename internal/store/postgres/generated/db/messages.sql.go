@@ -6,8 +6,86 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const countRecordsByGroup = `-- name: CountRecordsByGroup :one
+SELECT COUNT(*)
+FROM po_messages
+WHERE grp = $1
+  AND id > $2
+`
+
+type CountRecordsByGroupParams struct {
+	Grp string `json:"grp"`
+	ID  int64  `json:"id"`
+}
+
+func (q *Queries) CountRecordsByGroup(ctx context.Context, arg CountRecordsByGroupParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countRecordsByGroup, arg.Grp, arg.ID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countRecordsByStream = `-- name: CountRecordsByStream :one
+SELECT COUNT(*)
+FROM po_messages
+WHERE stream = $1
+  AND no > $2
+`
+
+type CountRecordsByStreamParams struct {
+	Stream string `json:"stream"`
+	No     int64  `json:"no"`
+}
+
+func (q *Queries) CountRecordsByStream(ctx context.Context, arg CountRecordsByStreamParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countRecordsByStream, arg.Stream, arg.No)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getGroupPosition = `-- name: GetGroupPosition :one
+SELECT GREATEST(MAX(id), -1)::bigint
+FROM po_messages
+WHERE grp = $1
+  AND (tx_id < txid_snapshot_xmin(txid_current_snapshot())
+    OR tx_id = txid_current_if_assigned())
+`
+
+// held back like ReadRecordsByGroup, so no record below the position is still to become visible
+func (q *Queries) GetGroupPosition(ctx context.Context, grp string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getGroupPosition, grp)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const getGroupPositionBefore = `-- name: GetGroupPositionBefore :one
+SELECT GREATEST(MAX(id), -1)::bigint
+FROM po_messages
+WHERE grp = $1
+  AND created < $2
+`
+
+type GetGroupPositionBeforeParams struct {
+	Grp     string    `json:"grp"`
+	Created time.Time `json:"created"`
+}
+
+func (q *Queries) GetGroupPositionBefore(ctx context.Context, arg GetGroupPositionBeforeParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getGroupPositionBefore, arg.Grp, arg.Created)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
 const getStreamPosition = `-- name: GetStreamPosition :one
 SELECT GREATEST(MAX(no), -1)::bigint
 FROM po_messages
@@ -21,12 +99,54 @@ func (q *Queries) GetStreamPosition(ctx context.Context, stream string) (int64,
 	return column_1, err
 }
 
+const getStreamPositionBefore = `-- name: GetStreamPositionBefore :one
+SELECT GREATEST(MAX(no), -1)::bigint
+FROM po_messages
+WHERE stream = $1
+  AND created < $2
+`
+
+type GetStreamPositionBeforeParams struct {
+	Stream  string    `json:"stream"`
+	Created time.Time `json:"created"`
+}
+
+func (q *Queries) GetStreamPositionBefore(ctx context.Context, arg GetStreamPositionBeforeParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getStreamPositionBefore, arg.Stream, arg.Created)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const hasHeldBackRecords = `-- name: HasHeldBackRecords :one
+SELECT EXISTS(SELECT 1
+              FROM po_messages
+              WHERE grp = $1
+                AND id > $2
+                AND tx_id >= txid_snapshot_xmin(txid_current_snapshot()))::bool
+`
+
+type HasHeldBackRecordsParams struct {
+	Grp string `json:"grp"`
+	ID  int64  `json:"id"`
+}
+
+// committed messages after the position held back by ReadRecordsByGroup
+func (q *Queries) HasHeldBackRecords(ctx context.Context, arg HasHeldBackRecordsParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, hasHeldBackRecords, arg.Grp, arg.ID)
+	var column_1 bool
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
 const readRecordsByGroup = `-- name: ReadRecordsByGroup :many
-SELECT id, created, stream, no, grp, content_type, data, correlation_id
+SELECT id, created, stream, no, grp, content_type, data, correlation_id, causation_id, metadata, message_id, tx_id
 FROM po_messages
 WHERE grp = $1
   AND id > $2
   AND id <= $3
+  AND (tx_id < txid_snapshot_xmin(txid_current_snapshot())
+    OR tx_id = txid_current_if_assigned())
 ORDER BY id ASC
 LIMIT $4
 `
@@ -38,6 +158,10 @@ type ReadRecordsByGroupParams struct {
 	Limit int32  `json:"limit"`
 }
 
+// ids are assigned before commit, so messages written by transactions
+// newer than the oldest one still running are held back,
+// as a lower id might still become visible.
+// The messages written by the transaction reading are its own to see.
 func (q *Queries) ReadRecordsByGroup(ctx context.Context, arg ReadRecordsByGroupParams) ([]PoMessage, error) {
 	rows, err := q.db.QueryContext(ctx, readRecordsByGroup,
 		arg.Grp,
@@ -61,6 +185,117 @@ func (q *Queries) ReadRecordsByGroup(ctx context.Context, arg ReadRecordsByGroup
 			&i.ContentType,
 			&i.Data,
 			&i.CorrelationID,
+			&i.CausationID,
+			&i.Metadata,
+			&i.MessageID,
+			&i.TxID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readRecordsByGroupOfTypes = `-- name: ReadRecordsByGroupOfTypes :many
+SELECT id, created, stream, no, grp, content_type, data, correlation_id, causation_id, metadata, message_id, tx_id
+FROM po_messages
+WHERE grp = $1
+  AND id > $2
+  AND id <= $3
+  AND content_type = ANY ($4::text[])
+  AND (tx_id < txid_snapshot_xmin(txid_current_snapshot())
+    OR tx_id = txid_current_if_assigned())
+ORDER BY id ASC
+LIMIT $5
+`
+
+type ReadRecordsByGroupOfTypesParams struct {
+	Grp          string   `json:"grp"`
+	FromID       int64    `json:"from_id"`
+	ToID         int64    `json:"to_id"`
+	ContentTypes []string `json:"content_types"`
+	MaxRecords   int32    `json:"max_records"`
+}
+
+// held back like ReadRecordsByGroup
+func (q *Queries) ReadRecordsByGroupOfTypes(ctx context.Context, arg ReadRecordsByGroupOfTypesParams) ([]PoMessage, error) {
+	rows, err := q.db.QueryContext(ctx, readRecordsByGroupOfTypes,
+		arg.Grp,
+		arg.FromID,
+		arg.ToID,
+		pq.Array(arg.ContentTypes),
+		arg.MaxRecords,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PoMessage
+	for rows.Next() {
+		var i PoMessage
+		if err := rows.Scan(
+			&i.ID,
+			&i.Created,
+			&i.Stream,
+			&i.No,
+			&i.Grp,
+			&i.ContentType,
+			&i.Data,
+			&i.CorrelationID,
+			&i.CausationID,
+			&i.Metadata,
+			&i.MessageID,
+			&i.TxID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readRecordsByMessageId = `-- name: ReadRecordsByMessageId :many
+SELECT id, created, stream, no, grp, content_type, data, correlation_id, causation_id, metadata, message_id, tx_id
+FROM po_messages
+WHERE message_id = ANY ($1::uuid[])
+ORDER BY id ASC
+`
+
+func (q *Queries) ReadRecordsByMessageId(ctx context.Context, messageID []uuid.UUID) ([]PoMessage, error) {
+	rows, err := q.db.QueryContext(ctx, readRecordsByMessageId, pq.Array(messageID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PoMessage
+	for rows.Next() {
+		var i PoMessage
+		if err := rows.Scan(
+			&i.ID,
+			&i.Created,
+			&i.Stream,
+			&i.No,
+			&i.Grp,
+			&i.ContentType,
+			&i.Data,
+			&i.CorrelationID,
+			&i.CausationID,
+			&i.Metadata,
+			&i.MessageID,
+			&i.TxID,
 		); err != nil {
 			return nil, err
 		}
@@ -76,7 +311,7 @@ func (q *Queries) ReadRecordsByGroup(ctx context.Context, arg ReadRecordsByGroup
 }
 
 const readRecordsByStream = `-- name: ReadRecordsByStream :many
-SELECT id, created, stream, no, grp, content_type, data, correlation_id
+SELECT id, created, stream, no, grp, content_type, data, correlation_id, causation_id, metadata, message_id, tx_id
 FROM po_messages
 WHERE stream = $1
   AND no > $2
@@ -115,6 +350,71 @@ func (q *Queries) ReadRecordsByStream(ctx context.Context, arg ReadRecordsByStre
 			&i.ContentType,
 			&i.Data,
 			&i.CorrelationID,
+			&i.CausationID,
+			&i.Metadata,
+			&i.MessageID,
+			&i.TxID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readRecordsByStreamOfTypes = `-- name: ReadRecordsByStreamOfTypes :many
+SELECT id, created, stream, no, grp, content_type, data, correlation_id, causation_id, metadata, message_id, tx_id
+FROM po_messages
+WHERE stream = $1
+  AND no > $2
+  AND no <= $3
+  AND content_type = ANY ($4::text[])
+ORDER BY no ASC
+LIMIT $5
+`
+
+type ReadRecordsByStreamOfTypesParams struct {
+	Stream       string   `json:"stream"`
+	FromNo       int64    `json:"from_no"`
+	ToNo         int64    `json:"to_no"`
+	ContentTypes []string `json:"content_types"`
+	MaxRecords   int32    `json:"max_records"`
+}
+
+func (q *Queries) ReadRecordsByStreamOfTypes(ctx context.Context, arg ReadRecordsByStreamOfTypesParams) ([]PoMessage, error) {
+	rows, err := q.db.QueryContext(ctx, readRecordsByStreamOfTypes,
+		arg.Stream,
+		arg.FromNo,
+		arg.ToNo,
+		pq.Array(arg.ContentTypes),
+		arg.MaxRecords,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PoMessage
+	for rows.Next() {
+		var i PoMessage
+		if err := rows.Scan(
+			&i.ID,
+			&i.Created,
+			&i.Stream,
+			&i.No,
+			&i.Grp,
+			&i.ContentType,
+			&i.Data,
+			&i.CorrelationID,
+			&i.CausationID,
+			&i.Metadata,
+			&i.MessageID,
+			&i.TxID,
 		); err != nil {
 			return nil, err
 		}
@@ -130,18 +430,21 @@ func (q *Queries) ReadRecordsByStream(ctx context.Context, arg ReadRecordsByStre
 }
 
 const storeRecord = `-- name: StoreRecord :one
-INSERT INTO po_messages (stream, no, grp, content_type, data, correlation_id)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, created, stream, no, grp, content_type, data, correlation_id
+INSERT INTO po_messages (stream, no, grp, content_type, data, correlation_id, causation_id, metadata, message_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, created, stream, no, grp, content_type, data, correlation_id, causation_id, metadata, message_id, tx_id
 `
 
 type StoreRecordParams struct {
-	Stream        string         `json:"stream"`
-	No            int64          `json:"no"`
-	Grp           string         `json:"grp"`
-	ContentType   string         `json:"content_type"`
-	Data          []byte         `json:"data"`
-	CorrelationID sql.NullString `json:"correlation_id"`
+	Stream        string          `json:"stream"`
+	No            int64           `json:"no"`
+	Grp           string          `json:"grp"`
+	ContentType   string          `json:"content_type"`
+	Data          []byte          `json:"data"`
+	CorrelationID sql.NullString  `json:"correlation_id"`
+	CausationID   sql.NullString  `json:"causation_id"`
+	Metadata      json.RawMessage `json:"metadata"`
+	MessageID     uuid.UUID       `json:"message_id"`
 }
 
 func (q *Queries) StoreRecord(ctx context.Context, arg StoreRecordParams) (PoMessage, error) {
@@ -152,6 +455,9 @@ func (q *Queries) StoreRecord(ctx context.Context, arg StoreRecordParams) (PoMes
 		arg.ContentType,
 		arg.Data,
 		arg.CorrelationID,
+		arg.CausationID,
+		arg.Metadata,
+		arg.MessageID,
 	)
 	var i PoMessage
 	err := row.Scan(
@@ -163,6 +469,10 @@ func (q *Queries) StoreRecord(ctx context.Context, arg StoreRecordParams) (PoMes
 		&i.ContentType,
 		&i.Data,
 		&i.CorrelationID,
+		&i.CausationID,
+		&i.Metadata,
+		&i.MessageID,
+		&i.TxID,
 	)
 	return i, err
 }
@@ -25,7 +25,7 @@ func bindata_read(data []byte, name string) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-var __1_create_records_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x01\x00\x00\xff\xff\x00\x00\x00\x00\x00\x00\x00\x00")
+var __1_create_records_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x03\x00\x00\x00\x00\x00\x00\x00\x00\x00")
 
 func _1_create_records_down_sql() ([]byte, error) {
 	return bindata_read(
@@ -34,7 +34,7 @@ func _1_create_records_down_sql() ([]byte, error) {
 	)
 }
 
-var __1_create_records_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x95\x41\x6f\x9b\x30\x14\xc7\xef\x7c\x8a\x77\x6b\x90\x1a\x69\x3b\xe7\x44\x5b\xda\xa2\xa5\x64\x4b\xc9\xda\xee\x82\x0c\xbc\x80\xa5\x60\x5b\xb6\xd9\xd6\x7d\xfa\x09\x12\x0c\x34\x40\x69\x52\x6e\x96\xed\x1f\xff\x67\xff\x1e\xcc\xe7\x40\x19\xd5\x94\xec\x40\xc5\x19\xe6\x04\xb6\x5c\x82\xce\x10\x72\x54\x8a\xa4\xa8\xac\xeb\xb5\xeb\x04\x2e\x04\xce\xd5\xd2\x05\xef\x16\xfc\x55\x00\xee\xb3\xf7\x18\x3c\x82\xe0\xa1\x59\x36\xb3\x00\x00\x68\x02\xed\x27\xa2\xa9\x42\x59\xd2\x47\x9f\x12\xe9\x6f\x96\xcb\xcb\x8a\x11\x4b\x24\x1a\x0d\x48\xd3\x1c\x95\x26\xb9\x80\x27\x2f\xb8\x87\xc0\x7b\x70\xe1\xd7\xca\x77\xe1\xc6\xbd\x75\x36\xcb\x00\xfc\xd5\xd3\xcc\x7e\xc3\x50\x5a\x22\xc9\xcd\x0b\x7e\x3a\xeb\xeb\x7b\x67\x3d\x9e\xe2\x6d\x0e\xc6\x3b\x93\x57\xde\x9d\xe7\x07\x3d\xbb\xea\x1c\x5f\x7a\x18\xa9\x14\xed\xa5\xbf\x89\x8c\x33\x22\x27\xe7\x80\xf9\x1c\x52\xc9\x0b\x01\x94\xc1\x1d\xdf\x1f\x0f\x67\x1a\x99\x0e\xf5\xab\xc0\x13\x90\x15\x23\x21\x9a\xb4\x26\xa3\x57\x8d\xe4\x3d\xc2\xd1\x35\x71\x29\x71\x47\x34\xe5\x2c\xa4\xc9\xe4\x1c\x66\xff\xf7\xb5\xf7\xe0\xac\x5f\xe0\x9b\xfb\x02\x33\x9a\xd8\x96\xbd\xb0\xac\x98\xe7\x39\x32\x0d\x9c\x81\x26\xd1\x0e\xdb\x8a\x01\x55\x70\x51\x56\x4f\x28\x53\xc6\xcf\x8b\x85\x55\x2b\xea\xf9\x37\xee\xf3\xb0\xa2\xe1\x5e\x8a\x90\xb2\x04\xff\xc2\xca\xef\xb0\x67\xfb\x49\x7b\x31\x15\x96\x4a\x31\x40\x4a\xa5\x68\x30\x1b\xdf\xfb\xb1\x99\x1c\x8d\x15\x79\x84\x32\x2c\xc6\x22\x5e\x02\xe3\x76\x53\xf4\x40\x5f\xaa\x22\x52\xb1\xa4\xa2\xbc\x9e\xba\x39\xbb\x8d\xd5\xf4\xd5\x1f\xaa\xb3\x6a\x08\xff\x38\x43\x48\x70\x4b\x8a\x9d\xee\xed\xab\x42\x24\xe7\x22\xba\xad\x79\x92\xbe\x87\xe2\xca\xa3\xfa\x80\x79\x23\xcd\x1d\xd1\x94\x32\xfd\x1e\xc1\x20\x06\x55\xed\x9c\x7a\xe5\xab\xe0\x8a\x96\x23\xe0\x5b\x20\x75\xed\x4d\xfe\xb6\xbe\xe3\xaa\x74\xd0\xb5\x2f\x9d\x83\x38\x68\x53\x95\xc7\xd9\x71\x1c\xe3\x4f\x67\xd7\x04\x95\x18\x11\x2a\xe3\xba\x57\xa3\xf3\x2d\x3a\x5f\xa2\xd3\x1c\x3a\x54\x55\x1a\xf4\x09\x0a\x0d\x1a\x54\xff\x1d\xe6\x5f\x8f\x09\x9d\xcf\xf0\x89\x1f\xe1\xd6\xcf\xe0\x83\x55\x0c\x5b\x5c\x5f\x78\x65\x70\x3d\x02\xa3\x32\x61\x49\x15\x7d\xba\xbb\x35\xd0\x78\xdb\x1c\x7e\x8f\xb5\xe6\xf5\x8d\xb1\xcd\x7a\x7b\x61\xfd\x0f\x00\x00\xff\xff\x59\xca\xd0\xc2\xb5\x08\x00\x00")
+var __1_create_records_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x95\x4f\x6f\x9b\x30\x14\xc0\xef\x7c\x8a\x77\x6b\x90\x1a\x69\x3b\xe7\x44\x5b\xda\xa2\xa5\x64\x4b\xc9\xda\xee\x82\x0c\x76\xe1\x49\xc1\xb6\x6c\xb3\xad\xfb\xf4\x13\x34\xe6\xcf\x06\x09\x23\x33\xa7\x07\xe6\xa7\xf7\xec\xdf\xb3\x97\x4b\x40\x8e\x06\xc9\x1e\x74\x9a\xb3\x82\xc0\xab\x50\x60\x72\x06\x05\xd3\x9a\x64\x4c\x3b\xd7\x5b\xdf\x8b\x7c\x88\xbc\xab\xb5\x0f\xc1\x2d\x84\x9b\x08\xfc\xe7\xe0\x31\x7a\x04\x29\xe2\x66\xda\xc2\x01\x00\x40\x0a\xdd\x91\x60\xa6\x99\xaa\xe8\x47\x47\x85\x0c\x77\xeb\xf5\x65\xcd\x48\x15\x23\x86\x35\x20\x83\x05\xd3\x86\x14\x12\x9e\x82\xe8\x1e\xa2\xe0\xc1\x87\x6f\x9b\xd0\x87\x1b\xff\xd6\xdb\xad\x23\x08\x37\x4f\x0b\xf7\x0f\x86\x36\x8a\x91\xc2\x22\xe0\xab\xb7\xbd\xbe\xf7\xb6\x36\x9c\x96\x07\x17\xf6\x7d\xfd\x5c\x05\x77\x41\x18\xd9\xa8\x33\x6c\x1e\x1f\x06\x18\x99\x92\x76\x5a\xf5\x7c\x27\x2a\xcd\x89\xb2\xe1\xc9\x3c\x60\xb9\x84\x4c\x89\x52\x02\x72\xb8\x13\x35\x32\x15\xdc\x30\x6e\x62\xf3\x26\xd9\x0c\x64\xcd\xa0\xc4\x10\xfb\xa5\xda\xa6\x37\xc3\xba\xf1\x24\x46\x2a\x94\x62\x7b\x62\x50\xf0\x18\xe9\xe4\x3c\x9a\xff\x3f\x6f\x83\x07\x6f\xfb\x02\x9f\xfc\x17\x58\x20\x75\x1d\x77\xe5\x38\xa9\x28\x0a\xc6\x0d\x08\x0e\x86\x24\x7b\xd6\x55\x0c\x50\xc3\x45\x55\x3d\x41\xae\x1b\x3f\x2f\x56\x8e\x55\x34\x08\x6f\xfc\xe7\x71\x45\xe3\x77\x29\x62\xe4\x94\xfd\x84\x4d\xd8\x63\x2f\xde\x3f\xba\xab\xa9\xb0\x4c\xc9\x11\x52\xa6\x64\x8b\xd9\x85\xc1\x97\xdd\xe4\xd4\x78\x59\x24\x4c\xc5\xe5\xb1\x14\x2f\x81\x0b\x77\xe5\x9c\xe8\x4b\x5d\x26\x3a\x55\x28\xab\xed\xd1\xce\x62\xa0\xb1\xda\xbe\xfa\x81\x26\xaf\x43\xf8\x25\x38\x03\xca\x5e\x49\xb9\x37\x83\x7d\x55\x4a\x7a\x2e\xa2\xdf\x9a\xb3\xf4\x3d\x14\x57\x2d\x15\xd2\x79\x88\x7e\x73\x27\x98\x21\x37\x36\x3a\x89\x18\x55\xb5\xb7\xea\xb5\xaf\x52\x68\xac\x22\x10\xaf\x40\x6c\xed\x6d\xfe\x5d\x7d\x8f\xab\xd2\x43\x5b\x5f\x5a\x50\x8c\xf4\xa0\x4d\xbd\x42\x82\xff\x9d\x4e\xe3\x4f\xef\xaf\x09\x2a\x71\x22\x75\x2e\xcc\xa0\x46\xe7\x5b\x74\xbe\x44\xf3\x1c\x3a\x54\x55\x9d\x5d\xff\x41\xa1\x51\x83\xec\xed\xb0\xfc\x78\xe2\x18\x9e\x79\x08\x77\x2e\x83\x7f\xac\x62\xdc\x62\xbb\xe1\xb5\xc1\x36\x82\x46\x65\xc2\x69\x9d\xfa\x74\x77\x2d\xb0\xf1\xf6\xf0\x62\xd8\x5a\x3b\xbb\x63\x6c\x3b\xdf\x5d\x39\xbf\x07\x00\x59\xca\xd0\xc2\xb5\x08\x00\x00")
 
 func _1_create_records_up_sql() ([]byte, error) {
 	return bindata_read(
@@ -43,6 +43,114 @@ func _1_create_records_up_sql() ([]byte, error) {
 	)
 }
 
+var __2_causation_id_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x40\x00\xbf\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x70\x6f\x5f\x6d\x65\x73\x73\x61\x67\x65\x73\x0a\x20\x20\x20\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x63\x61\x75\x73\x61\x74\x69\x6f\x6e\x5f\x69\x64\x3b\x0a\x03\x00\x51\x4a\x4b\x02\x40\x00\x00\x00")
+
+func _2_causation_id_down_sql() ([]byte, error) {
+	return bindata_read(
+		__2_causation_id_down_sql,
+		"2_causation_id.down.sql",
+	)
+}
+
+var __2_causation_id_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x6f\x00\x90\xff\x2d\x2d\x20\x69\x64\x20\x6f\x66\x20\x77\x68\x61\x74\x20\x63\x61\x75\x73\x65\x64\x20\x61\x20\x6d\x65\x73\x73\x61\x67\x65\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x70\x6f\x5f\x6d\x65\x73\x73\x61\x67\x65\x73\x0a\x20\x20\x20\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x4e\x4f\x54\x20\x45\x58\x49\x53\x54\x53\x20\x63\x61\x75\x73\x61\x74\x69\x6f\x6e\x5f\x69\x64\x20\x76\x61\x72\x63\x68\x61\x72\x20\x4e\x55\x4c\x4c\x3b\x0a\x03\x00\xd4\xe5\xe0\x9b\x6f\x00\x00\x00")
+
+func _2_causation_id_up_sql() ([]byte, error) {
+	return bindata_read(
+		__2_causation_id_up_sql,
+		"2_causation_id.up.sql",
+	)
+}
+
+var __3_metadata_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x3c\x00\xc3\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x70\x6f\x5f\x6d\x65\x73\x73\x61\x67\x65\x73\x0a\x20\x20\x20\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x6d\x65\x74\x61\x64\x61\x74\x61\x3b\x0a\x03\x00\x01\x51\xa0\x90\x3c\x00\x00\x00")
+
+func _3_metadata_down_sql() ([]byte, error) {
+	return bindata_read(
+		__3_metadata_down_sql,
+		"3_metadata.down.sql",
+	)
+}
+
+var __3_metadata_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x81\x00\x7e\xff\x2d\x2d\x20\x76\x61\x6c\x75\x65\x73\x20\x73\x74\x6f\x72\x65\x64\x20\x61\x6c\x6f\x6e\x67\x20\x77\x69\x74\x68\x20\x61\x20\x6d\x65\x73\x73\x61\x67\x65\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x70\x6f\x5f\x6d\x65\x73\x73\x61\x67\x65\x73\x0a\x20\x20\x20\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x4e\x4f\x54\x20\x45\x58\x49\x53\x54\x53\x20\x6d\x65\x74\x61\x64\x61\x74\x61\x20\x6a\x73\x6f\x6e\x62\x20\x44\x45\x46\x41\x55\x4c\x54\x20\x27\x7b\x7d\x27\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x3b\x0a\x03\x00\xbb\x16\xed\xcf\x81\x00\x00\x00")
+
+func _3_metadata_up_sql() ([]byte, error) {
+	return bindata_read(
+		__3_metadata_up_sql,
+		"3_metadata.up.sql",
+	)
+}
+
+var __4_message_id_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x73\x00\x8c\xff\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x70\x6f\x5f\x6d\x65\x73\x73\x61\x67\x65\x73\x5f\x6d\x65\x73\x73\x61\x67\x65\x5f\x69\x64\x5f\x75\x69\x6e\x64\x65\x78\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x70\x6f\x5f\x6d\x65\x73\x73\x61\x67\x65\x73\x0a\x20\x20\x20\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x6d\x65\x73\x73\x61\x67\x65\x5f\x69\x64\x3b\x0a\x03\x00\x9d\xbe\x2e\x37\x73\x00\x00\x00")
+
+func _4_message_id_down_sql() ([]byte, error) {
+	return bindata_read(
+		__4_message_id_down_sql,
+		"4_message_id.down.sql",
+	)
+}
+
+var __4_message_id_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x5c\x8e\xb1\x6a\xc3\x30\x18\x84\x77\x3d\xc5\x8d\x2d\xd4\x4f\xe0\x49\xb5\x55\x10\xa8\x32\xb5\x25\xf0\x26\x44\xff\x3f\x41\x90\xd8\x0a\xb2\x20\x8f\x9f\x25\x26\x26\xdb\x0d\xf7\xdd\x7d\x4d\x83\xba\xa4\x5b\x65\x24\xc2\x7a\x42\xc4\x95\x4b\x89\x67\xfe\x42\x2d\x4c\xd8\x56\x10\x53\xcd\x97\xf4\x1f\x37\x46\xcc\x99\x17\x2a\x42\x1a\xa7\x46\x38\xf9\x6d\x14\xf2\x1a\x9e\x4c\x11\x00\x20\xfb\x1e\xdd\x60\xfc\xaf\x85\xfe\x81\x1d\x1c\xd4\xac\x27\x37\xed\xcb\x21\x11\x6a\x4d\x04\xeb\x8d\x69\x85\xe8\x46\x25\x9d\x82\xb7\xfa\xcf\x2b\x68\xdb\xab\xf9\x0d\x3c\x3c\xec\x21\x24\x0a\x35\x2d\xc4\x77\x0c\xf6\xa8\x80\x8f\x57\xe3\xb3\x15\x8f\x01\x00\x5b\x2a\x10\x37\xe0\x00\x00\x00")
+
+func _4_message_id_up_sql() ([]byte, error) {
+	return bindata_read(
+		__4_message_id_up_sql,
+		"4_message_id.up.sql",
+	)
+}
+
+var __5_tx_id_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x39\x00\xc6\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x70\x6f\x5f\x6d\x65\x73\x73\x61\x67\x65\x73\x0a\x20\x20\x20\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x74\x78\x5f\x69\x64\x3b\x0a\x03\x00\x12\xa8\x50\x8f\x39\x00\x00\x00")
+
+func _5_tx_id_down_sql() ([]byte, error) {
+	return bindata_read(
+		__5_tx_id_down_sql,
+		"5_tx_id.down.sql",
+	)
+}
+
+var __5_tx_id_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x54\xcc\x31\x4e\xc3\x30\x14\x80\xe1\x3d\xa7\xf8\x47\x90\xc8\x09\x98\x02\x49\xa5\x4a\x26\x91\xa8\x23\xb1\x45\x6e\xfc\x48\x2c\x82\x5d\xd9\x2f\xa5\xdc\x9e\xa9\x03\xfb\xa7\xaf\xae\xd1\xec\x62\x71\xb3\x86\x14\xf9\xc9\x41\x43\x5c\xd0\x55\xf8\x96\x52\xdc\x22\x4f\xec\x45\x3c\x9a\x58\xd3\xe6\x39\xbb\xf9\x8b\x25\xa7\xfd\x42\x16\xe7\x4b\x55\xd7\xa4\xcf\x3b\x2e\xec\x51\xc3\x86\x5c\x25\xff\xfe\x9b\xd3\xe6\x25\xa3\xab\x8b\x04\x65\x75\x57\x41\xa2\x17\x5f\x35\xc6\x76\xef\xd8\xe6\xc5\x74\x5c\xd2\x74\x8f\x2a\x80\xa6\x6d\x79\x1d\xcc\xf8\xd6\x73\x3c\xd0\x0f\x96\xee\xe3\x78\xb2\x27\xf4\x36\x05\xcf\x39\x2c\x21\x2a\x6d\x77\x68\x46\x63\xd1\x5b\xf0\xd3\xbc\xe7\x2c\x51\x1f\x1e\xe9\x07\x4b\x3f\x1a\xf3\x5c\xfd\x0d\x00\xe6\x44\x9d\x65\xe5\x00\x00\x00")
+
+func _5_tx_id_up_sql() ([]byte, error) {
+	return bindata_read(
+		__5_tx_id_up_sql,
+		"5_tx_id.up.sql",
+	)
+}
+
+var __6_leases_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x20\x00\xdf\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x70\x6f\x5f\x6c\x65\x61\x73\x65\x73\x3b\x0a\x03\x00\xb5\x16\x64\xaf\x20\x00\x00\x00")
+
+func _6_leases_down_sql() ([]byte, error) {
+	return bindata_read(
+		__6_leases_down_sql,
+		"6_leases.down.sql",
+	)
+}
+
+var __6_leases_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x8d\xcd\x4a\xc4\x30\x14\x85\xf7\x79\x8a\xb3\x54\x98\x99\x17\x70\x15\x87\xc8\x04\xdb\x4e\x49\xe3\x4f\xdd\x48\x5a\xaf\x36\x50\x93\x90\x1b\x51\xdf\x5e\xda\x82\x1b\x37\xde\xcd\xe5\x1c\xbe\x8f\xb3\xdf\x63\x26\xc7\xc4\x98\x68\x7e\xc1\xf0\x0d\x1f\xb8\xb8\x30\x12\xef\x40\x87\xb7\x03\x62\x40\x99\x08\xc9\xe5\xe2\x8b\x8f\x81\x11\x5f\xe1\xc0\x1f\x03\x8f\xd9\xa7\xa5\x12\x47\xa3\xa4\x55\xb0\xf2\xba\x52\xd0\x37\x68\xce\x16\xea\x51\x77\xb6\x43\x8a\xcf\xdb\x80\xb8\x10\x00\x10\xdc\x3b\x2d\xff\x5e\x9a\xe3\x49\x1a\xfc\xb9\xc5\x6d\xee\xaa\x0a\xad\xd1\xb5\x34\x3d\x6e\x55\xbf\x5b\xd5\xf8\x19\x28\xff\x47\xdd\x70\xfa\x4a\x3e\x13\xc3\xea\x5a\x75\x56\xd6\x2d\x1e\xb4\x3d\xad\x11\x4f\xe7\x46\xfd\xe2\xe2\xf2\x4a\xfc\x0c\x00\x62\x55\x7f\xe1\x08\x01\x00\x00")
+
+func _6_leases_up_sql() ([]byte, error) {
+	return bindata_read(
+		__6_leases_up_sql,
+		"6_leases.up.sql",
+	)
+}
+
+var __7_outbox_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x20\x00\xdf\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x70\x6f\x5f\x6f\x75\x74\x62\x6f\x78\x3b\x0a\x03\x00\x07\xaf\x46\x2b\x20\x00\x00\x00")
+
+func _7_outbox_down_sql() ([]byte, error) {
+	return bindata_read(
+		__7_outbox_down_sql,
+		"7_outbox.down.sql",
+	)
+}
+
+var __7_outbox_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\xcf\xcd\x4e\x02\x31\x14\x05\xe0\xfd\x3c\xc5\x59\x42\x02\x4f\xe0\xaa\x83\x05\x1a\xe7\x87\x74\x8a\x88\x1b\x32\x3f\x05\x9a\xd4\xde\x49\xa7\x13\xe5\xed\xcd\xa0\x75\x63\x4c\x3c\xab\x7b\x4e\xf2\x2d\xee\x72\x09\x47\xc1\x9c\x4d\x5b\x07\x43\x6e\x00\x9d\xf1\xee\x4d\x08\xda\xc1\xeb\x96\x7c\x37\x2c\xd0\x6b\xd7\x19\x77\xc1\xe8\x82\xb1\xe8\xc7\xc6\x9a\xe1\xaa\x3b\x34\x37\x84\xab\x86\xd7\xb6\xbe\x25\x2b\xc9\x99\xe2\x50\x2c\xcd\x38\xc4\x1a\x45\xa9\xc0\x5f\x44\xa5\x2a\xf4\x74\xa2\x31\x34\xf4\x91\xcc\x12\x00\x30\x1d\xbe\x93\x8a\x4d\xc5\xa5\x60\x59\x1c\x62\x26\x5d\xec\xb3\x0c\x3b\x29\x72\x26\x8f\x78\xe2\xc7\xc5\x1d\x0f\xc1\xeb\xfa\x6d\xba\x9e\x99\x5c\x6d\x99\x8c\xe4\x37\xfe\x02\x8e\xe2\x9e\x8a\x8d\x28\x54\x6c\x7f\x82\x8b\xa5\xa6\xb6\x27\x47\xff\x05\xad\xd7\x75\xd0\xd3\x53\x4a\xe4\xbc\x52\x2c\xdf\xe1\x20\xd4\xf6\x5e\xf1\x5a\x16\xfc\x07\xe0\x91\xaf\xd9\x3e\x53\x28\xca\xc3\x6c\x9e\xcc\x1f\x92\xcf\x01\x00\x46\xcb\x9d\x5e\x82\x01\x00\x00")
+
+func _7_outbox_up_sql() ([]byte, error) {
+	return bindata_read(
+		__7_outbox_up_sql,
+		"7_outbox.up.sql",
+	)
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -67,17 +175,31 @@ func AssetNames() []string {
 var _bindata = map[string]func() ([]byte, error){
 	"1_create_records.down.sql": _1_create_records_down_sql,
 	"1_create_records.up.sql":   _1_create_records_up_sql,
+	"2_causation_id.down.sql":   _2_causation_id_down_sql,
+	"2_causation_id.up.sql":     _2_causation_id_up_sql,
+	"3_metadata.down.sql":       _3_metadata_down_sql,
+	"3_metadata.up.sql":         _3_metadata_up_sql,
+	"4_message_id.down.sql":     _4_message_id_down_sql,
+	"4_message_id.up.sql":       _4_message_id_up_sql,
+	"5_tx_id.down.sql":          _5_tx_id_down_sql,
+	"5_tx_id.up.sql":            _5_tx_id_up_sql,
+	"6_leases.down.sql":         _6_leases_down_sql,
+	"6_leases.up.sql":           _6_leases_up_sql,
+	"7_outbox.down.sql":         _7_outbox_down_sql,
+	"7_outbox.up.sql":           _7_outbox_up_sql,
 }
 
 // AssetDir returns the file names below a certain
 // directory embedded in the file by go-bindata.
 // For example if you run go-bindata on data/... and data contains the
 // following hierarchy:
-//     data/
-//       foo.txt
-//       img/
-//         a.png
-//         b.png
+//
+//	data/
+//	  foo.txt
+//	  img/
+//	    a.png
+//	    b.png
+//
 // then AssetDir("data") would return []string{"foo.txt", "img"}
 // AssetDir("data/img") would return []string{"a.png", "b.png"}
 // AssetDir("foo.txt") and AssetDir("notexist") would return an error
@@ -112,4 +234,16 @@ type _bintree_t struct {
 var _bintree = &_bintree_t{nil, map[string]*_bintree_t{
 	"1_create_records.down.sql": &_bintree_t{_1_create_records_down_sql, map[string]*_bintree_t{}},
 	"1_create_records.up.sql":   &_bintree_t{_1_create_records_up_sql, map[string]*_bintree_t{}},
+	"2_causation_id.down.sql":   &_bintree_t{_2_causation_id_down_sql, map[string]*_bintree_t{}},
+	"2_causation_id.up.sql":     &_bintree_t{_2_causation_id_up_sql, map[string]*_bintree_t{}},
+	"3_metadata.down.sql":       &_bintree_t{_3_metadata_down_sql, map[string]*_bintree_t{}},
+	"3_metadata.up.sql":         &_bintree_t{_3_metadata_up_sql, map[string]*_bintree_t{}},
+	"4_message_id.down.sql":     &_bintree_t{_4_message_id_down_sql, map[string]*_bintree_t{}},
+	"4_message_id.up.sql":       &_bintree_t{_4_message_id_up_sql, map[string]*_bintree_t{}},
+	"5_tx_id.down.sql":          &_bintree_t{_5_tx_id_down_sql, map[string]*_bintree_t{}},
+	"5_tx_id.up.sql":            &_bintree_t{_5_tx_id_up_sql, map[string]*_bintree_t{}},
+	"6_leases.down.sql":         &_bintree_t{_6_leases_down_sql, map[string]*_bintree_t{}},
+	"6_leases.up.sql":           &_bintree_t{_6_leases_up_sql, map[string]*_bintree_t{}},
+	"7_outbox.down.sql":         &_bintree_t{_7_outbox_down_sql, map[string]*_bintree_t{}},
+	"7_outbox.up.sql":           &_bintree_t{_7_outbox_up_sql, map[string]*_bintree_t{}},
 }}
@@ -4,19 +4,40 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
+
+	"github.com/google/uuid"
 )
 
+type PoLease struct {
+	Name    string    `json:"name"`
+	Owner   string    `json:"owner"`
+	Expires time.Time `json:"expires"`
+}
+
 // contains messages
 type PoMessage struct {
-	ID            int64          `json:"id"`
-	Created       time.Time      `json:"created"`
-	Stream        string         `json:"stream"`
-	No            int64          `json:"no"`
-	Grp           string         `json:"grp"`
-	ContentType   string         `json:"content_type"`
-	Data          []byte         `json:"data"`
-	CorrelationID sql.NullString `json:"correlation_id"`
+	ID            int64           `json:"id"`
+	Created       time.Time       `json:"created"`
+	Stream        string          `json:"stream"`
+	No            int64           `json:"no"`
+	Grp           string          `json:"grp"`
+	ContentType   string          `json:"content_type"`
+	Data          []byte          `json:"data"`
+	CorrelationID sql.NullString  `json:"correlation_id"`
+	CausationID   sql.NullString  `json:"causation_id"`
+	Metadata      json.RawMessage `json:"metadata"`
+	MessageID     uuid.UUID       `json:"message_id"`
+	TxID          int64           `json:"tx_id"`
+}
+
+type PoOutbox struct {
+	ID       int64     `json:"id"`
+	Stream   string    `json:"stream"`
+	No       int64     `json:"no"`
+	GlobalNo int64     `json:"global_no"`
+	Created  time.Time `json:"created"`
 }
 
 // snapshot position and data
@@ -0,0 +1,85 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: outbox.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+const claimNotifications = `-- name: ClaimNotifications :many
+SELECT id, stream, no, global_no, created
+FROM po_outbox
+ORDER BY id
+LIMIT $1 FOR UPDATE SKIP LOCKED
+`
+
+func (q *Queries) ClaimNotifications(ctx context.Context, limit int32) ([]PoOutbox, error) {
+	rows, err := q.db.QueryContext(ctx, claimNotifications, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PoOutbox
+	for rows.Next() {
+		var i PoOutbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.Stream,
+			&i.No,
+			&i.GlobalNo,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countNotifications = `-- name: CountNotifications :one
+SELECT COUNT(*)
+FROM po_outbox
+`
+
+func (q *Queries) CountNotifications(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countNotifications)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deleteNotifications = `-- name: DeleteNotifications :exec
+DELETE
+FROM po_outbox
+WHERE id = ANY ($1::bigint[])
+`
+
+func (q *Queries) DeleteNotifications(ctx context.Context, id []int64) error {
+	_, err := q.db.ExecContext(ctx, deleteNotifications, pq.Array(id))
+	return err
+}
+
+const storeNotification = `-- name: StoreNotification :exec
+INSERT INTO po_outbox (stream, no, global_no)
+VALUES ($1, $2, $3)
+`
+
+type StoreNotificationParams struct {
+	Stream   string `json:"stream"`
+	No       int64  `json:"no"`
+	GlobalNo int64  `json:"global_no"`
+}
+
+func (q *Queries) StoreNotification(ctx context.Context, arg StoreNotificationParams) error {
+	_, err := q.db.ExecContext(ctx, storeNotification, arg.Stream, arg.No, arg.GlobalNo)
+	return err
+}
@@ -5,10 +5,52 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/lib/pq"
 )
 
+const getSubscriptions = `-- name: GetSubscriptions :many
+SELECT stream, subscriber_id, no, updated
+FROM po_subscriptions
+ORDER BY stream, subscriber_id
+`
+
+type GetSubscriptionsRow struct {
+	Stream       string    `json:"stream"`
+	SubscriberID string    `json:"subscriber_id"`
+	No           int64     `json:"no"`
+	Updated      time.Time `json:"updated"`
+}
+
+func (q *Queries) GetSubscriptions(ctx context.Context) ([]GetSubscriptionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSubscriptions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSubscriptionsRow
+	for rows.Next() {
+		var i GetSubscriptionsRow
+		if err := rows.Scan(
+			&i.Stream,
+			&i.SubscriberID,
+			&i.No,
+			&i.Updated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const lockSubscriberPosition = `-- name: LockSubscriberPosition :many
 SELECT subscriber_id, no
 FROM po_subscriptions
@@ -51,9 +93,28 @@ func (q *Queries) LockSubscriberPosition(ctx context.Context, arg LockSubscriber
 	return items, nil
 }
 
+const moveSubscriberPosition = `-- name: MoveSubscriberPosition :exec
+INSERT INTO po_subscriptions (updated, no, subscriber_id, stream)
+VALUES (NOW(), $1, $2, $3)
+ON CONFLICT (stream, subscriber_id) DO UPDATE
+    SET no      = excluded.no,
+        updated = NOW()
+`
+
+type MoveSubscriberPositionParams struct {
+	No           int64  `json:"no"`
+	SubscriberID string `json:"subscriber_id"`
+	Stream       string `json:"stream"`
+}
+
+func (q *Queries) MoveSubscriberPosition(ctx context.Context, arg MoveSubscriberPositionParams) error {
+	_, err := q.db.ExecContext(ctx, moveSubscriberPosition, arg.No, arg.SubscriberID, arg.Stream)
+	return err
+}
+
 const setSubscriberPosition = `-- name: SetSubscriberPosition :exec
 INSERT INTO po_subscriptions (updated, no, subscriber_id, stream)
-VALUES (NOW(), -1, $1, $2)
+VALUES (NOW(), $3, $1, $2)
 ON CONFLICT (stream, subscriber_id) DO UPDATE
     SET no      = $3,
         updated = NOW()
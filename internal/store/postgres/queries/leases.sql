@@ -0,0 +1,22 @@
+-- name: AcquireLease :many
+INSERT INTO po_leases (name, owner, expires)
+VALUES ($1, $2, NOW() + $3::bigint * INTERVAL '1 millisecond')
+ON CONFLICT (name) DO UPDATE
+    SET owner   = excluded.owner,
+        expires = excluded.expires
+WHERE po_leases.owner = excluded.owner
+   OR po_leases.expires < NOW()
+RETURNING owner;
+
+-- name: ReleaseLease :exec
+DELETE
+FROM po_leases
+WHERE name = $1
+  AND owner = $2;
+
+-- name: GetLeases :many
+SELECT name, owner, expires
+FROM po_leases
+WHERE LEFT(name, LENGTH(@prefix::varchar)) = @prefix::varchar
+  AND expires > NOW()
+ORDER BY name;
@@ -0,0 +1,14 @@
+-- name: GetLockTimeout :one
+SELECT current_setting('lock_timeout')::text;
+
+-- name: SetLockTimeout :exec
+SELECT set_config('lock_timeout', @timeout::text, true);
+
+-- name: LockStream :exec
+SELECT pg_advisory_xact_lock(@space::int, hashtext(@stream::text));
+
+-- name: LockStreamSession :exec
+SELECT pg_advisory_lock(@space::int, hashtext(@stream::text));
+
+-- name: UnlockStreamSession :exec
+SELECT pg_advisory_unlock(@space::int, hashtext(@stream::text));
@@ -1,6 +1,6 @@
 -- name: StoreRecord :one
-INSERT INTO po_messages (stream, no, grp, content_type, data, correlation_id)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO po_messages (stream, no, grp, content_type, data, correlation_id, causation_id, metadata, message_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 RETURNING *;
 
 -- name: GetStreamPosition :one
@@ -8,6 +8,38 @@ SELECT GREATEST(MAX(no), -1)::bigint
 FROM po_messages
 WHERE stream = $1;
 
+-- name: GetGroupPosition :one
+-- held back like ReadRecordsByGroup, so no record below the position is still to become visible
+SELECT GREATEST(MAX(id), -1)::bigint
+FROM po_messages
+WHERE grp = $1
+  AND (tx_id < txid_snapshot_xmin(txid_current_snapshot())
+    OR tx_id = txid_current_if_assigned());
+
+-- name: GetStreamPositionBefore :one
+SELECT GREATEST(MAX(no), -1)::bigint
+FROM po_messages
+WHERE stream = $1
+  AND created < $2;
+
+-- name: GetGroupPositionBefore :one
+SELECT GREATEST(MAX(id), -1)::bigint
+FROM po_messages
+WHERE grp = $1
+  AND created < $2;
+
+-- name: CountRecordsByStream :one
+SELECT COUNT(*)
+FROM po_messages
+WHERE stream = $1
+  AND no > $2;
+
+-- name: CountRecordsByGroup :one
+SELECT COUNT(*)
+FROM po_messages
+WHERE grp = $1
+  AND id > $2;
+
 -- name: ReadRecordsByStream :many
 SELECT *
 FROM po_messages
@@ -18,10 +50,53 @@ ORDER BY no ASC
 LIMIT $4;
 
 -- name: ReadRecordsByGroup :many
+-- ids are assigned before commit, so messages written by transactions
+-- newer than the oldest one still running are held back,
+-- as a lower id might still become visible.
+-- The messages written by the transaction reading are its own to see.
 SELECT *
 FROM po_messages
 WHERE grp = $1
   AND id > $2
   AND id <= $3
+  AND (tx_id < txid_snapshot_xmin(txid_current_snapshot())
+    OR tx_id = txid_current_if_assigned())
 ORDER BY id ASC
 LIMIT $4;
+
+-- name: HasHeldBackRecords :one
+-- committed messages after the position held back by ReadRecordsByGroup
+SELECT EXISTS(SELECT 1
+              FROM po_messages
+              WHERE grp = $1
+                AND id > $2
+                AND tx_id >= txid_snapshot_xmin(txid_current_snapshot()))::bool;
+
+-- name: ReadRecordsByMessageId :many
+SELECT *
+FROM po_messages
+WHERE message_id = ANY (@message_id::uuid[])
+ORDER BY id ASC;
+
+-- name: ReadRecordsByStreamOfTypes :many
+SELECT *
+FROM po_messages
+WHERE stream = @stream
+  AND no > @from_no
+  AND no <= @to_no
+  AND content_type = ANY (@content_types::text[])
+ORDER BY no ASC
+LIMIT @max_records;
+
+-- name: ReadRecordsByGroupOfTypes :many
+-- held back like ReadRecordsByGroup
+SELECT *
+FROM po_messages
+WHERE grp = @grp
+  AND id > @from_id
+  AND id <= @to_id
+  AND content_type = ANY (@content_types::text[])
+  AND (tx_id < txid_snapshot_xmin(txid_current_snapshot())
+    OR tx_id = txid_current_if_assigned())
+ORDER BY id ASC
+LIMIT @max_records;
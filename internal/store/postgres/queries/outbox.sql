@@ -0,0 +1,18 @@
+-- name: StoreNotification :exec
+INSERT INTO po_outbox (stream, no, global_no)
+VALUES ($1, $2, $3);
+
+-- name: ClaimNotifications :many
+SELECT id, stream, no, global_no, created
+FROM po_outbox
+ORDER BY id
+LIMIT $1 FOR UPDATE SKIP LOCKED;
+
+-- name: DeleteNotifications :exec
+DELETE
+FROM po_outbox
+WHERE id = ANY (@id::bigint[]);
+
+-- name: CountNotifications :one
+SELECT COUNT(*)
+FROM po_outbox;
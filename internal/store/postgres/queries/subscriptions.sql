@@ -8,10 +8,22 @@ ORDER BY stream DESC, subscriber_id DESC -- to avoid deadlock
 
 -- name: SetSubscriberPosition :exec
 INSERT INTO po_subscriptions (updated, no, subscriber_id, stream)
-VALUES (NOW(), -1, $1, $2)
+VALUES (NOW(), $3, $1, $2)
 ON CONFLICT (stream, subscriber_id) DO UPDATE
     SET no      = $3,
         updated = NOW()
 WHERE po_subscriptions.stream = $2
   AND po_subscriptions.subscriber_id = $1
   AND po_subscriptions.no < $3;
+
+-- name: MoveSubscriberPosition :exec
+INSERT INTO po_subscriptions (updated, no, subscriber_id, stream)
+VALUES (NOW(), $1, $2, $3)
+ON CONFLICT (stream, subscriber_id) DO UPDATE
+    SET no      = excluded.no,
+        updated = NOW();
+
+-- name: GetSubscriptions :many
+SELECT stream, subscriber_id, no, updated
+FROM po_subscriptions
+ORDER BY stream, subscriber_id;
@@ -0,0 +1,2 @@
+ALTER TABLE po_messages
+    DROP COLUMN IF EXISTS causation_id;
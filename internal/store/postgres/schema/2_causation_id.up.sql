@@ -0,0 +1,3 @@
+-- id of what caused a message
+ALTER TABLE po_messages
+    ADD COLUMN IF NOT EXISTS causation_id varchar NULL;
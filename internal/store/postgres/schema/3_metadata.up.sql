@@ -0,0 +1,3 @@
+-- values stored along with a message
+ALTER TABLE po_messages
+    ADD COLUMN IF NOT EXISTS metadata jsonb DEFAULT '{}' NOT NULL;
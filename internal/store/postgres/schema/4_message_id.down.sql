@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS po_messages_message_id_uindex;
+
+ALTER TABLE po_messages
+    DROP COLUMN IF EXISTS message_id;
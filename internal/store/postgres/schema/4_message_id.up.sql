@@ -0,0 +1,5 @@
+-- unique id of a message, used to deduplicate appends
+ALTER TABLE po_messages
+    ADD COLUMN IF NOT EXISTS message_id uuid NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS po_messages_message_id_uindex ON po_messages (message_id);
@@ -0,0 +1,4 @@
+-- transaction writing the message, used to hold back group reads
+-- of messages until every transaction older than it have ended
+ALTER TABLE po_messages
+    ADD COLUMN IF NOT EXISTS tx_id bigint DEFAULT txid_current() NOT NULL;
@@ -0,0 +1,7 @@
+-- leases held by instances, e.g. on the partitions of a subscription
+CREATE TABLE IF NOT EXISTS po_leases
+(
+    name    VARCHAR                  NOT NULL PRIMARY KEY,
+    owner   VARCHAR                  NOT NULL,
+    expires TIMESTAMP WITH TIME ZONE NOT NULL
+);
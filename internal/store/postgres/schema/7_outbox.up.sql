@@ -0,0 +1,9 @@
+-- notifications of written records, pending until published by the relay
+CREATE TABLE IF NOT EXISTS po_outbox
+(
+    id        BIGSERIAL                NOT NULL PRIMARY KEY,
+    stream    VARCHAR                  NOT NULL,
+    no        BIGINT                   NOT NULL,
+    global_no BIGINT                   NOT NULL,
+    created   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
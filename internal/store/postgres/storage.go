@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/go-po/po/internal/record"
 	"github.com/go-po/po/internal/store"
@@ -16,7 +17,13 @@ func NewFromUrl(databaseUrl string) (*Storage, error) {
 	if err != nil {
 		return nil, err
 	}
-	return NewFromConn(db)
+	storage, err := NewFromConn(db)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	storage.closeConn = true
+	return storage, nil
 }
 
 func NewFromConn(conn *sql.DB) (*Storage, error) {
@@ -25,17 +32,53 @@ func NewFromConn(conn *sql.DB) (*Storage, error) {
 		return nil, err
 	}
 	return &Storage{
-		conn: conn,
+		conn: dbConn{DB: conn},
 	}, nil
 }
 
 type Storage struct {
-	conn *sql.DB
-	db   *db.Queries
+	conn   connection
+	db     *db.Queries
+	outbox bool // writes notifications to the outbox
+
+	closeConn bool // the database was opened by the storage, and is closed with it
+}
+
+// Storage taking part in a transaction owned by the application,
+// given as the *sql.Tx or the connection it runs on.
+// Writes are made within it, and only become visible when the application commits.
+func (store *Storage) WithTx(tx db.DBTX) *Storage {
+	return &Storage{
+		conn:   appTx{DBTX: tx},
+		outbox: store.outbox,
+	}
+}
+
+// Closes the database if it was opened by the storage.
+// A database handed to NewFromConn, or a transaction of the application, is left to its owner.
+func (store *Storage) Close() error {
+	if conn, ok := store.conn.(dbConn); ok && store.closeConn {
+		return conn.Close()
+	}
+	return nil
+}
+
+// connection the operations made with the context are made on
+func (store *Storage) connection(ctx context.Context) connection {
+	return scopedConnection(ctx, store.conn)
+}
+
+// connection the records written with the context are written on, along with their notifications once the outbox is enabled
+func (store *Storage) writer(ctx context.Context) connection {
+	conn := store.connection(ctx)
+	if store.outbox {
+		return outboxConn{connection: conn}
+	}
+	return conn
 }
 
 func (store *Storage) Begin(ctx context.Context) (store.Tx, error) {
-	return begin(ctx, store.conn)
+	return begin(ctx, store.connection(ctx))
 }
 
 func (store *Storage) SubscriptionPositionLock(storeTx store.Tx, id streams.Id, subscriptionIds ...string) ([]store.SubscriptionPosition, error) {
@@ -54,27 +97,107 @@ func (store *Storage) SetSubscriptionPosition(storeTx store.Tx, id streams.Id, p
 	return updateSubscriberPosition(tx.ctx, tx.tx, id, position)
 }
 
+// Sets the position of the subscription, even if lower than its current position
+func (store *Storage) MoveSubscriptionPosition(storeTx store.Tx, id streams.Id, position store.SubscriptionPosition) error {
+	tx, isTx := storeTx.(*storageTx)
+	if !isTx {
+		return fmt.Errorf("wrong tx: %T", storeTx)
+	}
+	return moveSubscriberPosition(tx.ctx, tx.tx, id, position)
+}
+
 func (store *Storage) WriteRecords(ctx context.Context, id streams.Id, data ...record.Data) ([]record.Record, error) {
-	return writeRecords(ctx, store.conn, id, -1, data...)
+	return writeRecords(ctx, store.writer(ctx), id, endOfStream, data...)
 }
 
 func (store *Storage) WriteRecordsFrom(ctx context.Context, id streams.Id, position int64, data ...record.Data) ([]record.Record, error) {
-	return writeRecords(ctx, store.conn, id, position, data...)
+	return writeRecords(ctx, store.writer(ctx), id, position, data...)
+}
+
+// Writes to all the streams or none of them
+func (store *Storage) WriteBatch(ctx context.Context, writes ...store.StreamWrite) ([]record.Record, error) {
+	return writeBatch(ctx, store.writer(ctx), writes...)
+}
+
+// Locks the stream against other lockers until the returned transaction ends
+func (store *Storage) LockStream(ctx context.Context, id streams.Id, timeout time.Duration) (store.Tx, error) {
+	return lockStream(ctx, store.conn, id, timeout)
 }
 
 func (store *Storage) ReadRecords(ctx context.Context, id streams.Id, from int64, to, limit int64) ([]record.Record, error) {
-	return readRecords(ctx, store.conn, id, from, to, limit)
+	return readRecords(ctx, store.connection(ctx), id, from, to, limit)
+}
+
+// Records of the content types only, and the position the read got to, past the records of other types
+func (store *Storage) ReadRecordsOfTypes(ctx context.Context, id streams.Id, from, to, limit int64, contentTypes []string) ([]record.Record, int64, error) {
+	return readRecordsOfTypes(ctx, store.connection(ctx), id, from, to, limit, contentTypes)
+}
+
+// Reports true if records of the group after the position are held back until older transactions end
+func (store *Storage) HeldBack(ctx context.Context, id streams.Id, position int64) (bool, error) {
+	return heldBack(ctx, store.conn, id, position)
+}
+
+// Position of the last record of the stream, -1 if it is empty
+func (store *Storage) HeadPosition(ctx context.Context, id streams.Id) (int64, error) {
+	return headPosition(ctx, store.connection(ctx), id)
+}
+
+// Position of the last record of the stream written before the time, -1 if there is none
+func (store *Storage) PositionBefore(ctx context.Context, id streams.Id, t time.Time) (int64, error) {
+	return positionBefore(ctx, store.connection(ctx), id, t)
+}
+
+// Number of records of the stream after the position
+func (store *Storage) CountRecords(ctx context.Context, id streams.Id, after int64) (int64, error) {
+	return countRecords(ctx, store.connection(ctx), id, after)
+}
+
+// Stored positions of every subscription
+func (store *Storage) ListSubscriptions(ctx context.Context) ([]store.Subscription, error) {
+	return subscriptions(ctx, store.conn)
 }
 
 func (store *Storage) ReadSnapshot(ctx context.Context, id streams.Id, snapshotId string) (record.Snapshot, error) {
-	return readSnapshot(ctx, store.conn, id, snapshotId)
+	return readSnapshot(ctx, store.connection(ctx), id, snapshotId)
 
 }
 
 func (store *Storage) UpdateSnapshot(ctx context.Context, id streams.Id, snapshotId string, snapshot record.Snapshot) error {
-	return updateSnapshot(ctx, store.conn, id, snapshotId, snapshot)
+	return updateSnapshot(ctx, store.connection(ctx), id, snapshotId, snapshot)
 }
 
 func (store *Storage) DeleteSnapshot(ctx context.Context, id streams.Id, snapshotId string) error {
-	return deleteSnapshot(ctx, store.conn, id, snapshotId)
+	return deleteSnapshot(ctx, store.connection(ctx), id, snapshotId)
+}
+
+// Takes or renews the lease for the owner, reporting false if another owner holds it
+func (store *Storage) AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	return acquireLease(ctx, store.conn, name, owner, ttl)
+}
+
+// Gives up the lease, if held by the owner
+func (store *Storage) ReleaseLease(ctx context.Context, name, owner string) error {
+	return releaseLease(ctx, store.conn, name, owner)
+}
+
+// Unexpired leases with names starting with the prefix
+func (store *Storage) Leases(ctx context.Context, prefix string) ([]store.Lease, error) {
+	return leases(ctx, store.conn, prefix)
+}
+
+// Writes the notifications of records to the outbox in the transaction writing them, from now on
+func (store *Storage) EnableOutbox() {
+	store.outbox = true
+}
+
+// Claims the oldest notifications not claimed by another relay, up to the limit, and publishes them in order.
+// The published are removed from the outbox, the rest are kept after a failure.
+func (store *Storage) RelayNotifications(ctx context.Context, limit int, publish func(n store.Notification) error) (int, error) {
+	return relayNotifications(ctx, store.conn, limit, publish)
+}
+
+// Number of notifications in the outbox
+func (store *Storage) CountNotifications(ctx context.Context) (int64, error) {
+	return countNotifications(ctx, store.conn)
 }
@@ -5,11 +5,92 @@ import (
 	"database/sql"
 
 	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/internal/store/postgres/generated/db"
 )
 
+// the database, or a transaction owned by the application
+type connection interface {
+	db.DBTX
+	begin(ctx context.Context) (dbTx, error)
+}
+
+// connection writing the notifications of the records to the outbox along with them
+type outboxConn struct {
+	connection
+}
+
+type dbTx interface {
+	db.DBTX
+	Commit() error
+	Rollback() error
+}
+
+type dbConn struct {
+	*sql.DB
+}
+
+func (conn dbConn) begin(ctx context.Context) (dbTx, error) {
+	return conn.BeginTx(ctx, &sql.TxOptions{
+		//Isolation: sql.LevelRepeatableRead,
+	})
+}
+
+// transaction owned by the application.
+// Transactions begun by the store are savepoints within it,
+// leaving the final commit to the application.
+type appTx struct {
+	db.DBTX
+}
+
+func (tx appTx) begin(ctx context.Context) (dbTx, error) {
+	_, err := tx.ExecContext(ctx, "SAVEPOINT po")
+	if err != nil {
+		return nil, err
+	}
+	return &savepoint{DBTX: tx.DBTX, ctx: ctx}, nil
+}
+
+type savepoint struct {
+	db.DBTX
+	ctx  context.Context
+	done bool
+}
+
+// Releases the savepoint, or rolls back to it if the transaction failed within it
+func (sp *savepoint) Commit() error {
+	if sp.done {
+		return sql.ErrTxDone
+	}
+	sp.done = true
+	_, err := sp.ExecContext(sp.ctx, "RELEASE SAVEPOINT po")
+	if err != nil {
+		_ = sp.rollback()
+	}
+	return err
+}
+
+func (sp *savepoint) Rollback() error {
+	if sp.done {
+		return sql.ErrTxDone
+	}
+	sp.done = true
+	return sp.rollback()
+}
+
+// Rolls back to the savepoint and releases it,
+// so savepoints taken around it are released next rather than this one.
+func (sp *savepoint) rollback() error {
+	_, err := sp.ExecContext(sp.ctx, "ROLLBACK TO SAVEPOINT po")
+	if err != nil {
+		return err
+	}
+	_, err = sp.ExecContext(sp.ctx, "RELEASE SAVEPOINT po")
+	return err
+}
+
 type storageTx struct {
 	ctx context.Context
-	tx  *sql.Tx
+	tx  dbTx
 }
 
 func (facade *storageTx) Commit() error {
@@ -20,10 +101,8 @@ func (facade *storageTx) Rollback() error {
 	return facade.tx.Rollback()
 }
 
-func begin(ctx context.Context, conn *sql.DB) (store.Tx, error) {
-	tx, err := conn.BeginTx(ctx, &sql.TxOptions{
-		//Isolation: sql.LevelRepeatableRead,
-	})
+func begin(ctx context.Context, conn connection) (store.Tx, error) {
+	tx, err := conn.begin(ctx)
 	if err != nil {
 		return nil, err
 	}
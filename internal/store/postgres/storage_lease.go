@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/internal/store/postgres/generated/db"
+)
+
+// Takes or renews the lease, reporting false if another owner holds it.
+// Expiry uses the clock of the database, shared by every instance.
+func acquireLease(ctx context.Context, conn connection, name, owner string, ttl time.Duration) (bool, error) {
+	owners, err := db.New(conn).AcquireLease(ctx, db.AcquireLeaseParams{
+		Name:    name,
+		Owner:   owner,
+		Column3: ttl.Milliseconds(),
+	})
+	if err != nil {
+		return false, err
+	}
+	return len(owners) == 1 && owners[0] == owner, nil
+}
+
+func releaseLease(ctx context.Context, conn connection, name, owner string) error {
+	return db.New(conn).ReleaseLease(ctx, db.ReleaseLeaseParams{
+		Name:  name,
+		Owner: owner,
+	})
+}
+
+func leases(ctx context.Context, conn connection, prefix string) ([]store.Lease, error) {
+	rows, err := db.New(conn).GetLeases(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	var result []store.Lease
+	for _, row := range rows {
+		result = append(result, store.Lease{
+			Name:    row.Name,
+			Owner:   row.Owner,
+			Expires: row.Expires,
+		})
+	}
+	return result, nil
+}
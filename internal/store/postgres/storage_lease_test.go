@@ -0,0 +1,11 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/go-po/po/internal/store/storetest"
+)
+
+func TestStorage_Lease(t *testing.T) {
+	storetest.Leases(t, &Storage{conn: databaseConnection(t)})
+}
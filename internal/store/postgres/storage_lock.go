@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/internal/store/postgres/generated/db"
+	"github.com/go-po/po/streams"
+	"github.com/lib/pq"
+)
+
+// first key of the advisory locks taken on streams
+const streamLockSpace int32 = 0x706f
+
+type streamLockKey struct{}
+
+// Lock on a stream, along with the transaction it was taken in.
+// Reads and writes made with its context are made within the transaction,
+// so they commit as the lock is released.
+type streamLock struct {
+	storageTx
+	owner    connection   // the lock was taken on
+	unlock   func() error // releases a lock held by the session, nil when held by the transaction
+	released bool
+}
+
+func (lock *streamLock) Context(ctx context.Context) context.Context {
+	return context.WithValue(ctx, streamLockKey{}, lock)
+}
+
+func (lock *streamLock) Commit() error {
+	return lock.release(lock.tx.Commit())
+}
+
+func (lock *streamLock) Rollback() error {
+	return lock.release(lock.tx.Rollback())
+}
+
+// releases a lock held by the session, once its transaction has ended
+func (lock *streamLock) release(err error) error {
+	if lock.unlock == nil || lock.released {
+		return err
+	}
+	lock.released = true
+	unlockErr := lock.unlock()
+	if err != nil {
+		return err
+	}
+	return unlockErr
+}
+
+// connection to use with the context, the transaction of the stream lock it carries if taken on the connection
+func scopedConnection(ctx context.Context, conn connection) connection {
+	lock, ok := ctx.Value(streamLockKey{}).(*streamLock)
+	if !ok || lock.owner != conn {
+		return conn
+	}
+	return appTx{DBTX: lock.tx}
+}
+
+// Takes an advisory lock on the stream, held until the returned transaction ends.
+// Within a transaction of the application, the lock is held by its session,
+// as a transaction lock would only be released once the application commits.
+// A zero timeout waits for as long as the context allows.
+func lockStream(ctx context.Context, conn connection, id streams.Id, timeout time.Duration) (store.Tx, error) {
+	tx, err := conn.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, session := conn.(appTx)
+	params := db.LockStreamParams{
+		Space:  streamLockSpace,
+		Stream: id.String(),
+	}
+	err = acquireStreamLock(ctx, tx, params, timeout, session)
+	if err != nil {
+		_ = tx.Rollback()
+		if session {
+			_ = db.New(conn).UnlockStreamSession(ctx, db.UnlockStreamSessionParams(params))
+		}
+		if err, ok := err.(*pq.Error); ok {
+			if err.Code.Name() == "lock_not_available" {
+				return nil, store.LockTimeoutError{StreamId: id, Err: err}
+			}
+		}
+		return nil, err
+	}
+	lock := &streamLock{
+		storageTx: storageTx{
+			ctx: ctx,
+			tx:  tx,
+		},
+		owner: conn,
+	}
+	if session {
+		lock.unlock = func() error {
+			return db.New(conn).UnlockStreamSession(ctx, db.UnlockStreamSessionParams(params))
+		}
+	}
+	return lock, nil
+}
+
+// Waits for the lock no longer than the timeout.
+// The lock timeout is set locally while waiting only, and then restored,
+// leaving the transaction as it was for the statements after it.
+func acquireStreamLock(ctx context.Context, tx dbTx, params db.LockStreamParams, timeout time.Duration, session bool) error {
+	dao := db.New(tx)
+	var previous string
+	var err error
+	if timeout > 0 {
+		previous, err = dao.GetLockTimeout(ctx)
+		if err != nil {
+			return err
+		}
+		err = dao.SetLockTimeout(ctx, fmt.Sprintf("%dms", timeout.Milliseconds()))
+		if err != nil {
+			return err
+		}
+	}
+	if session {
+		err = dao.LockStreamSession(ctx, db.LockStreamSessionParams(params))
+	} else {
+		err = dao.LockStream(ctx, params)
+	}
+	if err != nil {
+		return err
+	}
+	if timeout > 0 {
+		return dao.SetLockTimeout(ctx, previous)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/internal/store/postgres/generated/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorage_LockStream(t *testing.T) {
+	// setup
+	conn := databaseConnection(t)
+	ctx := context.Background()
+	id := streamId("lock")
+
+	held, err := lockStream(ctx, conn, id, time.Second)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	t.Run("timeout", func(t *testing.T) {
+		// execute
+		_, err := lockStream(ctx, conn, id, 10*time.Millisecond)
+		// verify
+		assert.True(t, errors.Is(err, store.LockTimeoutError{}), "lock timeout error")
+	})
+
+	t.Run("other stream", func(t *testing.T) {
+		// execute
+		lock, err := lockStream(ctx, conn, streamId("lock"), 10*time.Millisecond)
+		// verify
+		if assert.NoError(t, err) {
+			assert.NoError(t, lock.Commit())
+		}
+	})
+
+	t.Run("released", func(t *testing.T) {
+		// setup
+		assert.NoError(t, held.Commit())
+		// execute
+		lock, err := lockStream(ctx, conn, id, 10*time.Millisecond)
+		// verify
+		if assert.NoError(t, err) {
+			assert.NoError(t, lock.Commit())
+		}
+	})
+}
+
+func TestStorage_LockStream_Scoped(t *testing.T) {
+	// setup
+	conn := databaseConnection(t)
+	ctx := context.Background()
+
+	t.Run("writes within the lock", func(t *testing.T) {
+		// setup
+		s := &Storage{conn: conn}
+		id := streamId("scoped")
+		lock, err := s.LockStream(ctx, id, time.Second)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer func() {
+			_ = lock.Rollback()
+		}()
+		// execute
+		_, err = s.WriteRecords(lock.(*streamLock).Context(ctx), id, data(1)...)
+		// verify
+		assert.NoError(t, err)
+		outside, err := readRecords(ctx, conn, id, -1, math.MaxInt64, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(outside), "written before the lock is committed")
+		assert.NoError(t, lock.Commit())
+		outside, err = readRecords(ctx, conn, id, -1, math.MaxInt64, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(outside), "committed with the lock")
+	})
+
+	t.Run("application transaction", func(t *testing.T) {
+		// setup
+		id := streamId("app")
+		tx, err := conn.BeginTx(ctx, nil)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer func() {
+			_ = tx.Rollback()
+		}()
+		s := (&Storage{conn: conn}).WithTx(tx)
+		before, err := db.New(tx).GetLockTimeout(ctx)
+		assert.NoError(t, err)
+		// execute
+		lock, err := s.LockStream(ctx, id, time.Second)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.NoError(t, lock.Commit())
+		// verify
+		after, err := db.New(tx).GetLockTimeout(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, before, after, "lock timeout of the application transaction")
+		other, err := lockStream(ctx, conn, id, 10*time.Millisecond)
+		if assert.NoError(t, err, "released while the application transaction is open") {
+			assert.NoError(t, other.Commit())
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/internal/store/postgres/generated/db"
+	"github.com/go-po/po/streams"
+)
+
+// writes the notifications of the records in the transaction writing them
+func storeNotifications(ctx context.Context, dao *db.Queries, records []record.Record) error {
+	for _, r := range records {
+		err := dao.StoreNotification(ctx, db.StoreNotificationParams{
+			Stream:   r.Stream.String(),
+			No:       r.Number,
+			GlobalNo: r.GlobalNumber,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// the claimed rows stay locked until the published are deleted, skipped by other relays meanwhile
+func relayNotifications(ctx context.Context, conn connection, limit int, publish func(n store.Notification) error) (int, error) {
+	tx, err := conn.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	dao := db.New(tx)
+	rows, err := dao.ClaimNotifications(ctx, int32(limit))
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+	var published []int64
+	for _, row := range rows {
+		err = publish(store.Notification{
+			Id:           row.ID,
+			Stream:       streams.ParseId(row.Stream),
+			Number:       row.No,
+			GlobalNumber: row.GlobalNo,
+			Time:         row.Created,
+		})
+		if err != nil {
+			break
+		}
+		published = append(published, row.ID)
+	}
+	if len(published) > 0 {
+		deleteErr := dao.DeleteNotifications(ctx, published)
+		if deleteErr != nil {
+			_ = tx.Rollback()
+			return len(rows), deleteErr
+		}
+	}
+	commitErr := tx.Commit()
+	if err == nil {
+		err = commitErr
+	}
+	return len(rows), err
+}
+
+func countNotifications(ctx context.Context, conn connection) (int64, error) {
+	return db.New(conn).CountNotifications(ctx)
+}
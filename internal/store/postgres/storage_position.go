@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-po/po/internal/store/postgres/generated/db"
+	"github.com/go-po/po/streams"
+)
+
+func headPosition(ctx context.Context, conn connection, id streams.Id) (int64, error) {
+	dao := db.New(conn)
+	if id.HasEntity() {
+		return dao.GetStreamPosition(ctx, id.String())
+	}
+	return dao.GetGroupPosition(ctx, id.Group)
+}
+
+func positionBefore(ctx context.Context, conn connection, id streams.Id, t time.Time) (int64, error) {
+	dao := db.New(conn)
+	if id.HasEntity() {
+		return dao.GetStreamPositionBefore(ctx, db.GetStreamPositionBeforeParams{
+			Stream:  id.String(),
+			Created: t,
+		})
+	}
+	return dao.GetGroupPositionBefore(ctx, db.GetGroupPositionBeforeParams{
+		Grp:     id.Group,
+		Created: t,
+	})
+}
+
+func countRecords(ctx context.Context, conn connection, id streams.Id, after int64) (int64, error) {
+	dao := db.New(conn)
+	if id.HasEntity() {
+		return dao.CountRecordsByStream(ctx, db.CountRecordsByStreamParams{
+			Stream: id.String(),
+			No:     after,
+		})
+	}
+	return dao.CountRecordsByGroup(ctx, db.CountRecordsByGroupParams{
+		Grp: id.Group,
+		ID:  after,
+	})
+}
@@ -2,16 +2,16 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"math"
 
 	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
 	"github.com/go-po/po/internal/store/postgres/generated/db"
 	"github.com/go-po/po/streams"
 )
 
-func readRecords(ctx context.Context, conn *sql.DB, id streams.Id, from, to, limit int64) ([]record.Record, error) {
+func readRecords(ctx context.Context, conn connection, id streams.Id, from, to, limit int64) ([]record.Record, error) {
 
 	if limit > math.MaxInt32 || limit < 1 {
 		return nil, fmt.Errorf("limit cap: %d", limit)
@@ -44,7 +44,74 @@ func readRecords(ctx context.Context, conn *sql.DB, id streams.Id, from, to, lim
 	}
 
 	for _, msg := range msgs {
-		records = append(records, msgToRecord(msg))
+		r, err := msgToRecord(msg)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, r)
 	}
 	return records, nil
 }
+
+// Records of the content types, read up to the head of the stream, and the position the read got to.
+// The other records are filtered by the database, so their payloads are never fetched.
+func readRecordsOfTypes(ctx context.Context, conn connection, id streams.Id, from, to, limit int64, contentTypes []string) ([]record.Record, int64, error) {
+	if limit > math.MaxInt32 || limit < 1 {
+		return nil, from, fmt.Errorf("limit cap: %d", limit)
+	}
+	head, err := headPosition(ctx, conn, id)
+	if err != nil {
+		return nil, from, err
+	}
+	if head < to {
+		to = head
+	}
+	if to <= from || len(contentTypes) == 0 {
+		return nil, from, nil
+	}
+
+	dao := db.New(conn)
+	var msgs []db.PoMessage
+	if id.HasEntity() {
+		msgs, err = dao.ReadRecordsByStreamOfTypes(ctx, db.ReadRecordsByStreamOfTypesParams{
+			Stream:       id.String(),
+			FromNo:       from,
+			ToNo:         to,
+			ContentTypes: contentTypes,
+			MaxRecords:   int32(limit),
+		})
+	} else {
+		msgs, err = dao.ReadRecordsByGroupOfTypes(ctx, db.ReadRecordsByGroupOfTypesParams{
+			Grp:          id.Group,
+			FromID:       from,
+			ToID:         to,
+			ContentTypes: contentTypes,
+			MaxRecords:   int32(limit),
+		})
+	}
+	if err != nil {
+		return nil, from, err
+	}
+
+	var records []record.Record
+	for _, msg := range msgs {
+		r, err := msgToRecord(msg)
+		if err != nil {
+			return nil, from, err
+		}
+		records = append(records, r)
+	}
+	return records, store.FilteredPosition(id, records, limit, to), nil
+}
+
+// Reports true if committed records of the group after the position are held back,
+// as transactions older than them are still running
+func heldBack(ctx context.Context, conn connection, id streams.Id, position int64) (bool, error) {
+	if id.HasEntity() {
+		return false, nil
+	}
+	return db.New(conn).HasHeldBackRecords(ctx, db.HasHeldBackRecordsParams{
+		Grp: id.Group,
+		ID:  position,
+	})
+}
@@ -5,6 +5,9 @@ import (
 	"math"
 	"testing"
 
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -62,7 +65,7 @@ func TestStorage_ReadRecords(t *testing.T) {
 		var i int64
 		var middle int64
 		for i = 0; i < 5; i++ {
-			r, err := writeRecords(ctx, conn, id1, i, data(1)...)
+			r, err := writeRecords(ctx, conn, id1, i-1, data(1)...)
 			if !assert.NoError(t, err) {
 				t.FailNow()
 			}
@@ -70,7 +73,7 @@ func TestStorage_ReadRecords(t *testing.T) {
 				// middle
 				middle = r[0].GlobalNumber
 			}
-			_, err = writeRecords(ctx, conn, id2, i, data(1)...)
+			_, err = writeRecords(ctx, conn, id2, i-1, data(1)...)
 			if !assert.NoError(t, err) {
 				t.FailNow()
 			}
@@ -82,7 +85,124 @@ func TestStorage_ReadRecords(t *testing.T) {
 		// verify
 		assert.NoError(t, err)
 		if assert.Equal(t, 5, len(records)) {
-			assert.Equal(t, 5, int(records[3].Number))
+			assert.Equal(t, 4, int(records[3].Number))
 		}
 	})
 }
+
+func TestStorage_ReadRecordsOfTypes(t *testing.T) {
+	// setup
+	conn := databaseConnection(t)
+	ctx := context.Background()
+	group := streamId("")
+	id := group.WithEntity("entity")
+	var written []record.Record
+	for _, contentType := range []string{"a", "b", "a", "b", "b"} {
+		r, err := writeRecords(ctx, conn, id, store.AnyPosition, record.Data{ContentType: contentType, Data: []byte("{}")})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		written = append(written, r...)
+	}
+
+	t.Run("stream", func(t *testing.T) {
+		// execute
+		records, position, err := readRecordsOfTypes(ctx, conn, id, -1, math.MaxInt64, 100, []string{"a"})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), position)
+		if assert.Equal(t, 2, len(records)) {
+			assert.Equal(t, int64(0), records[0].Number)
+			assert.Equal(t, int64(2), records[1].Number)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		// execute
+		records, position, err := readRecordsOfTypes(ctx, conn, id, -1, math.MaxInt64, 2, []string{"b"})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), position)
+		if assert.Equal(t, 2, len(records)) {
+			assert.Equal(t, int64(1), records[0].Number)
+			assert.Equal(t, int64(3), records[1].Number)
+		}
+	})
+
+	t.Run("group", func(t *testing.T) {
+		// execute
+		records, position, err := readRecordsOfTypes(ctx, conn, group, written[0].GlobalNumber, math.MaxInt64, 100, []string{"a"})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, written[4].GlobalNumber, position)
+		if assert.Equal(t, 1, len(records)) {
+			assert.Equal(t, written[2].GlobalNumber, records[0].GlobalNumber)
+		}
+	})
+
+	t.Run("no records of the types", func(t *testing.T) {
+		// execute
+		records, position, err := readRecordsOfTypes(ctx, conn, id, 0, math.MaxInt64, 100, []string{"c"})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), position)
+		assert.Empty(t, records)
+	})
+}
+
+func TestStorage_ReadRecords_OpenTransaction(t *testing.T) {
+	// setup
+	conn := databaseConnection(t)
+	ctx := context.Background()
+	id := streamId("open")
+	group := streams.ParseId(id.Group)
+	open, err := conn.BeginTx(ctx, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer func() {
+		_ = open.Rollback()
+	}()
+	_, err = writeRecords(ctx, appTx{DBTX: open}, id, -1, data(1)...)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = writeRecords(ctx, conn, group.WithEntity("committed"), -1, data(1)...)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	t.Run("held back", func(t *testing.T) {
+		// execute
+		records, err := readRecords(ctx, conn, group, -1, math.MaxInt64, 100)
+		// verify
+		assert.NoError(t, err)
+		assert.Empty(t, records)
+		held, err := heldBack(ctx, conn, group, -1)
+		assert.NoError(t, err)
+		assert.True(t, held, "held back")
+	})
+
+	t.Run("own writes", func(t *testing.T) {
+		// execute
+		records, err := readRecords(ctx, appTx{DBTX: open}, group, -1, math.MaxInt64, 100)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(records)) {
+			assert.Equal(t, id.String(), records[0].Stream.String())
+		}
+	})
+
+	t.Run("released", func(t *testing.T) {
+		// setup
+		assert.NoError(t, open.Commit())
+		// execute
+		records, err := readRecords(ctx, conn, group, -1, math.MaxInt64, 100)
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(records))
+		held, err := heldBack(ctx, conn, group, -1)
+		assert.NoError(t, err)
+		assert.False(t, held, "held back")
+	})
+}
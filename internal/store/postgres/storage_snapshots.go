@@ -11,7 +11,7 @@ import (
 
 var emptyJson = []byte("{}")
 
-func readSnapshot(ctx context.Context, conn *sql.DB, id streams.Id, snapshotId string) (record.Snapshot, error) {
+func readSnapshot(ctx context.Context, conn connection, id streams.Id, snapshotId string) (record.Snapshot, error) {
 	dao := db.New(conn)
 	position, err := dao.GetSnapshotPosition(ctx, db.GetSnapshotPositionParams{
 		Stream:     id.String(),
@@ -34,7 +34,7 @@ func readSnapshot(ctx context.Context, conn *sql.DB, id streams.Id, snapshotId s
 	}, nil
 }
 
-func updateSnapshot(ctx context.Context, conn *sql.DB, id streams.Id, snapshotId string, snapshot record.Snapshot) error {
+func updateSnapshot(ctx context.Context, conn connection, id streams.Id, snapshotId string, snapshot record.Snapshot) error {
 	dao := db.New(conn)
 	err := dao.UpdateSnapshot(ctx, db.UpdateSnapshotParams{
 		Stream:      id.String(),
@@ -49,7 +49,7 @@ func updateSnapshot(ctx context.Context, conn *sql.DB, id streams.Id, snapshotId
 	return nil
 }
 
-func deleteSnapshot(ctx context.Context, conn *sql.DB, id streams.Id, snapshotId string) error {
+func deleteSnapshot(ctx context.Context, conn connection, id streams.Id, snapshotId string) error {
 	return db.New(conn).DeleteSnapshot(ctx, db.DeleteSnapshotParams{
 		Stream:     id.String(),
 		SnapshotID: snapshotId,
@@ -37,3 +37,28 @@ func updateSubscriberPosition(ctx context.Context, conn db.DBTX, id streams.Id,
 		SubscriberID: position.SubscriptionId,
 	})
 }
+
+func moveSubscriberPosition(ctx context.Context, conn db.DBTX, id streams.Id, position store.SubscriptionPosition) error {
+	return db.New(conn).MoveSubscriberPosition(ctx, db.MoveSubscriberPositionParams{
+		No:           position.Position,
+		Stream:       id.String(),
+		SubscriberID: position.SubscriptionId,
+	})
+}
+
+func subscriptions(ctx context.Context, conn db.DBTX) ([]store.Subscription, error) {
+	rows, err := db.New(conn).GetSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var result []store.Subscription
+	for _, row := range rows {
+		result = append(result, store.Subscription{
+			Stream:         streams.ParseId(row.Stream),
+			SubscriptionId: row.SubscriberID,
+			Position:       row.No,
+			Updated:        row.Updated,
+		})
+	}
+	return result, nil
+}
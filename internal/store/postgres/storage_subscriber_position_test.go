@@ -29,4 +29,85 @@ func TestStorage_Subscriber(t *testing.T) {
 
 	})
 
+	t.Run("list subscriptions", func(t *testing.T) {
+		// setup
+		id := streamId("subscriberL")
+		tx, err := conn.Begin()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.NoError(t, moveSubscriberPosition(ctx, tx, id, store.SubscriptionPosition{
+			SubscriptionId: "L",
+			Position:       3,
+		}))
+		assert.NoError(t, tx.Commit())
+		// execute
+		got, err := subscriptions(ctx, conn)
+		// verify
+		assert.NoError(t, err)
+		found := false
+		for _, subscription := range got {
+			if subscription.Stream == id && subscription.SubscriptionId == "L" {
+				found = true
+				assert.Equal(t, int64(3), subscription.Position)
+				assert.False(t, subscription.Updated.IsZero(), "updated")
+			}
+		}
+		assert.True(t, found, "listed")
+	})
+
+	t.Run("move subscriber position backwards", func(t *testing.T) {
+		// setup
+		id := streamId("subscriberM")
+		for _, position := range []int64{-1, 5} {
+			tx, err := conn.Begin()
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.NoError(t, updateSubscriberPosition(ctx, tx, id, store.SubscriptionPosition{
+				SubscriptionId: "M",
+				Position:       position,
+			}))
+			assert.NoError(t, tx.Commit())
+		}
+		tx, err := conn.Begin()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer func() {
+			_ = tx.Rollback()
+		}()
+		// execute
+		err = moveSubscriberPosition(ctx, tx, id, store.SubscriptionPosition{
+			SubscriptionId: "M",
+			Position:       2,
+		})
+		// verify
+		assert.NoError(t, err)
+		got, err := subscriberPositionLock(ctx, tx, id, "M")
+		assert.NoError(t, err)
+		assert.Equal(t, []store.SubscriptionPosition{{SubscriptionId: "M", Position: 2}}, got)
+	})
+
+	t.Run("first position set", func(t *testing.T) {
+		// setup
+		id := streamId("subscriberF")
+		tx, err := conn.Begin()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer func() {
+			_ = tx.Rollback()
+		}()
+		// execute
+		err = updateSubscriberPosition(ctx, tx, id, store.SubscriptionPosition{
+			SubscriptionId: "F",
+			Position:       4,
+		})
+		// verify
+		assert.NoError(t, err)
+		got, err := subscriberPositionLock(ctx, tx, id, "F")
+		assert.NoError(t, err)
+		assert.Equal(t, []store.SubscriptionPosition{{SubscriptionId: "F", Position: 4}}, got)
+	})
 }
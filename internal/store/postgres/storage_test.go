@@ -9,7 +9,7 @@ import (
 
 const databaseUrl = "postgres://po:po@localhost:5431/po?sslmode=disable"
 
-func databaseConnection(t *testing.T) *sql.DB {
+func databaseConnection(t *testing.T) dbConn {
 	if testing.Short() {
 		t.SkipNow()
 	}
@@ -25,5 +25,35 @@ func databaseConnection(t *testing.T) *sql.DB {
 	t.Cleanup(func() {
 		_ = db.Close()
 	})
-	return db
+	return dbConn{DB: db}
+}
+
+func TestStorage_Close(t *testing.T) {
+	conn := databaseConnection(t)
+
+	t.Run("database of the caller", func(t *testing.T) {
+		// setup
+		s, err := NewFromConn(conn.DB)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		err = s.Close()
+		// verify
+		assert.NoError(t, err)
+		assert.NoError(t, conn.Ping(), "left open")
+	})
+
+	t.Run("opened by the storage", func(t *testing.T) {
+		// setup
+		s, err := NewFromUrl(databaseUrl)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		err = s.Close()
+		// verify
+		assert.NoError(t, err)
+		assert.Error(t, s.conn.(dbConn).Ping(), "closed")
+	})
 }
@@ -3,19 +3,77 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 
 	"github.com/go-po/po/internal/record"
 	"github.com/go-po/po/internal/store"
 	"github.com/go-po/po/internal/store/postgres/generated/db"
 	"github.com/go-po/po/streams"
+	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
 
-func writeRecords(ctx context.Context, conn *sql.DB, id streams.Id, position int64, data ...record.Data) ([]record.Record, error) {
-	if len(data) == 0 {
+// used in place of a position to append to the end of the stream
+const endOfStream = store.AnyPosition
+
+func writeRecords(ctx context.Context, conn connection, id streams.Id, position int64, data ...record.Data) ([]record.Record, error) {
+	return writeBatch(ctx, conn, store.StreamWrite{Id: id, Position: position, Data: data})
+}
+
+// writes the notifications of the records to the outbox along with them, if the connection is an outboxConn
+func writeBatch(ctx context.Context, conn connection, writes ...store.StreamWrite) ([]record.Record, error) {
+	records, err := writeBatchTx(ctx, conn, writes...)
+	if conflict, isConflict := err.(store.WriteConflictError); isConflict {
+		// the transaction is aborted, so look up the stream outside of it
+		dao := db.New(conn)
+		conflict.Current, err = dao.GetStreamPosition(ctx, conflict.StreamId.String())
+		if err != nil {
+			return nil, err
+		}
+		if len(writes) == 1 {
+			// a concurrent append of the same messages might have won
+			records, err = deduplicate(ctx, dao, writes[0].Id, conflict.Current, writes[0].Data)
+			if records != nil || err != nil {
+				return records, err
+			}
+		}
+		return nil, conflict
+	}
+	return records, err
+}
+
+// records already written with the message ids of data
+func deduplicate(ctx context.Context, dao *db.Queries, id streams.Id, current int64, data []record.Data) ([]record.Record, error) {
+	var messageIds []uuid.UUID
+	for _, messageId := range store.MessageIds(data) {
+		parsed, err := uuid.Parse(messageId)
+		if err != nil {
+			return nil, fmt.Errorf("message id [%s]: %w", messageId, err)
+		}
+		messageIds = append(messageIds, parsed)
+	}
+	if len(messageIds) == 0 {
 		return nil, nil
 	}
-	tx, err := conn.BeginTx(ctx, nil)
+	msgs, err := dao.ReadRecordsByMessageId(ctx, messageIds)
+	if err != nil {
+		return nil, err
+	}
+	var stored []record.Record
+	for _, msg := range msgs {
+		r, err := msgToRecord(msg)
+		if err != nil {
+			return nil, err
+		}
+		stored = append(stored, r)
+	}
+	return store.Deduplicate(id, current, data, stored)
+}
+
+func writeBatchTx(ctx context.Context, conn connection, writes ...store.StreamWrite) ([]record.Record, error) {
+	_, outbox := conn.(outboxConn)
+	tx, err := conn.begin(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -25,46 +83,117 @@ func writeRecords(ctx context.Context, conn *sql.DB, id streams.Id, position int
 
 	dao := db.New(tx)
 
-	if position < 0 {
-		position, err = dao.GetStreamPosition(ctx, id.String())
+	var records []record.Record
+	for _, write := range writes {
+		written, err := writeStream(ctx, dao, outbox, write)
 		if err != nil {
 			return nil, err
 		}
+		records = append(records, written...)
+	}
+
+	return records, tx.Commit()
+}
+
+func writeStream(ctx context.Context, dao *db.Queries, outbox bool, write store.StreamWrite) ([]record.Record, error) {
+	if len(write.Data) == 0 {
+		return nil, nil
+	}
+	current, err := dao.GetStreamPosition(ctx, write.Id.String())
+	if err != nil {
+		return nil, err
+	}
+	written, err := deduplicate(ctx, dao, write.Id, current, write.Data)
+	if written != nil || err != nil {
+		return written, err
+	}
+	err = store.CheckPosition(write.Id, write.Position, current)
+	if err != nil {
+		return nil, err
 	}
 
+	position := current
 	var records []record.Record
-	for _, r := range data {
-		stored, err := writeRecord(ctx, dao, id, r, position+1)
+	for _, r := range write.Data {
+		stored, err := writeRecord(ctx, dao, write.Id, r, position+1)
 		if err != nil {
 			return nil, err
 		}
 		records = append(records, stored)
 		position = stored.Number
 	}
-
-	return records, tx.Commit()
+	if outbox {
+		err = storeNotifications(ctx, dao, records)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
 }
 
-func msgToRecord(msg db.PoMessage) record.Record {
+func msgToRecord(msg db.PoMessage) (record.Record, error) {
+	var metadata streams.Metadata
+	if len(msg.Metadata) > 0 {
+		err := json.Unmarshal(msg.Metadata, &metadata)
+		if err != nil {
+			return record.Record{}, fmt.Errorf("metadata of %s:%d: %w", msg.Stream, msg.No, err)
+		}
+	}
+	if len(metadata) == 0 {
+		metadata = nil
+	}
+	var messageId string
+	if msg.MessageID != uuid.Nil {
+		messageId = msg.MessageID.String()
+	}
 	return record.Record{
-		Number:       msg.No,
-		Stream:       streams.ParseId(msg.Stream),
-		Data:         msg.Data,
-		Group:        msg.Grp,
-		ContentType:  msg.ContentType,
-		GlobalNumber: msg.ID,
-		Time:         msg.Created,
+		MessageId:     messageId,
+		Number:        msg.No,
+		Stream:        streams.ParseId(msg.Stream),
+		Data:          msg.Data,
+		Group:         msg.Grp,
+		ContentType:   msg.ContentType,
+		GlobalNumber:  msg.ID,
+		Time:          msg.Created,
+		CorrelationId: msg.CorrelationID.String,
+		CausationId:   msg.CausationID.String,
+		Metadata:      metadata,
+	}, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{
+		String: s,
+		Valid:  s != "",
 	}
 }
 
 func writeRecord(ctx context.Context, dao *db.Queries, id streams.Id, data record.Data, position int64) (record.Record, error) {
+	var err error
+	metadata := emptyJson
+	if len(data.Metadata) > 0 {
+		metadata, err = json.Marshal(data.Metadata)
+		if err != nil {
+			return record.Record{}, fmt.Errorf("metadata: %w", err)
+		}
+	}
+	messageId := uuid.New()
+	if data.MessageId != "" {
+		messageId, err = uuid.Parse(data.MessageId)
+		if err != nil {
+			return record.Record{}, fmt.Errorf("message id [%s]: %w", data.MessageId, err)
+		}
+	}
 	stored, err := dao.StoreRecord(ctx, db.StoreRecordParams{
 		Stream:        id.String(),
 		No:            position,
 		Grp:           id.Group,
 		ContentType:   data.ContentType,
 		Data:          data.Data,
-		CorrelationID: sql.NullString{},
+		CorrelationID: nullString(data.CorrelationId),
+		CausationID:   nullString(data.CausationId),
+		Metadata:      metadata,
+		MessageID:     messageId,
 	})
 	if err != nil {
 		if err, ok := err.(*pq.Error); ok {
@@ -78,5 +207,5 @@ func writeRecord(ctx context.Context, dao *db.Queries, id streams.Id, data recor
 		}
 		return record.Record{}, err
 	}
-	return msgToRecord(stored), nil
+	return msgToRecord(stored)
 }
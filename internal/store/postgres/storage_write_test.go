@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"errors"
+	"math"
 	"math/rand"
 	"strconv"
 	"testing"
@@ -11,6 +12,7 @@ import (
 	"github.com/go-po/po/internal/record"
 	"github.com/go-po/po/internal/store"
 	"github.com/go-po/po/streams"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -86,10 +88,151 @@ func TestStorage_WriteRecords(t *testing.T) {
 			if errors.As(err, &conflict) {
 				assert.Equal(t, id.String(), conflict.StreamId.String())
 				assert.Equal(t, 4, int(conflict.Position))
+				assert.Equal(t, 4, int(conflict.Current))
 			} else {
 				t.Logf("unexpected error type: %T", err)
 				t.FailNow()
 			}
 		}
 	})
+	t.Run("stream exists", func(t *testing.T) {
+		// setup
+		id := streamId("exists")
+		_, err := writeRecords(ctx, conn, id, -1, data(2)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		_, err = writeRecords(ctx, conn, id, -1, data(1)...)
+		// verify
+		conflict := store.WriteConflictError{}
+		if assert.True(t, errors.As(err, &conflict), "write conflict error") {
+			assert.Equal(t, 0, int(conflict.Position))
+			assert.Equal(t, 1, int(conflict.Current))
+		}
+	})
+
+	t.Run("ahead of stream", func(t *testing.T) {
+		// setup
+		id := streamId("ahead")
+		_, err := writeRecords(ctx, conn, id, -1, data(2)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		_, err = writeRecords(ctx, conn, id, 4, data(1)...)
+		// verify
+		conflict := store.WriteConflictError{}
+		if assert.True(t, errors.As(err, &conflict), "write conflict error") {
+			assert.Equal(t, 1, int(conflict.Current))
+		}
+	})
+
+	t.Run("end of stream", func(t *testing.T) {
+		// setup
+		id := streamId("end")
+		_, err := writeRecords(ctx, conn, id, -1, data(2)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		got, err := writeRecords(ctx, conn, id, endOfStream, data(1)...)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(got)) {
+			assert.Equal(t, 2, int(got[0].Number))
+		}
+	})
+
+	t.Run("batch conflict writes nothing", func(t *testing.T) {
+		// setup
+		a, b := streamId("batch"), streamId("batch")
+		_, err := writeRecords(ctx, conn, b, -1, data(1)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		_, err = writeBatch(ctx, conn,
+			store.StreamWrite{Id: a, Position: -1, Data: data(1)},
+			store.StreamWrite{Id: b, Position: -1, Data: data(1)},
+		)
+		// verify
+		conflict := store.WriteConflictError{}
+		if assert.True(t, errors.As(err, &conflict), "write conflict error") {
+			assert.Equal(t, b.String(), conflict.StreamId.String())
+			assert.Equal(t, 0, int(conflict.Current))
+		}
+		records, err := readRecords(ctx, conn, a, -1, math.MaxInt64, 100)
+		assert.NoError(t, err)
+		assert.Empty(t, records)
+	})
+
+	t.Run("application transaction", func(t *testing.T) {
+		// setup
+		id := streamId("app")
+		tx, err := conn.BeginTx(ctx, nil)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer func() {
+			_ = tx.Rollback()
+		}()
+		_, err = writeRecords(ctx, appTx{DBTX: tx}, id, -1, data(1)...)
+		assert.NoError(t, err)
+		// execute
+		_, err = writeRecords(ctx, appTx{DBTX: tx}, id, -1, data(1)...)
+		// verify
+		assert.True(t, errors.As(err, &store.WriteConflictError{}), "write conflict error")
+		inside, err := readRecords(ctx, appTx{DBTX: tx}, id, -1, math.MaxInt64, 100)
+		assert.NoError(t, err, "transaction still usable")
+		assert.Equal(t, 1, len(inside))
+		outside, err := readRecords(ctx, conn, id, -1, math.MaxInt64, 100)
+		assert.NoError(t, err)
+		assert.Empty(t, outside)
+	})
+
+	t.Run("same messages", func(t *testing.T) {
+		// setup
+		id := streamId("dedup")
+		input := data(2)
+		input[0].MessageId = uuid.New().String()
+		input[1].MessageId = uuid.New().String()
+		first, err := writeRecords(ctx, conn, id, -1, input...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		got, err := writeRecords(ctx, conn, id, -1, input...)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 2, len(got)) {
+			assert.Equal(t, first[0].GlobalNumber, got[0].GlobalNumber)
+			assert.Equal(t, first[1].MessageId, got[1].MessageId)
+		}
+		records, err := readRecords(ctx, conn, id, -1, math.MaxInt64, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(records))
+	})
+
+	t.Run("correlation and metadata", func(t *testing.T) {
+		// setup
+		id := streamId("correlation")
+		input := data(1)
+		input[0].CorrelationId = "correlation id"
+		input[0].CausationId = "causation id"
+		input[0].Metadata = streams.Metadata{"user": []byte(`"peter"`)}
+		_, err := writeRecords(ctx, conn, id, -1, input...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		got, err := readRecords(ctx, conn, id, -1, math.MaxInt64, 100)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(got)) {
+			assert.Equal(t, "correlation id", got[0].CorrelationId)
+			assert.Equal(t, "causation id", got[0].CausationId)
+			assert.JSONEq(t, `"peter"`, string(got[0].Metadata["user"]))
+		}
+	})
 }
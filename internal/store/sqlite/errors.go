@@ -0,0 +1,27 @@
+package sqlite
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-po/po/internal/store"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Reports true if the error is a violation of the unique number of the records in their stream.
+// Other unique violations, like a message id written twice, are not conflicts a retry can resolve.
+func isStreamNumberConflict(err error) bool {
+	sqliteErr, ok := err.(sqlite3.Error)
+	if !ok || sqliteErr.ExtendedCode != sqlite3.ErrConstraintUnique {
+		return false
+	}
+	return strings.Contains(sqliteErr.Error(), "po_messages.stream, po_messages.no")
+}
+
+type ErrUnknownTx struct {
+	tx store.Tx
+}
+
+func (err ErrUnknownTx) Error() string {
+	return fmt.Sprintf("unknown tx type: %T", err.tx)
+}
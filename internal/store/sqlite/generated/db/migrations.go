@@ -0,0 +1,161 @@
+package db
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func bindata_read(data []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewBuffer(data))
+	if err != nil {
+		return nil, fmt.Errorf("Read %q: %v", name, err)
+	}
+
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, gz)
+	gz.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("Read %q: %v", name, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+var __1_create_records_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x6c\x00\x93\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x70\x6f\x5f\x73\x6e\x61\x70\x73\x68\x6f\x74\x73\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x70\x6f\x5f\x73\x75\x62\x73\x63\x72\x69\x70\x74\x69\x6f\x6e\x73\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x70\x6f\x5f\x6d\x65\x73\x73\x61\x67\x65\x73\x3b\x0a\x03\x00\xf6\x4c\x9f\x1b\x6c\x00\x00\x00")
+
+func _1_create_records_down_sql() ([]byte, error) {
+	return bindata_read(
+		__1_create_records_down_sql,
+		"1_create_records.down.sql",
+	)
+}
+
+var __1_create_records_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x54\xcb\x6e\xdb\x30\x10\xbc\xeb\x2b\xf6\x16\x0b\x88\xbf\xc0\x27\x39\x66\x02\xa1\x32\xdd\x2a\x14\xe0\x9c\x08\x46\x62\x65\x02\x11\x49\x90\x14\xd0\xa2\xe8\xbf\x17\xd6\xcb\x52\x2b\xc6\xac\x7d\xa1\xb1\xb3\xb3\xe3\xd9\xc7\x76\x0b\x42\x0a\x27\xd8\x07\xd8\xf2\xc2\x1b\x06\xdf\x95\x01\x77\xe1\xd0\x70\x6b\x59\xcd\x6d\xf4\x94\xa3\x84\x20\x20\xc9\x3e\x43\x90\x3e\x03\x3e\x11\x40\xe7\xf4\x95\xbc\x82\x56\x74\x82\x6d\x22\x00\x00\x51\xc1\xfc\x93\x62\x82\x5e\x50\x0e\x5f\xf3\xf4\x98\xe4\x6f\xf0\x05\xbd\x41\x52\x90\x53\x8a\x9f\x72\x74\x44\x98\x3c\x76\x59\xa5\xe1\xcc\xf1\x29\xf5\x90\x10\x44\xd2\x23\x1a\x7f\x77\xdf\x6b\x59\x5c\x64\x59\x9f\x61\x9d\xe1\xac\x19\x63\x40\xd0\x99\x8c\x6f\x4f\x86\x54\xab\xca\x3e\xc9\xa8\x8d\x1e\x03\xf7\x6b\xc0\x76\x0b\xb5\x51\xad\x06\x21\xe1\x45\x75\x04\xa5\x92\x8e\x4b\x47\xdd\x4f\xcd\xc3\x44\x56\xcc\xb1\x31\x02\x00\xfb\xec\xb4\x1f\xdf\x9e\x8c\x52\x19\xc3\x3f\x98\x13\x4a\x52\x51\x79\x6a\xdc\xd0\xac\xb5\x13\x16\xee\xa1\x1b\xee\xd8\x4c\x51\x87\x3e\xa0\xe7\xa4\xc8\x08\x3c\xfc\xfa\xfd\xf0\xaf\x9a\x61\x1a\xe8\x30\x06\x5e\xfe\x28\xde\x45\xe3\x60\xa5\xf8\x80\xce\xfe\xc1\xa2\x7d\xab\xa9\x90\x15\xff\x01\x27\x3c\x8f\xc1\xa6\x0f\xc6\xbb\x50\xb2\xda\x68\x0f\x53\x6d\xf4\x8d\xa6\xc0\xe9\xb7\x22\x58\x9a\x6c\x9b\x77\x6e\x68\xfb\x99\xc4\x47\x90\xea\xbf\xf9\x87\x07\x15\x95\x8f\xfc\x86\x98\x39\xea\x59\x55\xdb\xbe\xdb\xd2\x08\x7d\xed\xbf\x8d\x36\x2b\x9b\x37\x2d\xde\xb2\xab\xad\xae\xee\x83\x96\x1b\x39\x75\xfe\x2f\x50\x2f\xe1\xea\x96\xa8\x7c\x20\xa9\x56\x17\x75\x09\x9a\x5f\x94\xc9\xe2\xe1\x1f\xf6\xf4\x71\x14\x60\x89\x64\xda\x5e\x94\x5b\xb5\x23\xc4\x8d\x10\x33\x7c\x5e\x0c\xb5\xbb\x65\x09\xb1\xc2\xe3\xc4\xe2\xca\x78\x78\x16\x77\x65\xba\x2a\x21\x8e\xde\x44\xc6\x51\xbc\x8b\xfe\x0c\x00\x67\xa0\xe8\x7a\x30\x06\x00\x00")
+
+func _1_create_records_up_sql() ([]byte, error) {
+	return bindata_read(
+		__1_create_records_up_sql,
+		"1_create_records.up.sql",
+	)
+}
+
+var __2_leases_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x20\x00\xdf\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x70\x6f\x5f\x6c\x65\x61\x73\x65\x73\x3b\x0a\x03\x00\xb5\x16\x64\xaf\x20\x00\x00\x00")
+
+func _2_leases_down_sql() ([]byte, error) {
+	return bindata_read(
+		__2_leases_down_sql,
+		"2_leases.down.sql",
+	)
+}
+
+var __2_leases_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\x8f\xc1\x6a\x84\x30\x10\x86\xef\x79\x8a\xff\xd8\x82\x3e\x41\x4f\xb6\x4c\x4b\xa8\xb5\x25\xa6\xa0\xa7\x12\xd2\x69\x0d\xab\x13\x31\x2e\xfa\xf8\x8b\xca\xee\x69\x4f\x1f\xc3\xf7\x31\xf0\xe7\x39\x78\x1d\xc3\xc4\x09\x41\x70\x96\xb0\x42\x9c\xc4\xc4\x3e\xca\x6f\xca\xe0\xe3\x30\xba\x29\xc8\x3f\x7a\x76\x89\x13\x96\x30\x77\x98\x3b\x86\xef\xa3\x3f\x21\xfe\xed\x47\x90\x34\x3b\xf1\x9c\xd4\x8b\xa1\xc2\x12\x6c\xf1\x5c\x12\xf4\x2b\xaa\x4f\x0b\x6a\x74\x6d\x6b\x8c\xf1\xe7\x78\xa2\x1e\x14\x00\x88\x1b\x78\xa3\xa5\xc6\x6e\xdc\xd2\xea\xbb\x2c\xf1\x65\xf4\x47\x61\x5a\xbc\x53\x9b\xed\x65\x5c\x84\xa7\x3b\xe5\x61\xaf\x03\x74\x65\xe9\x8d\xcc\xcd\xaa\xc7\x27\x75\x19\x00\x51\x6d\x82\x6f\xe0\x00\x00\x00")
+
+func _2_leases_up_sql() ([]byte, error) {
+	return bindata_read(
+		__2_leases_up_sql,
+		"2_leases.up.sql",
+	)
+}
+
+var __3_outbox_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x20\x00\xdf\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x70\x6f\x5f\x6f\x75\x74\x62\x6f\x78\x3b\x0a\x03\x00\x07\xaf\x46\x2b\x20\x00\x00\x00")
+
+func _3_outbox_down_sql() ([]byte, error) {
+	return bindata_read(
+		__3_outbox_down_sql,
+		"3_outbox.down.sql",
+	)
+}
+
+var __3_outbox_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x64\xcd\xb1\x4e\xc3\x30\x10\x06\xe0\x3d\x4f\xf1\x8f\x20\xb5\x4f\xc0\x64\xca\x81\x2c\x12\x17\xb9\x57\xa9\x9d\x2a\x27\x71\x5b\x4b\xc6\x17\x39\x8e\xa0\x6f\x8f\x52\x98\xc2\x2d\x77\xc3\xf7\xdf\xbf\x5e\x23\x49\x09\xe7\xd0\xb9\x12\x24\x8d\x90\x33\xbe\x72\x28\xc5\x27\x64\xdf\x49\xee\xc7\x15\x06\x9f\xfa\x90\x2e\x98\x52\x09\x11\xc3\xd4\xc6\x30\x5e\x7d\x8f\xf6\x86\x72\xf5\xc8\x3e\xba\x5b\xb5\xb1\xa4\x98\xc0\xea\xb9\x26\xe8\x57\x98\x2d\x83\x0e\x7a\xc7\x3b\x0c\x72\x92\xa9\xb4\xf2\x5d\x3d\x54\x00\x10\x7a\xfc\x8d\x36\x4c\x6f\x64\xf1\x61\x75\xa3\xec\x11\xef\x74\x84\xda\xf3\x56\x9b\x8d\xa5\x86\x0c\xaf\xee\x81\x2e\x7b\x57\xfc\x9c\x7a\x51\x4c\xac\x1b\xba\xbf\x37\xfb\xba\xfe\x05\x63\xc9\xde\x7d\xce\x17\xd3\x81\xe7\xbd\x00\x49\x96\x95\x0b\x70\x89\xd2\xba\x78\x4a\xf2\x1f\x54\x8f\x4f\xd5\xcf\x00\x44\x28\x18\x44\x28\x01\x00\x00")
+
+func _3_outbox_up_sql() ([]byte, error) {
+	return bindata_read(
+		__3_outbox_up_sql,
+		"3_outbox.up.sql",
+	)
+}
+
+// Asset loads and returns the asset for the given name.
+// It returns an error if the asset could not be found or
+// could not be loaded.
+func Asset(name string) ([]byte, error) {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	if f, ok := _bindata[cannonicalName]; ok {
+		return f()
+	}
+	return nil, fmt.Errorf("Asset %s not found", name)
+}
+
+// AssetNames returns the names of the assets.
+func AssetNames() []string {
+	names := make([]string, 0, len(_bindata))
+	for name := range _bindata {
+		names = append(names, name)
+	}
+	return names
+}
+
+// _bindata is a table, holding each asset generator, mapped to its name.
+var _bindata = map[string]func() ([]byte, error){
+	"1_create_records.down.sql": _1_create_records_down_sql,
+	"1_create_records.up.sql":   _1_create_records_up_sql,
+	"2_leases.down.sql":         _2_leases_down_sql,
+	"2_leases.up.sql":           _2_leases_up_sql,
+	"3_outbox.down.sql":         _3_outbox_down_sql,
+	"3_outbox.up.sql":           _3_outbox_up_sql,
+}
+
+// AssetDir returns the file names below a certain
+// directory embedded in the file by go-bindata.
+// For example if you run go-bindata on data/... and data contains the
+// following hierarchy:
+//
+//	data/
+//	  foo.txt
+//	  img/
+//	    a.png
+//	    b.png
+//
+// then AssetDir("data") would return []string{"foo.txt", "img"}
+// AssetDir("data/img") would return []string{"a.png", "b.png"}
+// AssetDir("foo.txt") and AssetDir("notexist") would return an error
+// AssetDir("") will return []string{"data"}.
+func AssetDir(name string) ([]string, error) {
+	node := _bintree
+	if len(name) != 0 {
+		cannonicalName := strings.Replace(name, "\\", "/", -1)
+		pathList := strings.Split(cannonicalName, "/")
+		for _, p := range pathList {
+			node = node.Children[p]
+			if node == nil {
+				return nil, fmt.Errorf("Asset %s not found", name)
+			}
+		}
+	}
+	if node.Func != nil {
+		return nil, fmt.Errorf("Asset %s not found", name)
+	}
+	rv := make([]string, 0, len(node.Children))
+	for name := range node.Children {
+		rv = append(rv, name)
+	}
+	return rv, nil
+}
+
+type _bintree_t struct {
+	Func     func() ([]byte, error)
+	Children map[string]*_bintree_t
+}
+
+var _bintree = &_bintree_t{nil, map[string]*_bintree_t{
+	"1_create_records.down.sql": &_bintree_t{_1_create_records_down_sql, map[string]*_bintree_t{}},
+	"1_create_records.up.sql":   &_bintree_t{_1_create_records_up_sql, map[string]*_bintree_t{}},
+	"2_leases.down.sql":         &_bintree_t{_2_leases_down_sql, map[string]*_bintree_t{}},
+	"2_leases.up.sql":           &_bintree_t{_2_leases_up_sql, map[string]*_bintree_t{}},
+	"3_outbox.down.sql":         &_bintree_t{_3_outbox_down_sql, map[string]*_bintree_t{}},
+	"3_outbox.up.sql":           &_bintree_t{_3_outbox_up_sql, map[string]*_bintree_t{}},
+}}
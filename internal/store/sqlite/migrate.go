@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+
+	"github.com/go-po/po/internal/store/sqlite/generated/db"
+	"github.com/golang-migrate/migrate/v4"
+	bindata "github.com/golang-migrate/migrate/v4/source/go_bindata"
+)
+
+func migrateDatabase(conn *sql.DB) error {
+
+	d, err := sqlite3.WithInstance(conn, &sqlite3.Config{
+		MigrationsTable: "po_migrations",
+	})
+	if err != nil {
+		return fmt.Errorf("driver: %w", err)
+	}
+
+	resource := bindata.Resource(db.AssetNames(),
+		func(name string) ([]byte, error) {
+			return db.Asset(name)
+		})
+	data, err := bindata.WithInstance(resource)
+	if err != nil {
+		return fmt.Errorf("bindata: %w", err)
+	}
+
+	migrates, err := migrate.NewWithInstance("migrations", data, "po", d)
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	err = migrates.Up()
+
+	switch err {
+	case migrate.ErrNoChange:
+		return nil
+	case nil:
+		return nil
+	default:
+		return fmt.Errorf("up: %w", err)
+	}
+}
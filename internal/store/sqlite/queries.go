@@ -0,0 +1,144 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// implemented by both *sql.DB and *sql.Tx
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+const messageColumns = `id, created, stream, no, grp, content_type, data, correlation_id, causation_id, metadata, message_id`
+
+const getStreamPosition = `-- name: GetStreamPosition
+SELECT COALESCE(MAX(no), -1) FROM po_messages WHERE stream = ?`
+
+const getGroupPosition = `-- name: GetGroupPosition
+SELECT COALESCE(MAX(id), -1) FROM po_messages WHERE grp = ?`
+
+const getStreamPositionBefore = `-- name: GetStreamPositionBefore
+SELECT COALESCE(MAX(no), -1) FROM po_messages WHERE stream = ? AND created < ?`
+
+const getGroupPositionBefore = `-- name: GetGroupPositionBefore
+SELECT COALESCE(MAX(id), -1) FROM po_messages WHERE grp = ? AND created < ?`
+
+const countRecordsByStream = `-- name: CountRecordsByStream
+SELECT COUNT(*) FROM po_messages WHERE stream = ? AND no > ?`
+
+const countRecordsByGroup = `-- name: CountRecordsByGroup
+SELECT COUNT(*) FROM po_messages WHERE grp = ? AND id > ?`
+
+const storeRecord = `-- name: StoreRecord
+INSERT INTO po_messages (created, stream, no, grp, content_type, data, correlation_id, causation_id, metadata, message_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+const readRecordsByStream = `-- name: ReadRecordsByStream
+SELECT ` + messageColumns + ` FROM po_messages
+WHERE stream = ? AND no > ? AND no <= ?
+ORDER BY no
+LIMIT ?`
+
+const readRecordsByGroup = `-- name: ReadRecordsByGroup
+SELECT ` + messageColumns + ` FROM po_messages
+WHERE grp = ? AND id > ? AND id <= ?
+ORDER BY id
+LIMIT ?`
+
+func readRecordsByStreamOfTypes(count int) string {
+	return `-- name: ReadRecordsByStreamOfTypes
+SELECT ` + messageColumns + ` FROM po_messages
+WHERE stream = ? AND no > ? AND no <= ? AND content_type IN (` + placeholders(count) + `)
+ORDER BY no
+LIMIT ?`
+}
+
+func readRecordsByGroupOfTypes(count int) string {
+	return `-- name: ReadRecordsByGroupOfTypes
+SELECT ` + messageColumns + ` FROM po_messages
+WHERE grp = ? AND id > ? AND id <= ? AND content_type IN (` + placeholders(count) + `)
+ORDER BY id
+LIMIT ?`
+}
+
+const readRecordByMessageId = `-- name: ReadRecordByMessageId
+SELECT ` + messageColumns + ` FROM po_messages
+WHERE message_id = ?`
+
+const getSnapshot = `-- name: GetSnapshot
+SELECT no, content_type, data FROM po_snapshots
+WHERE stream = ? AND snapshot_id = ?`
+
+const updateSnapshotQuery = `-- name: UpdateSnapshot
+INSERT INTO po_snapshots (created, updated, stream, snapshot_id, no, content_type, data)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (stream, snapshot_id) DO UPDATE
+SET updated = excluded.updated, no = excluded.no, content_type = excluded.content_type, data = excluded.data`
+
+const deleteSnapshotQuery = `-- name: DeleteSnapshot
+DELETE FROM po_snapshots WHERE stream = ? AND snapshot_id = ?`
+
+const getSubscriberPosition = `-- name: GetSubscriberPosition
+SELECT no FROM po_subscriptions
+WHERE stream = ? AND subscriber_id = ?`
+
+const getSubscriptions = `-- name: GetSubscriptions
+SELECT stream, subscriber_id, no, updated FROM po_subscriptions
+ORDER BY stream, subscriber_id`
+
+const acquireLeaseQuery = `-- name: AcquireLease
+INSERT INTO po_leases (name, owner, expires)
+VALUES (?, ?, ?)
+ON CONFLICT (name) DO UPDATE
+SET owner = excluded.owner, expires = excluded.expires
+WHERE po_leases.owner = excluded.owner OR po_leases.expires < ?`
+
+const getLeaseOwner = `-- name: GetLeaseOwner
+SELECT owner FROM po_leases WHERE name = ?`
+
+const releaseLeaseQuery = `-- name: ReleaseLease
+DELETE FROM po_leases WHERE name = ? AND owner = ?`
+
+const storeNotification = `-- name: StoreNotification
+INSERT INTO po_outbox (created, stream, no, global_no) VALUES (?, ?, ?, ?)`
+
+const getNotifications = `-- name: GetNotifications
+SELECT id, stream, no, global_no, created FROM po_outbox
+ORDER BY id
+LIMIT ?`
+
+func deleteNotifications(count int) string {
+	return `-- name: DeleteNotifications
+DELETE FROM po_outbox WHERE id IN (` + placeholders(count) + `)`
+}
+
+const countNotificationsQuery = `-- name: CountNotifications
+SELECT COUNT(*) FROM po_outbox`
+
+const getLeases = `-- name: GetLeases
+SELECT name, owner, expires FROM po_leases
+WHERE substr(name, 1, length(?)) = ? AND expires > ?
+ORDER BY name`
+
+// positions only move forward
+const setSubscriberPosition = `-- name: SetSubscriberPosition
+INSERT INTO po_subscriptions (created, updated, stream, subscriber_id, no)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (stream, subscriber_id) DO UPDATE
+SET updated = excluded.updated, no = excluded.no
+WHERE po_subscriptions.no < excluded.no`
+
+// moves backwards as well
+const moveSubscriberPositionQuery = `-- name: MoveSubscriberPosition
+INSERT INTO po_subscriptions (created, updated, stream, subscriber_id, no)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (stream, subscriber_id) DO UPDATE
+SET updated = excluded.updated, no = excluded.no`
+
+func placeholders(count int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", count), ", ")
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS po_snapshots;
+DROP TABLE IF EXISTS po_subscriptions;
+DROP TABLE IF EXISTS po_messages;
@@ -0,0 +1,42 @@
+-- initial schema for the messages
+CREATE TABLE IF NOT EXISTS po_messages
+(
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    created        DATETIME             NOT NULL,
+    stream         TEXT                 NOT NULL,
+    no             INTEGER              NOT NULL,
+    grp            TEXT                 NOT NULL, -- group in Go
+    content_type   TEXT                 NOT NULL,
+    data           BLOB                 NOT NULL,
+    correlation_id TEXT                 NULL,
+    causation_id   TEXT                 NULL,
+    metadata       TEXT DEFAULT '{}'    NOT NULL,
+    message_id     TEXT                 NULL
+);
+
+CREATE INDEX IF NOT EXISTS po_messages_stream_index ON po_messages (stream);
+CREATE INDEX IF NOT EXISTS po_messages_grp_index ON po_messages (grp);
+CREATE UNIQUE INDEX IF NOT EXISTS po_messages_stream_number_uindex ON po_messages (stream, no);
+CREATE UNIQUE INDEX IF NOT EXISTS po_messages_message_id_uindex ON po_messages (message_id);
+
+CREATE TABLE IF NOT EXISTS po_subscriptions
+(
+    created       DATETIME NOT NULL,
+    updated       DATETIME NOT NULL,
+    stream        TEXT     NOT NULL,
+    subscriber_id TEXT     NOT NULL,
+    no            INTEGER  NOT NULL,
+    PRIMARY KEY (stream, subscriber_id)
+);
+
+CREATE TABLE IF NOT EXISTS po_snapshots
+(
+    created      DATETIME NOT NULL,
+    updated      DATETIME NOT NULL,
+    stream       TEXT     NOT NULL,
+    snapshot_id  TEXT     NOT NULL,
+    no           INTEGER  NOT NULL,
+    content_type TEXT     NOT NULL,
+    data         BLOB     NOT NULL,
+    PRIMARY KEY (stream, snapshot_id)
+);
@@ -0,0 +1,7 @@
+-- expires in unix nanoseconds, comparing leases with the clock of the instances
+CREATE TABLE IF NOT EXISTS po_leases
+(
+    name    TEXT    NOT NULL PRIMARY KEY,
+    owner   TEXT    NOT NULL,
+    expires INTEGER NOT NULL
+);
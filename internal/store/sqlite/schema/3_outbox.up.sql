@@ -0,0 +1,9 @@
+-- notifications of written records, pending until published by the relay
+CREATE TABLE IF NOT EXISTS po_outbox
+(
+    id        INTEGER PRIMARY KEY AUTOINCREMENT,
+    created   DATETIME NOT NULL,
+    stream    TEXT     NOT NULL,
+    no        INTEGER  NOT NULL,
+    global_no INTEGER  NOT NULL
+);
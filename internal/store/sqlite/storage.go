@@ -0,0 +1,215 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
+	_ "github.com/mattn/go-sqlite3" // required
+)
+
+// Opens the database file at path, creating it if missing.
+// Writes take the database lock when they begin, waiting on other writers
+// for up to the busy timeout.
+func New(path string) (*Storage, error) {
+	conn, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate", path))
+	if err != nil {
+		return nil, err
+	}
+	err = migrateDatabase(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return &Storage{
+		conn:        conn,
+		locks:       make(map[string]*sync.Mutex),
+		streamLocks: make(map[string]chan struct{}),
+	}, nil
+}
+
+type Storage struct {
+	conn   *sql.DB
+	outbox bool // writes notifications to the outbox
+
+	mu          sync.Mutex               // protects the lock maps and relaying
+	locks       map[string]*sync.Mutex   // subscriber position locks by stream and subscriber id
+	streamLocks map[string]chan struct{} // stream locks by stream id, held while full
+	relaying    bool                     // the outbox is claimed by a relay
+}
+
+// Closes the database
+func (store *Storage) Close() error {
+	return store.conn.Close()
+}
+
+func (store *Storage) WriteRecords(ctx context.Context, id streams.Id, data ...record.Data) ([]record.Record, error) {
+	return writeRecords(ctx, store.writer(), id, endOfStream, data...)
+}
+
+func (store *Storage) WriteRecordsFrom(ctx context.Context, id streams.Id, position int64, data ...record.Data) ([]record.Record, error) {
+	return writeRecords(ctx, store.writer(), id, position, data...)
+}
+
+// Writes to all the streams or none of them
+func (store *Storage) WriteBatch(ctx context.Context, writes ...store.StreamWrite) ([]record.Record, error) {
+	return writeBatch(ctx, store.writer(), writes...)
+}
+
+func (store *Storage) ReadRecords(ctx context.Context, id streams.Id, from int64, to, limit int64) ([]record.Record, error) {
+	return readRecords(ctx, store.conn, id, from, to, limit)
+}
+
+// Records of the content types only, and the position the read got to, past the records of other types
+func (store *Storage) ReadRecordsOfTypes(ctx context.Context, id streams.Id, from, to, limit int64, contentTypes []string) ([]record.Record, int64, error) {
+	return readRecordsOfTypes(ctx, store.conn, id, from, to, limit, contentTypes)
+}
+
+// Position of the last record of the stream, -1 if it is empty
+func (store *Storage) HeadPosition(ctx context.Context, id streams.Id) (int64, error) {
+	return headPosition(ctx, store.conn, id)
+}
+
+// Position of the last record of the stream written before the time, -1 if there is none
+func (store *Storage) PositionBefore(ctx context.Context, id streams.Id, t time.Time) (int64, error) {
+	return positionBefore(ctx, store.conn, id, t)
+}
+
+// Number of records of the stream after the position
+func (store *Storage) CountRecords(ctx context.Context, id streams.Id, after int64) (int64, error) {
+	return countRecords(ctx, store.conn, id, after)
+}
+
+// Stored positions of every subscription
+func (store *Storage) ListSubscriptions(ctx context.Context) ([]store.Subscription, error) {
+	return subscriptions(ctx, store.conn)
+}
+
+func (store *Storage) ReadSnapshot(ctx context.Context, id streams.Id, snapshotId string) (record.Snapshot, error) {
+	return readSnapshot(ctx, store.conn, id, snapshotId)
+}
+
+func (store *Storage) UpdateSnapshot(ctx context.Context, id streams.Id, snapshotId string, snapshot record.Snapshot) error {
+	return updateSnapshot(ctx, store.conn, id, snapshotId, snapshot)
+}
+
+func (store *Storage) DeleteSnapshot(ctx context.Context, id streams.Id, snapshotId string) error {
+	return deleteSnapshot(ctx, store.conn, id, snapshotId)
+}
+
+// Subscriber positions are held in the transaction and written when it commits,
+// so the database lock is not held while subscribers handle messages.
+// database the records are written to, along with their notifications once the outbox is enabled
+func (store *Storage) writer() database {
+	if store.outbox {
+		return outboxDB{DB: store.conn}
+	}
+	return store.conn
+}
+
+func (store *Storage) Begin(ctx context.Context) (store.Tx, error) {
+	return &storageTx{
+		ctx:       ctx,
+		conn:      store.conn,
+		positions: make(map[string]map[string]int64),
+		moved:     make(map[string]map[string]bool),
+	}, nil
+}
+
+func (store *Storage) SubscriptionPositionLock(storeTx store.Tx, id streams.Id, subscriptionIds ...string) ([]store.SubscriptionPosition, error) {
+	tx, isTx := storeTx.(*storageTx)
+	if !isTx {
+		return nil, ErrUnknownTx{tx: storeTx}
+	}
+	if len(subscriptionIds) == 0 {
+		return nil, nil
+	}
+	tx.lockPositions(subscriptionIds, func(subscriptionId string) *sync.Mutex {
+		return store.positionLock(id, subscriptionId)
+	})
+	return subscriberPositions(tx.ctx, store.conn, id, subscriptionIds...)
+}
+
+func (store *Storage) SetSubscriptionPosition(storeTx store.Tx, id streams.Id, position store.SubscriptionPosition) error {
+	tx, isTx := storeTx.(*storageTx)
+	if !isTx {
+		return ErrUnknownTx{tx: storeTx}
+	}
+	tx.setPosition(id, position)
+	return nil
+}
+
+// Sets the position of the subscription when the transaction commits, even if lower than its current position
+func (store *Storage) MoveSubscriptionPosition(storeTx store.Tx, id streams.Id, position store.SubscriptionPosition) error {
+	tx, isTx := storeTx.(*storageTx)
+	if !isTx {
+		return ErrUnknownTx{tx: storeTx}
+	}
+	tx.movePosition(id, position)
+	return nil
+}
+
+// Locks the stream against other lockers in this process until the returned transaction ends.
+// A zero timeout waits for as long as the context allows.
+func (store *Storage) LockStream(ctx context.Context, id streams.Id, timeout time.Duration) (store.Tx, error) {
+	return lockStream(ctx, store.streamLock(id), id, timeout)
+}
+
+func (store *Storage) positionLock(id streams.Id, subscriptionId string) *sync.Mutex {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	key := id.String() + "\x00" + subscriptionId
+	lock, found := store.locks[key]
+	if !found {
+		lock = &sync.Mutex{}
+		store.locks[key] = lock
+	}
+	return lock
+}
+
+func (store *Storage) streamLock(id streams.Id) chan struct{} {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	lock, found := store.streamLocks[id.String()]
+	if !found {
+		lock = make(chan struct{}, 1)
+		store.streamLocks[id.String()] = lock
+	}
+	return lock
+}
+
+// Takes or renews the lease for the owner, reporting false if another owner holds it
+func (store *Storage) AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	return acquireLease(ctx, store.conn, name, owner, ttl)
+}
+
+// Gives up the lease, if held by the owner
+func (store *Storage) ReleaseLease(ctx context.Context, name, owner string) error {
+	return releaseLease(ctx, store.conn, name, owner)
+}
+
+// Unexpired leases with names starting with the prefix
+func (store *Storage) Leases(ctx context.Context, prefix string) ([]store.Lease, error) {
+	return leases(ctx, store.conn, prefix)
+}
+
+// Writes the notifications of records to the outbox in the transaction writing them, from now on
+func (store *Storage) EnableOutbox() {
+	store.outbox = true
+}
+
+// Claims the oldest notifications not claimed by another relay, up to the limit, and publishes them in order.
+// The published are removed from the outbox, the rest are kept after a failure.
+func (store *Storage) RelayNotifications(ctx context.Context, limit int, publish func(n store.Notification) error) (int, error) {
+	return store.relayNotifications(ctx, store.conn, limit, publish)
+}
+
+// Number of notifications in the outbox
+func (store *Storage) CountNotifications(ctx context.Context) (int64, error) {
+	return countNotifications(ctx, store.conn)
+}
@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/go-po/po/internal/store"
+)
+
+// Takes or renews the lease, reporting false if another owner holds it.
+// Expiry uses the clock of the instance, shared by every process using the database file.
+func acquireLease(ctx context.Context, conn *sql.DB, name, owner string, ttl time.Duration) (bool, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	now := time.Now()
+	_, err = tx.ExecContext(ctx, acquireLeaseQuery, name, owner, now.Add(ttl).UnixNano(), now.UnixNano())
+	if err != nil {
+		return false, err
+	}
+	var current string
+	err = tx.QueryRowContext(ctx, getLeaseOwner, name).Scan(&current)
+	if err != nil {
+		return false, err
+	}
+	return current == owner, tx.Commit()
+}
+
+func releaseLease(ctx context.Context, conn dbtx, name, owner string) error {
+	_, err := conn.ExecContext(ctx, releaseLeaseQuery, name, owner)
+	return err
+}
+
+func leases(ctx context.Context, conn dbtx, prefix string) ([]store.Lease, error) {
+	rows, err := conn.QueryContext(ctx, getLeases, prefix, prefix, time.Now().UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var result []store.Lease
+	for rows.Next() {
+		lease := store.Lease{}
+		var expires int64
+		err = rows.Scan(&lease.Name, &lease.Owner, &expires)
+		if err != nil {
+			return nil, err
+		}
+		lease.Expires = time.Unix(0, expires)
+		result = append(result, lease)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,46 @@
+package sqlite
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
+)
+
+// Takes the lock of the stream, held until the returned transaction ends.
+// SQLite has no lock scoped to a single stream, so the lock only
+// covers lockers within the same process.
+func lockStream(ctx context.Context, lock chan struct{}, id streams.Id, timeout time.Duration) (store.Tx, error) {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case lock <- struct{}{}:
+		return &streamLockTx{lock: lock}, nil
+	case <-expired:
+		return nil, store.LockTimeoutError{StreamId: id}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type streamLockTx struct {
+	lock chan struct{}
+	once sync.Once
+}
+
+func (tx *streamLockTx) Commit() error {
+	tx.once.Do(func() {
+		<-tx.lock
+	})
+	return nil
+}
+
+func (tx *streamLockTx) Rollback() error {
+	return tx.Commit()
+}
@@ -0,0 +1,50 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-po/po/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorage_LockStream(t *testing.T) {
+	// setup
+	s := storage(t)
+	ctx := context.Background()
+	id := streamId("lock")
+
+	held, err := s.LockStream(ctx, id, time.Second)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	t.Run("timeout", func(t *testing.T) {
+		// execute
+		_, err := s.LockStream(ctx, id, 10*time.Millisecond)
+		// verify
+		assert.True(t, errors.Is(err, store.LockTimeoutError{}), "lock timeout error")
+	})
+
+	t.Run("other stream", func(t *testing.T) {
+		// execute
+		lock, err := s.LockStream(ctx, streamId("lock"), 10*time.Millisecond)
+		// verify
+		if assert.NoError(t, err) {
+			assert.NoError(t, lock.Commit())
+		}
+	})
+
+	t.Run("released", func(t *testing.T) {
+		// setup
+		assert.NoError(t, held.Commit())
+		// execute
+		lock, err := s.LockStream(ctx, id, 10*time.Millisecond)
+		// verify
+		if assert.NoError(t, err) {
+			assert.NoError(t, lock.Commit())
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
+)
+
+func readRecords(ctx context.Context, conn dbtx, id streams.Id, from, to, limit int64) ([]record.Record, error) {
+
+	if limit > math.MaxInt32 || limit < 1 {
+		return nil, fmt.Errorf("limit cap: %d", limit)
+	}
+
+	var rows *sql.Rows
+	var err error
+	if id.HasEntity() {
+		rows, err = conn.QueryContext(ctx, readRecordsByStream, id.String(), from, to, limit)
+	} else {
+		rows, err = conn.QueryContext(ctx, readRecordsByGroup, id.Group, from, to, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return scanRecords(rows)
+}
+
+// Records of the content types, read up to the head of the stream, and the position the read got to
+func readRecordsOfTypes(ctx context.Context, conn dbtx, id streams.Id, from, to, limit int64, contentTypes []string) ([]record.Record, int64, error) {
+	if limit > math.MaxInt32 || limit < 1 {
+		return nil, from, fmt.Errorf("limit cap: %d", limit)
+	}
+	head, err := headPosition(ctx, conn, id)
+	if err != nil {
+		return nil, from, err
+	}
+	if head < to {
+		to = head
+	}
+	if to <= from || len(contentTypes) == 0 {
+		return nil, from, nil
+	}
+
+	var query string
+	var args []interface{}
+	if id.HasEntity() {
+		query = readRecordsByStreamOfTypes(len(contentTypes))
+		args = append(args, id.String(), from, to)
+	} else {
+		query = readRecordsByGroupOfTypes(len(contentTypes))
+		args = append(args, id.Group, from, to)
+	}
+	for _, contentType := range contentTypes {
+		args = append(args, contentType)
+	}
+	args = append(args, limit)
+
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, from, err
+	}
+	records, err := scanRecords(rows)
+	if err != nil {
+		return nil, from, err
+	}
+	return records, store.FilteredPosition(id, records, limit, to), nil
+}
+
+// reads all the rows and closes them
+func scanRecords(rows *sql.Rows) ([]record.Record, error) {
+	defer func() {
+		_ = rows.Close()
+	}()
+	var records []record.Record
+	for rows.Next() {
+		var r record.Record
+		var stream string
+		var correlationId, causationId, messageId sql.NullString
+		var metadata []byte
+		err := rows.Scan(
+			&r.GlobalNumber,
+			&r.Time,
+			&stream,
+			&r.Number,
+			&r.Group,
+			&r.ContentType,
+			&r.Data,
+			&correlationId,
+			&causationId,
+			&metadata,
+			&messageId,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if len(metadata) > 0 {
+			err = json.Unmarshal(metadata, &r.Metadata)
+			if err != nil {
+				return nil, fmt.Errorf("metadata of %s:%d: %w", stream, r.Number, err)
+			}
+		}
+		if len(r.Metadata) == 0 {
+			r.Metadata = nil
+		}
+		r.Stream = streams.ParseId(stream)
+		r.CorrelationId = correlationId.String
+		r.CausationId = causationId.String
+		r.MessageId = messageId.String
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
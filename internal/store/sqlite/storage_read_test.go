@@ -0,0 +1,147 @@
+package sqlite
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorage_ReadRecords(t *testing.T) {
+	// setup
+	s := storage(t)
+	ctx := context.Background()
+
+	t.Run("empty group", func(t *testing.T) {
+		// setup
+		id := streamId("")
+		// execute
+		records, err := s.ReadRecords(ctx, id, -1, math.MaxInt64, 100)
+		// verify
+		assert.NoError(t, err)
+		assert.Empty(t, records)
+	})
+
+	t.Run("mid stream", func(t *testing.T) {
+		// setup
+		id := streamId("entity")
+		_, err := s.WriteRecordsFrom(ctx, id, -1, data(10)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		records, err := s.ReadRecords(ctx, id, 4, 7, 100)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 3, len(records)) {
+			assert.Equal(t, 5, int(records[0].Number))
+			assert.Equal(t, 7, int(records[2].Number))
+			assert.Equal(t, id.String(), records[0].Stream.String())
+		}
+	})
+
+	t.Run("mid group", func(t *testing.T) {
+		// setup
+		group := streamId("")
+		id1 := group.WithEntity("entity-1")
+		id2 := group.WithEntity("entity-2")
+		var middle int64
+		for i := int64(0); i < 5; i++ {
+			r, err := s.WriteRecordsFrom(ctx, id1, i-1, data(1)...)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			if i == 2 {
+				middle = r[0].GlobalNumber
+			}
+			_, err = s.WriteRecordsFrom(ctx, id2, i-1, data(1)...)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+		}
+		// execute
+		records, err := s.ReadRecords(ctx, group, middle, math.MaxInt64, 100)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 5, len(records)) {
+			assert.Equal(t, 4, int(records[3].Number))
+			assert.Equal(t, group.Group, records[0].Group)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		// setup
+		id := streamId("limit")
+		_, err := s.WriteRecords(ctx, id, data(5)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		records, err := s.ReadRecords(ctx, id, -1, math.MaxInt64, 2)
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(records))
+	})
+}
+
+func TestStorage_ReadRecordsOfTypes(t *testing.T) {
+	// setup
+	s := storage(t)
+	ctx := context.Background()
+	group := streamId("")
+	id := group.WithEntity("entity")
+	var written []record.Record
+	for _, contentType := range []string{"a", "b", "a", "b", "b"} {
+		r, err := s.WriteRecords(ctx, id, record.Data{ContentType: contentType, Data: []byte("{}")})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		written = append(written, r...)
+	}
+
+	t.Run("stream", func(t *testing.T) {
+		// execute
+		records, position, err := s.ReadRecordsOfTypes(ctx, id, -1, math.MaxInt64, 100, []string{"a"})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), position)
+		if assert.Equal(t, 2, len(records)) {
+			assert.Equal(t, int64(0), records[0].Number)
+			assert.Equal(t, int64(2), records[1].Number)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		// execute
+		records, position, err := s.ReadRecordsOfTypes(ctx, id, -1, math.MaxInt64, 2, []string{"b"})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), position)
+		if assert.Equal(t, 2, len(records)) {
+			assert.Equal(t, int64(1), records[0].Number)
+			assert.Equal(t, int64(3), records[1].Number)
+		}
+	})
+
+	t.Run("group", func(t *testing.T) {
+		// execute
+		records, position, err := s.ReadRecordsOfTypes(ctx, group, written[0].GlobalNumber, math.MaxInt64, 100, []string{"a"})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, written[4].GlobalNumber, position)
+		if assert.Equal(t, 1, len(records)) {
+			assert.Equal(t, written[2].GlobalNumber, records[0].GlobalNumber)
+		}
+	})
+
+	t.Run("no records of the types", func(t *testing.T) {
+		// execute
+		records, position, err := s.ReadRecordsOfTypes(ctx, id, 0, math.MaxInt64, 100, []string{"c"})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), position)
+		assert.Empty(t, records)
+	})
+}
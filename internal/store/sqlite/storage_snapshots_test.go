@@ -0,0 +1,77 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorage_Snapshot(t *testing.T) {
+	// setup
+	s := storage(t)
+	ctx := context.Background()
+
+	t.Run("read nil snapshot", func(t *testing.T) {
+		// setup
+		id := streamId("nil")
+		// execute
+		snapshot, err := s.ReadSnapshot(ctx, id, "not there")
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, -1, int(snapshot.Position))
+		assert.Equal(t, []byte(`{}`), snapshot.Data)
+	})
+
+	t.Run("write/write/read", func(t *testing.T) {
+		// setup
+		id := streamId("write/write/read")
+		err := s.UpdateSnapshot(ctx, id, "snapshot", record.Snapshot{
+			Data:        []byte(`{ "Key" : 1 }`),
+			Position:    4,
+			ContentType: "application/json",
+		})
+		if !assert.NoError(t, err, "write") {
+			t.FailNow()
+		}
+		err = s.UpdateSnapshot(ctx, id, "snapshot", record.Snapshot{
+			Data:        []byte(`{ "Key" : 2 }`),
+			Position:    5,
+			ContentType: "application/json",
+		})
+		if !assert.NoError(t, err, "write") {
+			t.FailNow()
+		}
+
+		// execute
+		snapshot, err := s.ReadSnapshot(ctx, id, "snapshot")
+
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, 5, int(snapshot.Position))
+		assert.Equal(t, []byte(`{ "Key" : 2 }`), snapshot.Data)
+	})
+
+	t.Run("write/delete/read", func(t *testing.T) {
+		// setup
+		id := streamId("write/delete/read")
+		err := s.UpdateSnapshot(ctx, id, "snapshot", record.Snapshot{
+			Data:        []byte(`{ "Key" : 9 }`),
+			Position:    9,
+			ContentType: "application/json",
+		})
+		if !assert.NoError(t, err, "write") {
+			t.FailNow()
+		}
+
+		// execute
+		err = s.DeleteSnapshot(ctx, id, "snapshot")
+
+		// verify
+		assert.NoError(t, err)
+		snapshot, err := s.ReadSnapshot(ctx, id, "snapshot")
+		assert.NoError(t, err)
+		assert.Equal(t, -1, int(snapshot.Position))
+	})
+}
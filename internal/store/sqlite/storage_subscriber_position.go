@@ -0,0 +1,191 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
+)
+
+func subscriberPositions(ctx context.Context, conn dbtx, id streams.Id, ids ...string) ([]store.SubscriptionPosition, error) {
+	var result []store.SubscriptionPosition
+	for _, subscriptionId := range ids {
+		var position int64
+		err := conn.QueryRowContext(ctx, getSubscriberPosition, id.String(), subscriptionId).Scan(&position)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, store.SubscriptionPosition{
+			SubscriptionId: subscriptionId,
+			Position:       position,
+		})
+	}
+	return result, nil
+}
+
+func updateSubscriberPosition(ctx context.Context, conn dbtx, stream string, position store.SubscriptionPosition) error {
+	now := time.Now().UTC()
+	_, err := conn.ExecContext(ctx, setSubscriberPosition,
+		now,
+		now,
+		stream,
+		position.SubscriptionId,
+		position.Position,
+	)
+	return err
+}
+
+func moveSubscriberPosition(ctx context.Context, conn dbtx, stream string, position store.SubscriptionPosition) error {
+	now := time.Now().UTC()
+	_, err := conn.ExecContext(ctx, moveSubscriberPositionQuery,
+		now,
+		now,
+		stream,
+		position.SubscriptionId,
+		position.Position,
+	)
+	return err
+}
+
+func subscriptions(ctx context.Context, conn dbtx) ([]store.Subscription, error) {
+	rows, err := conn.QueryContext(ctx, getSubscriptions)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var result []store.Subscription
+	for rows.Next() {
+		var stream string
+		subscription := store.Subscription{}
+		err = rows.Scan(&stream, &subscription.SubscriptionId, &subscription.Position, &subscription.Updated)
+		if err != nil {
+			return nil, err
+		}
+		subscription.Stream = streams.ParseId(stream)
+		result = append(result, subscription)
+	}
+	return result, rows.Err()
+}
+
+// Holds the subscriber position locks taken and the positions set,
+// writing the positions in a single database transaction on Commit.
+type storageTx struct {
+	ctx  context.Context
+	conn *sql.DB
+
+	mu        sync.Mutex                  // protects the fields below
+	done      bool                        // committed or rolled back
+	positions map[string]map[string]int64 // pending subscriber positions by stream id
+	moved     map[string]map[string]bool  // pending positions set even if lower, by stream id
+	held      []*sync.Mutex               // subscriber position locks taken
+}
+
+// takes the position locks of the subscriptions in lock order
+func (tx *storageTx) lockPositions(subscriptionIds []string, positionLock func(subscriptionId string) *sync.Mutex) {
+	for _, subscriptionId := range store.LockOrder(subscriptionIds) {
+		tx.lock(positionLock(subscriptionId))
+	}
+}
+
+func (tx *storageTx) lock(lock *sync.Mutex) {
+	tx.mu.Lock()
+	for _, held := range tx.held {
+		if held == lock {
+			tx.mu.Unlock()
+			return
+		}
+	}
+	tx.mu.Unlock()
+
+	lock.Lock()
+
+	tx.mu.Lock()
+	tx.held = append(tx.held, lock)
+	tx.mu.Unlock()
+}
+
+func (tx *storageTx) setPosition(id streams.Id, position store.SubscriptionPosition) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if _, found := tx.positions[id.String()]; !found {
+		tx.positions[id.String()] = make(map[string]int64)
+	}
+	tx.positions[id.String()][position.SubscriptionId] = position.Position
+	delete(tx.moved[id.String()], position.SubscriptionId)
+}
+
+func (tx *storageTx) movePosition(id streams.Id, position store.SubscriptionPosition) {
+	tx.setPosition(id, position)
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if _, found := tx.moved[id.String()]; !found {
+		tx.moved[id.String()] = make(map[string]bool)
+	}
+	tx.moved[id.String()][position.SubscriptionId] = true
+}
+
+func (tx *storageTx) Commit() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	defer tx.release()
+	if len(tx.positions) == 0 {
+		return nil
+	}
+
+	dbTx, err := tx.conn.BeginTx(tx.ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = dbTx.Rollback()
+	}()
+	for stream, positions := range tx.positions {
+		for subscriptionId, position := range positions {
+			update := updateSubscriberPosition
+			if tx.moved[stream][subscriptionId] {
+				update = moveSubscriberPosition
+			}
+			err = update(tx.ctx, dbTx, stream, store.SubscriptionPosition{
+				SubscriptionId: subscriptionId,
+				Position:       position,
+			})
+			if err != nil {
+				return fmt.Errorf("subscriber position %s: %w", subscriptionId, err)
+			}
+		}
+	}
+	return dbTx.Commit()
+}
+
+func (tx *storageTx) Rollback() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.done {
+		return nil
+	}
+	tx.release() // discard the pending positions
+	return nil
+}
+
+// must be called while holding the tx lock
+func (tx *storageTx) release() {
+	tx.done = true
+	for _, lock := range tx.held {
+		lock.Unlock()
+	}
+	tx.held = nil
+}
+
+var _ store.Tx = &storageTx{}
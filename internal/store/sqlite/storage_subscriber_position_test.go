@@ -0,0 +1,168 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-po/po/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorage_Subscriber(t *testing.T) {
+	// setup
+	s := storage(t)
+	ctx := context.Background()
+
+	t.Run("positions only move forward", func(t *testing.T) {
+		// setup
+		id := streamId("")
+		for _, position := range []int64{-1, 5, 3} {
+			tx, err := s.Begin(ctx)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.NoError(t, s.SetSubscriptionPosition(tx, id, store.SubscriptionPosition{
+				SubscriptionId: "A",
+				Position:       position,
+			}))
+			assert.NoError(t, tx.Commit())
+		}
+		tx, err := s.Begin(ctx)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer func() {
+			_ = tx.Rollback()
+		}()
+		// execute
+		got, err := s.SubscriptionPositionLock(tx, id, "A", "B")
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(got)) {
+			assert.Equal(t, "A", got[0].SubscriptionId)
+			assert.Equal(t, 5, int(got[0].Position))
+		}
+	})
+
+	t.Run("moved positions go backwards", func(t *testing.T) {
+		// setup
+		id := streamId("")
+		tx, err := s.Begin(ctx)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.NoError(t, s.SetSubscriptionPosition(tx, id, store.SubscriptionPosition{
+			SubscriptionId: "A",
+			Position:       5,
+		}))
+		assert.NoError(t, tx.Commit())
+		tx, err = s.Begin(ctx)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		_, err = s.SubscriptionPositionLock(tx, id, "A")
+		assert.NoError(t, err)
+		// execute
+		err = s.MoveSubscriptionPosition(tx, id, store.SubscriptionPosition{
+			SubscriptionId: "A",
+			Position:       2,
+		})
+		// verify
+		assert.NoError(t, err)
+		assert.NoError(t, tx.Commit())
+		tx, err = s.Begin(ctx)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer func() {
+			_ = tx.Rollback()
+		}()
+		got, err := s.SubscriptionPositionLock(tx, id, "A")
+		assert.NoError(t, err)
+		assert.Equal(t, []store.SubscriptionPosition{{SubscriptionId: "A", Position: 2}}, got)
+	})
+
+	t.Run("list subscriptions", func(t *testing.T) {
+		// setup
+		id := streamId("")
+		tx, err := s.Begin(ctx)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.NoError(t, s.SetSubscriptionPosition(tx, id, store.SubscriptionPosition{
+			SubscriptionId: "L",
+			Position:       3,
+		}))
+		assert.NoError(t, tx.Commit())
+		// execute
+		got, err := s.ListSubscriptions(ctx)
+		// verify
+		assert.NoError(t, err)
+		found := false
+		for _, subscription := range got {
+			if subscription.Stream == id && subscription.SubscriptionId == "L" {
+				found = true
+				assert.Equal(t, int64(3), subscription.Position)
+				assert.False(t, subscription.Updated.IsZero(), "updated")
+			}
+		}
+		assert.True(t, found, "listed")
+	})
+
+	t.Run("rollback discards positions", func(t *testing.T) {
+		// setup
+		id := streamId("")
+		tx, err := s.Begin(ctx)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.NoError(t, s.SetSubscriptionPosition(tx, id, store.SubscriptionPosition{
+			SubscriptionId: "A",
+			Position:       4,
+		}))
+		// execute
+		assert.NoError(t, tx.Rollback())
+		// verify
+		tx, err = s.Begin(ctx)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer func() {
+			_ = tx.Rollback()
+		}()
+		got, err := s.SubscriptionPositionLock(tx, id, "A")
+		assert.NoError(t, err)
+		assert.Empty(t, got)
+	})
+
+	t.Run("lock held until commit", func(t *testing.T) {
+		// setup
+		id := streamId("")
+		first, err := s.Begin(ctx)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		_, err = s.SubscriptionPositionLock(first, id, "A")
+		assert.NoError(t, err)
+		locked := make(chan struct{})
+		// execute
+		go func() {
+			second, err := s.Begin(ctx)
+			if assert.NoError(t, err) {
+				_, err = s.SubscriptionPositionLock(second, id, "A")
+				assert.NoError(t, err)
+				assert.NoError(t, second.Rollback())
+			}
+			close(locked)
+		}()
+		// verify
+		select {
+		case <-locked:
+			t.Fatal("lock taken twice")
+		case <-time.After(20 * time.Millisecond):
+		}
+		assert.NoError(t, first.Commit())
+		<-locked
+	})
+}
@@ -0,0 +1,78 @@
+package sqlite
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+// storage in a database file removed when the test ends
+func storage(t *testing.T) *Storage {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "po-sqlite")
+	if !assert.NoError(t, err, "temp dir") {
+		t.FailNow()
+	}
+	s, err := New(filepath.Join(dir, "po.db"))
+	if !assert.NoError(t, err, "database") {
+		t.FailNow()
+	}
+	t.Cleanup(func() {
+		_ = s.Close()
+		_ = os.RemoveAll(dir)
+	})
+	return s
+}
+
+var streamCounter int
+
+func streamId(entity string) streams.Id {
+	streamCounter = streamCounter + 1
+	prefix := "stream" + strconv.Itoa(streamCounter)
+	if len(entity) > 0 {
+		return streams.ParseId("%s-%s", prefix, entity)
+	}
+	return streams.ParseId(prefix)
+}
+
+func data(c int) []record.Data {
+	var result []record.Data
+	for i := 0; i < c; i++ {
+		result = append(result, record.Data{
+			ContentType: "application/json",
+			Data:        []byte("{}"),
+		})
+	}
+	return result
+}
+
+func TestNew(t *testing.T) {
+	// setup
+	dir, err := ioutil.TempDir("", "po-sqlite")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	path := filepath.Join(dir, "po.db")
+	first, err := New(path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, first.Close())
+
+	// execute
+	second, err := New(path)
+
+	// verify
+	if assert.NoError(t, err, "migrating an existing database") {
+		assert.NoError(t, second.Close())
+	}
+}
@@ -0,0 +1,186 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
+	"github.com/google/uuid"
+)
+
+// used in place of a position to append to the end of the stream
+const endOfStream = store.AnyPosition
+
+var emptyJson = []byte("{}")
+
+func writeRecords(ctx context.Context, conn database, id streams.Id, position int64, data ...record.Data) ([]record.Record, error) {
+	return writeBatch(ctx, conn, store.StreamWrite{Id: id, Position: position, Data: data})
+}
+
+// database written to, the *sql.DB of the storage or an outboxDB
+type database interface {
+	dbtx
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// database writing the notifications of the records to the outbox along with them
+type outboxDB struct {
+	*sql.DB
+}
+
+// Writes all the streams in one transaction.
+// The transaction holds the database write lock from the start,
+// so records are committed in the order of their global number.
+// The notifications of the records are written to the outbox along with them, if the database is an outboxDB.
+func writeBatch(ctx context.Context, conn database, writes ...store.StreamWrite) ([]record.Record, error) {
+	_, outbox := conn.(outboxDB)
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var records []record.Record
+	for _, write := range writes {
+		written, err := writeStream(ctx, tx, outbox, write)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, written...)
+	}
+
+	return records, tx.Commit()
+}
+
+func writeStream(ctx context.Context, tx dbtx, outbox bool, write store.StreamWrite) ([]record.Record, error) {
+	if len(write.Data) == 0 {
+		return nil, nil
+	}
+	current, err := streamPosition(ctx, tx, write.Id)
+	if err != nil {
+		return nil, err
+	}
+	written, err := deduplicate(ctx, tx, write.Id, current, write.Data)
+	if written != nil || err != nil {
+		return written, err
+	}
+	err = store.CheckPosition(write.Id, write.Position, current)
+	if err != nil {
+		return nil, err
+	}
+
+	position := current
+	var records []record.Record
+	for _, r := range write.Data {
+		stored, err := writeRecord(ctx, tx, write.Id, r, position+1)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, stored)
+		position = stored.Number
+	}
+	if outbox {
+		err = storeNotifications(ctx, tx, records)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+func streamPosition(ctx context.Context, conn dbtx, id streams.Id) (int64, error) {
+	var position int64
+	err := conn.QueryRowContext(ctx, getStreamPosition, id.String()).Scan(&position)
+	return position, err
+}
+
+// records already written with the message ids of data
+func deduplicate(ctx context.Context, conn dbtx, id streams.Id, current int64, data []record.Data) ([]record.Record, error) {
+	var stored []record.Record
+	for _, messageId := range store.MessageIds(data) {
+		rows, err := conn.QueryContext(ctx, readRecordByMessageId, messageId)
+		if err != nil {
+			return nil, err
+		}
+		records, err := scanRecords(rows)
+		if err != nil {
+			return nil, err
+		}
+		stored = append(stored, records...)
+	}
+	return store.Deduplicate(id, current, data, stored)
+}
+
+func writeRecord(ctx context.Context, conn dbtx, id streams.Id, data record.Data, position int64) (record.Record, error) {
+	var err error
+	metadata := emptyJson
+	if len(data.Metadata) > 0 {
+		metadata, err = json.Marshal(data.Metadata)
+		if err != nil {
+			return record.Record{}, fmt.Errorf("metadata: %w", err)
+		}
+	}
+	messageId := data.MessageId
+	if messageId == "" {
+		messageId = uuid.New().String()
+	}
+	created := time.Now().UTC()
+	result, err := conn.ExecContext(ctx, storeRecord,
+		created,
+		id.String(),
+		position,
+		id.Group,
+		data.ContentType,
+		data.Data,
+		nullString(data.CorrelationId),
+		nullString(data.CausationId),
+		string(metadata),
+		messageId,
+	)
+	if err != nil {
+		if isStreamNumberConflict(err) {
+			return record.Record{}, store.WriteConflictError{
+				StreamId: id,
+				Position: position,
+				Current:  position - 1,
+				Err:      err,
+			}
+		}
+		return record.Record{}, err
+	}
+	globalNumber, err := result.LastInsertId()
+	if err != nil {
+		return record.Record{}, err
+	}
+	var stored streams.Metadata
+	if len(data.Metadata) > 0 {
+		stored = data.Metadata
+	}
+	return record.Record{
+		MessageId:     messageId,
+		Number:        position,
+		Stream:        id,
+		Data:          data.Data,
+		Group:         id.Group,
+		ContentType:   data.ContentType,
+		GlobalNumber:  globalNumber,
+		Time:          created,
+		CorrelationId: data.CorrelationId,
+		CausationId:   data.CausationId,
+		Metadata:      stored,
+	}, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{
+		String: s,
+		Valid:  s != "",
+	}
+}
@@ -0,0 +1,186 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorage_WriteRecords(t *testing.T) {
+	// setup
+	s := storage(t)
+	ctx := context.Background()
+
+	t.Run("multiple records", func(t *testing.T) {
+		// setup
+		id := streamId("multiple")
+		// execute
+		got, err := s.WriteRecordsFrom(ctx, id, -1, data(4)...)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 4, len(got)) {
+			assert.Equal(t, id.String(), got[0].Stream.String())
+			assert.Equal(t, 0, int(got[0].Number))
+			assert.Equal(t, 3, int(got[3].Number))
+			assert.Equal(t, got[0].GlobalNumber+3, got[3].GlobalNumber)
+			assert.NotEmpty(t, got[0].MessageId)
+		}
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		// setup
+		id := streamId("conflict")
+		_, err := s.WriteRecordsFrom(ctx, id, -1, data(5)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		_, err = s.WriteRecordsFrom(ctx, id, 3, data(2)...)
+		// verify
+		conflict := store.WriteConflictError{}
+		if assert.True(t, errors.As(err, &conflict), "write conflict error") {
+			assert.Equal(t, id.String(), conflict.StreamId.String())
+			assert.Equal(t, 4, int(conflict.Position))
+			assert.Equal(t, 4, int(conflict.Current))
+		}
+	})
+
+	t.Run("existing stream", func(t *testing.T) {
+		// setup
+		id := streamId("existing")
+		// execute
+		_, err := s.WriteRecordsFrom(ctx, id, store.ExistingStream, data(1)...)
+		// verify
+		assert.True(t, errors.Is(err, store.ErrStreamNotFound), "stream not found")
+	})
+
+	t.Run("end of stream", func(t *testing.T) {
+		// setup
+		id := streamId("end")
+		_, err := s.WriteRecordsFrom(ctx, id, -1, data(2)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		got, err := s.WriteRecords(ctx, id, data(1)...)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(got)) {
+			assert.Equal(t, 2, int(got[0].Number))
+		}
+	})
+
+	t.Run("batch conflict writes nothing", func(t *testing.T) {
+		// setup
+		a, b := streamId("batch"), streamId("batch")
+		_, err := s.WriteRecordsFrom(ctx, b, -1, data(1)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		_, err = s.WriteBatch(ctx,
+			store.StreamWrite{Id: a, Position: -1, Data: data(1)},
+			store.StreamWrite{Id: b, Position: -1, Data: data(1)},
+		)
+		// verify
+		conflict := store.WriteConflictError{}
+		if assert.True(t, errors.As(err, &conflict), "write conflict error") {
+			assert.Equal(t, b.String(), conflict.StreamId.String())
+			assert.Equal(t, 0, int(conflict.Current))
+		}
+		records, err := s.ReadRecords(ctx, a, -1, math.MaxInt64, 100)
+		assert.NoError(t, err)
+		assert.Empty(t, records)
+	})
+
+	t.Run("same messages", func(t *testing.T) {
+		// setup
+		id := streamId("dedup")
+		input := data(2)
+		input[0].MessageId = uuid.New().String()
+		input[1].MessageId = uuid.New().String()
+		first, err := s.WriteRecordsFrom(ctx, id, -1, input...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		got, err := s.WriteRecordsFrom(ctx, id, -1, input...)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 2, len(got)) {
+			assert.Equal(t, first[0].GlobalNumber, got[0].GlobalNumber)
+			assert.Equal(t, first[1].MessageId, got[1].MessageId)
+		}
+		records, err := s.ReadRecords(ctx, id, -1, math.MaxInt64, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(records))
+	})
+
+	t.Run("message id twice in a batch", func(t *testing.T) {
+		// setup
+		id := streamId("twice")
+		input := data(2)
+		input[0].MessageId = uuid.New().String()
+		input[1].MessageId = input[0].MessageId
+		// execute
+		_, err := s.WriteRecordsFrom(ctx, id, -1, input...)
+		// verify
+		assert.Error(t, err)
+		assert.False(t, errors.As(err, &store.WriteConflictError{}), "not a write conflict")
+	})
+
+	t.Run("correlation and metadata", func(t *testing.T) {
+		// setup
+		id := streamId("correlation")
+		input := data(1)
+		input[0].CorrelationId = "correlation id"
+		input[0].CausationId = "causation id"
+		input[0].Metadata = streams.Metadata{"user": []byte(`"peter"`)}
+		written, err := s.WriteRecords(ctx, id, input...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		got, err := s.ReadRecords(ctx, id, -1, math.MaxInt64, 100)
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(got)) {
+			assert.Equal(t, "correlation id", got[0].CorrelationId)
+			assert.Equal(t, "causation id", got[0].CausationId)
+			assert.JSONEq(t, `"peter"`, string(got[0].Metadata["user"]))
+			assert.Equal(t, written[0].MessageId, got[0].MessageId)
+			assert.True(t, written[0].Time.Equal(got[0].Time), "time")
+		}
+	})
+
+	t.Run("concurrent writers", func(t *testing.T) {
+		// setup
+		group := streamId("")
+		wg := sync.WaitGroup{}
+		// execute
+		for writer := 0; writer < 5; writer++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 10; i++ {
+					_, err := s.WriteRecords(ctx, group.WithEntity("entity"), data(1)...)
+					assert.NoError(t, err)
+				}
+			}()
+		}
+		wg.Wait()
+		// verify
+		records, err := s.ReadRecords(ctx, group.WithEntity("entity"), -1, math.MaxInt64, 100)
+		assert.NoError(t, err)
+		if assert.Equal(t, 50, len(records)) {
+			assert.Equal(t, 49, int(records[49].Number))
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-po/po/internal/broker"
+	"github.com/stretchr/testify/assert"
+)
+
+// Leases tests the leases held by partitioned subscribers
+func Leases(t *testing.T, s broker.LeaseStore) {
+	// setup
+	ctx := context.Background()
+	prefix := uniqueName("lease") + "/"
+	name := prefix + "0"
+
+	acquired, err := s.AcquireLease(ctx, name, "A", time.Minute)
+	if !assert.NoError(t, err) || !assert.True(t, acquired) {
+		t.FailNow()
+	}
+
+	t.Run("renew", func(t *testing.T) {
+		// execute
+		acquired, err := s.AcquireLease(ctx, name, "A", time.Minute)
+		// verify
+		assert.NoError(t, err)
+		assert.True(t, acquired)
+	})
+
+	t.Run("held by other", func(t *testing.T) {
+		// execute
+		acquired, err := s.AcquireLease(ctx, name, "B", time.Minute)
+		// verify
+		assert.NoError(t, err)
+		assert.False(t, acquired)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		// execute
+		held, err := s.Leases(ctx, prefix)
+		// verify
+		assert.NoError(t, err)
+		if assert.Len(t, held, 1) {
+			assert.Equal(t, name, held[0].Name)
+			assert.Equal(t, "A", held[0].Owner)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		// setup
+		expiring := prefix + "1"
+		_, err := s.AcquireLease(ctx, expiring, "A", time.Millisecond)
+		assert.NoError(t, err)
+		time.Sleep(5 * time.Millisecond)
+		// execute
+		acquired, err := s.AcquireLease(ctx, expiring, "B", time.Minute)
+		// verify
+		assert.NoError(t, err)
+		assert.True(t, acquired)
+	})
+
+	t.Run("released", func(t *testing.T) {
+		// setup
+		assert.NoError(t, s.ReleaseLease(ctx, name, "A"))
+		// execute
+		acquired, err := s.AcquireLease(ctx, name, "B", time.Minute)
+		// verify
+		assert.NoError(t, err)
+		assert.True(t, acquired)
+	})
+}
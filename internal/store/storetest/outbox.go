@@ -0,0 +1,166 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-po/po/internal/broker"
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// OutboxStore is a store writing the notifications of its records to the outbox
+type OutboxStore interface {
+	broker.OutboxStore
+	WriteRecords(ctx context.Context, id streams.Id, data ...record.Data) ([]record.Record, error)
+}
+
+// Outbox tests the outbox of the notifications relayed to the protocol.
+// The outbox of s must not be enabled yet.
+func Outbox(t *testing.T, s OutboxStore) {
+	// setup
+	ctx := context.Background()
+	id := streams.ParseId(uniqueName("outbox"))
+	mine := func(n store.Notification) bool {
+		return n.Stream.String() == id.String()
+	}
+	// relays every notification, and returns those of the stream
+	relayed := func(t *testing.T) []store.Notification {
+		var result []store.Notification
+		_, err := s.RelayNotifications(ctx, 1000, func(n store.Notification) error {
+			if mine(n) {
+				result = append(result, n)
+			}
+			return nil
+		})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return result
+	}
+	_, err := s.WriteRecords(ctx, id, data(1)...)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	s.EnableOutbox()
+
+	// execute
+	written, err := s.WriteRecords(ctx, id, data(2)...)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// verify
+	count, err := s.CountNotifications(ctx)
+	assert.NoError(t, err)
+	assert.True(t, count >= 2, "counted")
+	pending := relayed(t)
+	if assert.Len(t, pending, 2, "written without the outbox are left out") {
+		for i, n := range pending {
+			assert.Equal(t, written[i].Number, n.Number)
+			assert.Equal(t, written[i].GlobalNumber, n.GlobalNumber)
+			assert.False(t, n.Time.IsZero(), "time")
+		}
+	}
+	assert.Empty(t, relayed(t), "removed once published")
+
+	t.Run("limit", func(t *testing.T) {
+		// setup
+		_, err := s.WriteRecords(ctx, id, data(2)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		published := 0
+		claimed, err := s.RelayNotifications(ctx, 1, func(n store.Notification) error {
+			published = published + 1
+			return nil
+		})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, 1, claimed)
+		assert.Equal(t, 1, published)
+		relayed(t)
+	})
+
+	t.Run("failed publish", func(t *testing.T) {
+		// setup
+		_, err := s.WriteRecords(ctx, id, data(2)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		failure := errors.New("failed publish")
+		// execute
+		_, err = s.RelayNotifications(ctx, 1000, func(n store.Notification) error {
+			if mine(n) {
+				return failure
+			}
+			return nil
+		})
+		// verify
+		assert.Equal(t, failure, err)
+		assert.Len(t, relayed(t), 2, "kept in the outbox")
+	})
+
+	t.Run("claimed by others", func(t *testing.T) {
+		// setup
+		written, err := s.WriteRecords(ctx, id, data(1)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		claimed := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan error)
+		go func() {
+			_, err := s.RelayNotifications(ctx, 1000, func(n store.Notification) error {
+				if mine(n) {
+					close(claimed)
+					<-release
+					return errors.New("released")
+				}
+				return nil
+			})
+			done <- err
+		}()
+		<-claimed
+		// execute
+		others := relayed(t)
+		// verify
+		close(release)
+		assert.Error(t, <-done)
+		assert.Empty(t, others, "skipped")
+		if pending := relayed(t); assert.Len(t, pending, 1, "kept once released") {
+			assert.Equal(t, written[0].GlobalNumber, pending[0].GlobalNumber)
+		}
+	})
+
+	t.Run("deduplicated writes", func(t *testing.T) {
+		// setup
+		input := data(1)
+		input[0].MessageId = uuid.New().String()
+		_, err := s.WriteRecords(ctx, id, input...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		_, err = s.WriteRecords(ctx, id, input...)
+		// verify
+		assert.NoError(t, err)
+		assert.Len(t, relayed(t), 1)
+	})
+}
+
+func data(count int) []record.Data {
+	var result []record.Data
+	for i := 0; i < count; i++ {
+		result = append(result, record.Data{
+			ContentType: "application/json",
+			Data:        []byte("{}"),
+		})
+	}
+	return result
+}
@@ -0,0 +1,99 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+// PositionStore is a store locating positions in its streams
+type PositionStore interface {
+	HeadPosition(ctx context.Context, id streams.Id) (int64, error)
+	PositionBefore(ctx context.Context, id streams.Id, t time.Time) (int64, error)
+	CountRecords(ctx context.Context, id streams.Id, after int64) (int64, error)
+	WriteRecords(ctx context.Context, id streams.Id, data ...record.Data) ([]record.Record, error)
+}
+
+// Positions tests the positions located for subscribers and inspections
+func Positions(t *testing.T, s PositionStore) {
+	// setup
+	ctx := context.Background()
+
+	t.Run("empty stream", func(t *testing.T) {
+		// setup
+		id := streams.ParseId(uniqueName("entity"))
+		// execute
+		head, err := s.HeadPosition(ctx, id)
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, int64(-1), head)
+	})
+
+	t.Run("head of stream and group", func(t *testing.T) {
+		// setup
+		id := streams.ParseId(uniqueName("entity"))
+		_, err := s.WriteRecords(ctx, streams.ParseId(id.Group).WithEntity("other"), data(2)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		records, err := s.WriteRecords(ctx, id, data(3)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		streamHead, streamErr := s.HeadPosition(ctx, id)
+		groupHead, groupErr := s.HeadPosition(ctx, streams.ParseId(id.Group))
+		// verify
+		assert.NoError(t, streamErr)
+		assert.NoError(t, groupErr)
+		assert.Equal(t, int64(2), streamHead)
+		assert.Equal(t, records[2].GlobalNumber, groupHead)
+	})
+
+	t.Run("count records", func(t *testing.T) {
+		// setup
+		id := streams.ParseId(uniqueName("entity"))
+		records, err := s.WriteRecords(ctx, id, data(3)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		inStream, streamErr := s.CountRecords(ctx, id, 0)
+		inGroup, groupErr := s.CountRecords(ctx, streams.ParseId(id.Group), records[0].GlobalNumber)
+		// verify
+		assert.NoError(t, streamErr)
+		assert.NoError(t, groupErr)
+		assert.Equal(t, int64(2), inStream)
+		assert.Equal(t, int64(2), inGroup)
+	})
+
+	t.Run("position before", func(t *testing.T) {
+		// setup
+		id := streams.ParseId(uniqueName("entity"))
+		first, err := s.WriteRecords(ctx, id, data(1)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		time.Sleep(time.Millisecond)
+		second, err := s.WriteRecords(ctx, id, data(1)...)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		group := streams.ParseId(id.Group)
+		// execute
+		before, beforeErr := s.PositionBefore(ctx, group, first[0].Time)
+		between, betweenErr := s.PositionBefore(ctx, group, second[0].Time)
+		after, afterErr := s.PositionBefore(ctx, id, second[0].Time.Add(time.Second))
+		// verify
+		assert.NoError(t, beforeErr)
+		assert.NoError(t, betweenErr)
+		assert.NoError(t, afterErr)
+		assert.Equal(t, int64(-1), before)
+		assert.Equal(t, first[0].GlobalNumber, between)
+		assert.Equal(t, int64(1), after)
+	})
+}
@@ -0,0 +1,13 @@
+// Package storetest holds the conformance tests of the optional capabilities of the stores,
+// run by the tests of every store implementing them.
+package storetest
+
+import (
+	"math/rand"
+	"strconv"
+)
+
+// unique to the run, as the databases keep what earlier runs wrote
+func uniqueName(name string) string {
+	return strconv.FormatInt(rand.Int63(), 10) + "-" + name
+}
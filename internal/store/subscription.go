@@ -0,0 +1,27 @@
+package store
+
+import (
+	"sort"
+	"time"
+
+	"github.com/go-po/po/streams"
+)
+
+// Stored position of a subscription
+type Subscription struct {
+	Stream         streams.Id
+	SubscriptionId string
+	Position       int64
+	Updated        time.Time // when the position last moved, zero if unknown
+}
+
+// Orders the subscriptions by stream and subscription id, as listed by the stores
+func SortSubscriptions(subscriptions []Subscription) {
+	sort.Slice(subscriptions, func(i, j int) bool {
+		a, b := subscriptions[i], subscriptions[j]
+		if a.Stream.String() != b.Stream.String() {
+			return a.Stream.String() < b.Stream.String()
+		}
+		return a.SubscriptionId < b.SubscriptionId
+	})
+}
@@ -1,20 +1,36 @@
 package store
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/go-po/po/streams"
 )
 
+var (
+	ErrWrongExpectedVersion = errors.New("wrong expected version")
+	ErrStreamNotFound       = errors.New("stream not found")
+)
+
 type Tx interface {
 	Commit() error
 	Rollback() error
 }
 
+// Copy of the subscription ids in the order their position locks are taken,
+// avoiding deadlocks between transactions locking several of them
+func LockOrder(subscriptionIds []string) []string {
+	sorted := append([]string(nil), subscriptionIds...)
+	sort.Strings(sorted)
+	return sorted
+}
+
 // Error type used when optimistic locking hits a write conflict
 type WriteConflictError struct {
 	StreamId streams.Id
 	Position int64 // position attempted to write
+	Current  int64 // last position written to the stream, -1 if empty
 	Err      error // possible underlying storage engine error
 }
 
@@ -24,10 +40,29 @@ func (err WriteConflictError) Error() string {
 
 func (err WriteConflictError) Is(target error) bool {
 	_, ok := target.(WriteConflictError)
-	return ok
+	return ok || target == ErrWrongExpectedVersion
+}
+
+func (err WriteConflictError) Unwrap() error {
+	return err.Err
 }
 
 type SubscriptionPosition struct {
 	SubscriptionId string
 	Position       int64
 }
+
+// Error type used when a stream lock could not be taken within the timeout
+type LockTimeoutError struct {
+	StreamId streams.Id
+	Err      error // possible underlying storage engine error
+}
+
+func (err LockTimeoutError) Error() string {
+	return fmt.Sprintf("timeout locking [%s]", err.StreamId)
+}
+
+func (err LockTimeoutError) Is(target error) bool {
+	_, ok := target.(LockTimeoutError)
+	return ok
+}
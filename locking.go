@@ -0,0 +1,103 @@
+package po
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
+)
+
+// How Execute guards a stream against concurrent writers
+type LockMode struct {
+	Pessimistic bool          // lock the stream while projecting and appending, rather than retrying on conflicts
+	Timeout     time.Duration // longest wait for the lock, zero waits for as long as the context allows
+}
+
+// Executes commands optimistically, retrying them on write conflicts
+func OptimisticLocking() LockMode {
+	return LockMode{}
+}
+
+// Executes commands holding a lock on the stream
+func PessimisticLocking(timeout time.Duration) LockMode {
+	return LockMode{
+		Pessimistic: true,
+		Timeout:     timeout,
+	}
+}
+
+type lockModeKey struct{}
+
+// Overrides the lock mode of Execute calls made with the returned context
+func ContextWithLockMode(ctx context.Context, mode LockMode) context.Context {
+	return context.WithValue(ctx, lockModeKey{}, mode)
+}
+
+func lockModeFromContext(ctx context.Context, fallback LockMode) LockMode {
+	mode, found := ctx.Value(lockModeKey{}).(LockMode)
+	if !found {
+		return fallback
+	}
+	return mode
+}
+
+type streamLocker interface {
+	LockStream(ctx context.Context, id streams.Id, timeout time.Duration) (store.Tx, error)
+}
+
+// Lock held in a transaction of the store, which the reads and writes made with its context are made in
+type scopedLock interface {
+	Context(ctx context.Context) context.Context
+}
+
+type heldNotificationsKey struct{}
+
+// notifier of the records written with the context, holding them back until the lock is committed
+func notifierFromContext(ctx context.Context, fallback notifier) notifier {
+	held, found := ctx.Value(heldNotificationsKey{}).(*deferredBroker)
+	if !found {
+		return fallback
+	}
+	return held
+}
+
+// holds the lock of the stream while executing, when the lock mode is pessimistic
+func newLockingExecutor(mode LockMode, locker streamLocker, broker Broker, inner executor) executorFunc {
+	return func(ctx context.Context, id streams.Id, position int64, cmd CommandHandler) (int64, error) {
+		mode := lockModeFromContext(ctx, mode)
+		if !mode.Pessimistic {
+			return inner.Execute(ctx, id, position, cmd)
+		}
+		lock, err := locker.LockStream(ctx, id, mode.Timeout)
+		if errors.Is(err, store.LockTimeoutError{}) {
+			return position, LockTimeoutError{StreamId: id, Timeout: mode.Timeout, Err: err}
+		}
+		if err != nil {
+			return position, err
+		}
+		defer func() {
+			_ = lock.Rollback()
+		}()
+		scoped, ok := lock.(scopedLock)
+		if !ok {
+			position, err = inner.Execute(ctx, id, position, cmd)
+			if err != nil {
+				return position, err
+			}
+			return position, lock.Commit()
+		}
+
+		held := &deferredBroker{Broker: broker}
+		written, err := inner.Execute(context.WithValue(scoped.Context(ctx), heldNotificationsKey{}, held), id, position, cmd)
+		if err != nil {
+			return written, err
+		}
+		err = lock.Commit()
+		if err != nil {
+			return position, err
+		}
+		return written, held.flush(ctx)
+	}
+}
@@ -0,0 +1,135 @@
+package po
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-po/po/internal/broker/channels"
+	"github.com/go-po/po/internal/logger"
+	"github.com/go-po/po/internal/observer"
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/internal/store/inmemory"
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+type incrementCmd struct {
+	count int
+}
+
+func (cmd *incrementCmd) Handle(ctx context.Context, msg streams.Message) error {
+	cmd.count = cmd.count + 1
+	return nil
+}
+
+func (cmd *incrementCmd) Execute(appender TransactionAppender) error {
+	appender.Append(Msg{Name: "incremented"})
+	return nil
+}
+
+func TestPo_Execute_Pessimistic(t *testing.T) {
+	ctx := context.Background()
+	id := streams.ParseId("counter-1")
+
+	t.Run("concurrent commands", func(t *testing.T) {
+		// setup
+		mem := inmemory.New()
+		es := newPo(mem, channels.New(), testRegistry, &logger.NoopLogger{}, observer.NewStub(), CatchUpPolicy{})
+		es.retry = RetryPolicy{MaxAttempts: 1}
+		es.lock = PessimisticLocking(time.Second)
+		_, err := es.Subscribe(ctx, "counter", id, HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+			return nil
+		}))
+		assert.NoError(t, err)
+		// execute
+		wg := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, es.Execute(ctx, id, &incrementCmd{}))
+			}()
+		}
+		wg.Wait()
+		// verify
+		cmd := &incrementCmd{}
+		assert.NoError(t, es.Project(ctx, id, cmd))
+		assert.Equal(t, 10, cmd.count)
+	})
+
+	t.Run("lock timeout", func(t *testing.T) {
+		// setup
+		mem := inmemory.New()
+		es := newPo(mem, channels.New(), testRegistry, &logger.NoopLogger{}, observer.NewStub(), CatchUpPolicy{})
+		held, err := mem.LockStream(ctx, id, 0)
+		assert.NoError(t, err)
+		defer func() {
+			_ = held.Rollback()
+		}()
+		ctx := ContextWithLockMode(ctx, PessimisticLocking(time.Millisecond))
+		// execute
+		err = es.Execute(ctx, id, &incrementCmd{})
+		// verify
+		timeout := LockTimeoutError{}
+		if assert.True(t, errors.As(err, &timeout), "lock timeout error") {
+			assert.Equal(t, id.String(), timeout.StreamId.String())
+		}
+	})
+}
+
+type scopedStubKey struct{}
+
+// lock of a store scoping the writes to its transaction
+type scopedStubLock struct {
+	committed bool
+}
+
+func (lock *scopedStubLock) Context(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scopedStubKey{}, lock)
+}
+
+func (lock *scopedStubLock) Commit() error {
+	lock.committed = true
+	return nil
+}
+
+func (lock *scopedStubLock) Rollback() error {
+	return nil
+}
+
+type scopedStubLocker struct {
+	lock *scopedStubLock
+}
+
+func (locker scopedStubLocker) LockStream(ctx context.Context, id streams.Id, timeout time.Duration) (store.Tx, error) {
+	return locker.lock, nil
+}
+
+func TestLockingExecutor_Scoped(t *testing.T) {
+	// setup
+	ctx := context.Background()
+	id := streams.ParseId("scoped-1")
+	recorder := &recordingBroker{}
+	lock := &scopedStubLock{}
+	var scoped, notifiedEarly bool
+	exec := newLockingExecutor(PessimisticLocking(time.Second), scopedStubLocker{lock: lock}, recorder,
+		executorFunc(func(ctx context.Context, id streams.Id, lockPosition int64, cmd CommandHandler) (int64, error) {
+			scoped = ctx.Value(scopedStubKey{}) == lock
+			err := notifierFromContext(ctx, recorder).Notify(ctx, record.Record{Stream: id, Number: 0})
+			notifiedEarly = len(recorder.notified) > 0
+			return 0, err
+		}))
+	// execute
+	position, err := exec.Execute(ctx, id, -1, newStubCmd(0))
+	// verify
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), position)
+	assert.True(t, scoped, "executed within the lock")
+	assert.False(t, notifiedEarly, "notified before the lock is committed")
+	assert.True(t, lock.committed, "committed")
+	assert.Equal(t, 1, len(recorder.notified))
+}
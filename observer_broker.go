@@ -4,6 +4,7 @@ import (
 	"context"
 	"strconv"
 
+	"github.com/go-po/po/internal/broker"
 	"github.com/go-po/po/internal/observer"
 	"github.com/go-po/po/internal/observer/binary"
 	"github.com/go-po/po/internal/observer/unary"
@@ -35,8 +36,42 @@ func (obs *observesBroker) Notify(ctx context.Context, positions ...record.Recor
 	return obs.broker.Notify(ctx, positions...)
 }
 
-func (obs *observesBroker) Register(ctx context.Context, subscriberId string, streamId streams.Id, subscriber streams.Handler) error {
+func (obs *observesBroker) Register(ctx context.Context, subscriberId string, streamId streams.Id, subscriber streams.Handler, opts ...broker.SubscriberOption) error {
 	done := obs.onRegister.Observe(ctx, streamId.String(), subscriberId)
 	defer done()
-	return obs.broker.Register(ctx, subscriberId, streamId, subscriber)
+	return obs.broker.Register(ctx, subscriberId, streamId, subscriber, opts...)
+}
+
+func (obs *observesBroker) RegisterPartitioned(ctx context.Context, subscriberId string, streamId streams.Id, partitions int, subscriber streams.Handler, opts ...broker.SubscriberOption) error {
+	done := obs.onRegister.Observe(ctx, streamId.String(), subscriberId)
+	defer done()
+	return obs.broker.RegisterPartitioned(ctx, subscriberId, streamId, partitions, subscriber, opts...)
+}
+
+func (obs *observesBroker) Unregister(subscriberId string, streamId streams.Id) error {
+	return obs.broker.Unregister(subscriberId, streamId)
+}
+
+func (obs *observesBroker) MoveSubscriber(ctx context.Context, subscriberId string, streamId streams.Id, partitions int, start broker.Start) error {
+	return obs.broker.MoveSubscriber(ctx, subscriberId, streamId, partitions, start)
+}
+
+func (obs *observesBroker) Subscriptions(ctx context.Context) ([]broker.SubscriptionState, error) {
+	return obs.broker.Subscriptions(ctx)
+}
+
+func (obs *observesBroker) Close(ctx context.Context) error {
+	return obs.broker.Close(ctx)
+}
+
+func (obs *observesBroker) DeadLetters(ctx context.Context, subscriberId string) ([]broker.DeadLetter, error) {
+	return obs.broker.DeadLetters(ctx, subscriberId)
+}
+
+func (obs *observesBroker) ReplayDeadLetter(ctx context.Context, subscriberId string, number int64) error {
+	return obs.broker.ReplayDeadLetter(ctx, subscriberId, number)
+}
+
+func (obs *observesBroker) DiscardDeadLetter(ctx context.Context, subscriberId string, number int64) error {
+	return obs.broker.DiscardDeadLetter(ctx, subscriberId, number)
 }
@@ -2,6 +2,7 @@ package po
 
 import (
 	"context"
+	"io"
 
 	"github.com/go-po/po/internal/broker"
 	"github.com/go-po/po/internal/observer"
@@ -36,10 +37,29 @@ func (obs *observesProtocol) Register(ctx context.Context, group string, input b
 	if err != nil {
 		return publisher, err
 	}
+	return obs.outBound(publisher), nil
+}
+
+func (obs *observesProtocol) Publisher(ctx context.Context, group string) (broker.RecordHandler, error) {
+	publisher, err := obs.protocol.Publisher(ctx, group)
+	if err != nil {
+		return publisher, err
+	}
+	return obs.outBound(publisher), nil
+}
+
+func (obs *observesProtocol) outBound(publisher broker.RecordHandler) broker.RecordHandler {
 	return broker.RecordHandlerFunc(func(ctx context.Context, record record.Record) (bool, error) {
 		// observe out-bound
 		done := obs.onOut.Observe(ctx, record.Group)
 		defer done()
 		return publisher.Handle(ctx, record)
-	}), nil
+	})
+}
+
+func (obs *observesProtocol) Close() error {
+	if closer, ok := obs.protocol.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
 }
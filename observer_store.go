@@ -2,8 +2,11 @@ package po
 
 import (
 	"context"
+	"io"
 	"strconv"
+	"time"
 
+	"github.com/go-po/po/internal/broker"
 	"github.com/go-po/po/internal/observer"
 	"github.com/go-po/po/internal/observer/binary"
 	"github.com/go-po/po/internal/record"
@@ -40,6 +43,12 @@ func (facade *observesStore) WriteRecordsFrom(ctx context.Context, id streams.Id
 	return records, err
 }
 
+func (facade *observesStore) WriteBatch(ctx context.Context, writes ...store.StreamWrite) ([]record.Record, error) {
+	records, err := facade.store.WriteBatch(ctx, writes...)
+	facade.logErr(err, "po/store write batch: %s", err)
+	return records, err
+}
+
 func (facade *observesStore) ReadSnapshot(ctx context.Context, id streams.Id, snapshotId string) (record.Snapshot, error) {
 	snapshot, err := facade.store.ReadSnapshot(ctx, id, snapshotId)
 	facade.logErr(err, "po/store read snapshot: %s", err)
@@ -71,12 +80,32 @@ func (facade *observesStore) ReadRecords(ctx context.Context, id streams.Id, fro
 	return rec, err
 }
 
+// Filtered by the store if it can, else the records of other types are dropped once read
+func (facade *observesStore) ReadRecordsOfTypes(ctx context.Context, id streams.Id, from, to, limit int64, contentTypes []string) ([]record.Record, int64, error) {
+	rec, position, err := broker.ReadRecordsOfTypes(ctx, facade.store, id, from, to, limit, contentTypes)
+	facade.logErr(err, "po/store read records of types: %s", err)
+	return rec, position, err
+}
+
 func (facade *observesStore) SetSubscriptionPosition(tx store.Tx, id streams.Id, position store.SubscriptionPosition) error {
 	err := facade.store.SetSubscriptionPosition(tx, id, position)
 	facade.logErr(err, "po/store set subscription position: %s", err)
 	return err
 }
 
+func (facade *observesStore) LockStream(ctx context.Context, id streams.Id, timeout time.Duration) (store.Tx, error) {
+	tx, err := facade.store.LockStream(ctx, id, timeout)
+	facade.logErr(err, "po/store lock stream: %s", err)
+	return tx, err
+}
+
+func (facade *observesStore) Close() error {
+	if closer, ok := facade.store.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
 func (facade *observesStore) logErr(err error, format string, args ...interface{}) {
 	if err != nil {
 		facade.logger.Errorf(format, args...)
@@ -3,14 +3,19 @@ package po
 import (
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/go-po/po/internal/broker"
 	"github.com/go-po/po/internal/broker/channels"
+	"github.com/go-po/po/internal/broker/pgnotify"
 	"github.com/go-po/po/internal/logger"
 	"github.com/go-po/po/internal/observer"
 	"github.com/go-po/po/internal/registry"
+	"github.com/go-po/po/internal/store/filelog"
 	"github.com/go-po/po/internal/store/inmemory"
+	"github.com/go-po/po/internal/store/mysql"
 	"github.com/go-po/po/internal/store/postgres"
+	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -20,10 +25,22 @@ type Options struct {
 	logger   Logger
 	prom     prometheus.Registerer
 	protocol broker.Protocol
+	retry    RetryPolicy
+	lock     LockMode
+	catchUp  CatchUpPolicy
+
+	subscriberErrors SubscriberErrorPolicy
+	batchSize        int
+	instanceId       string
+	leaseTTL         time.Duration
+	outbox           *OutboxPolicy // nil when appends notify the protocol themselves
 }
 
 type Option func(opt *Options) error
 
+// messages handled per transaction by each subscriber, unless set with WithSubscriberBatchSize
+const defaultBatchSize = broker.DefaultBatchSize
+
 func New(store Store, protocol broker.Protocol) *Po {
 	logger := &logger.NoopLogger{}
 	return newPo(
@@ -32,6 +49,7 @@ func New(store Store, protocol broker.Protocol) *Po {
 		registry.DefaultRegistry,
 		logger,
 		observer.New(logger, observer.NewPromStub()),
+		DefaultCatchUpPolicy(),
 	)
 }
 
@@ -40,6 +58,13 @@ func NewFromOptions(opts ...Option) (*Po, error) {
 		registry: registry.DefaultRegistry,
 		logger:   &logger.NoopLogger{},
 		prom:     observer.NewPromStub(),
+		retry:    DefaultRetryPolicy(),
+		catchUp:  DefaultCatchUpPolicy(),
+
+		subscriberErrors: DefaultSubscriberErrorPolicy(),
+		batchSize:        defaultBatchSize,
+		instanceId:       uuid.New().String(),
+		leaseTTL:         broker.DefaultLeaseTTL,
 	}
 
 	for _, opt := range opts {
@@ -65,24 +90,137 @@ func NewFromOptions(opts ...Option) (*Po, error) {
 		return nil, fmt.Errorf("po: no broker protocol provided")
 	}
 
-	return newPo(options.store, options.protocol, options.registry, options.logger, builder), nil
+	if options.retry.MaxAttempts < 1 {
+		return nil, fmt.Errorf("po: retry policy needs at least one attempt")
+	}
+
+	if options.subscriberErrors.MaxAttempts < 1 {
+		return nil, fmt.Errorf("po: subscriber error policy needs at least one attempt")
+	}
+
+	if options.batchSize < 1 {
+		return nil, fmt.Errorf("po: subscriber batch size must be positive")
+	}
+
+	if options.instanceId == "" || options.leaseTTL <= 0 {
+		return nil, fmt.Errorf("po: partitioned subscribers need an instance id and a lease ttl")
+	}
+
+	if options.catchUp.Interval < 0 || options.catchUp.Jitter < 0 || options.catchUp.Jitter > 1 {
+		return nil, fmt.Errorf("po: invalid catch up policy")
+	}
+
+	brokerOpts := []broker.Option{
+		broker.WithInstance(options.instanceId, options.leaseTTL),
+	}
+	if options.outbox != nil {
+		outbox, ok := options.store.(broker.OutboxStore)
+		if !ok {
+			return nil, fmt.Errorf("po: store %T: %w", options.store, ErrNoOutbox)
+		}
+		if options.outbox.Interval <= 0 || options.outbox.BatchSize < 1 {
+			return nil, fmt.Errorf("po: invalid outbox policy")
+		}
+		brokerOpts = append(brokerOpts,
+			broker.WithOutbox(outbox, options.outbox.Interval, options.outbox.BatchSize, options.outbox.delay),
+			broker.WithOutboxObservers(
+				builder.Unary().
+					LogInfof("po/broker relay outbox: %s").
+					Build(),
+				builder.Value().
+					Histogram(prometheus.NewHistogram(prometheus.HistogramOpts{
+						Name: "po_outbox_relay_lag_ms",
+						Help: "time from writing a record to publishing its notification",
+					})).
+					Build(),
+				builder.Value().
+					Gauge(prometheus.NewGauge(prometheus.GaugeOpts{
+						Name: "po_outbox_backlog",
+						Help: "number of notifications waiting in the outbox",
+					})).
+					Build(),
+			),
+		)
+	}
+
+	po := newPo(options.store, options.protocol, options.registry, options.logger, builder, options.catchUp, brokerOpts...)
+	po.retry = options.retry
+	po.lock = options.lock
+	po.subscriberErrors = options.subscriberErrors
+	po.batchSize = options.batchSize
+	return po, nil
 }
 
-func newPo(store Store, protocol broker.Protocol, registry Registry, logger Logger, builder *observer.Builder) *Po {
+func newPo(store Store, protocol broker.Protocol, registry Registry, logger Logger, builder *observer.Builder, catchUp CatchUpPolicy, brokerOpts ...broker.Option) *Po {
+	brokerOpts = append([]broker.Option{
+		broker.WithCatchUp(catchUp.Interval, catchUp.jitter()),
+		broker.WithCatchUpErrors(builder.Binary().
+			LogInfof("po/broker catch up %s: %s").
+			Build()),
+		broker.WithSubscriberErrors(
+			countFirst(
+				builder.Binary().
+					LogInfof("po/broker subscriber %s failed: %s").
+					Build(),
+				builder.Unary().
+					MetricCounterVec(prometheus.NewCounterVec(prometheus.CounterOpts{
+						Name: "po_subscriber_error_counter",
+						Help: "number of errors returned by subscribers",
+					}, []string{"subscriber"})).
+					Build(),
+			),
+			builder.Binary().
+				LogInfof("po/broker subscriber %s gave up on a message: %s").
+				MetricCounterVec(prometheus.NewCounterVec(prometheus.CounterOpts{
+					Name: "po_subscriber_failure_counter",
+					Help: "number of messages subscribers gave up on, by the action taken",
+				}, []string{"subscriber", "action"})).
+				Build(),
+		),
+	}, brokerOpts...)
+	if leases, ok := store.(broker.LeaseStore); ok {
+		brokerOpts = append(brokerOpts, broker.WithLeases(leases))
+	}
+	if positions, ok := store.(broker.PositionStore); ok {
+		brokerOpts = append(brokerOpts, broker.WithPositions(positions))
+	}
+	if subscriptions, ok := store.(broker.SubscriptionStore); ok {
+		brokerOpts = append(brokerOpts, broker.WithSubscriptionList(subscriptions))
+	}
+	if heldBack, ok := store.(broker.HeldBackStore); ok {
+		brokerOpts = append(brokerOpts, broker.WithHeldBack(heldBack, broker.DefaultHeldBackDelay))
+	}
 	store = observeStore(store, builder)
 	broker := observeBroker(
-		broker.New(store, registry, observeProtocol(protocol, builder)),
+		broker.New(store, registry, observeProtocol(protocol, builder), brokerOpts...),
 		builder)
 	return &Po{
 		obs: poObserver{
 			Stream:  builder.Nullary().Build(),
 			Project: builder.Nullary().Build(),
+			Execute: builder.Unary().
+				HistogramVec(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+					Name: "po_execute_duration_ms",
+					Help: "duration of executing a command including retries",
+				}, []string{"group"})).
+				Build(),
+			Retry: builder.Unary().
+				LogDebugf("po/execute retry on %s").
+				MetricCounterVec(prometheus.NewCounterVec(prometheus.CounterOpts{
+					Name: "po_execute_retry_counter",
+					Help: "number of retries executing a command",
+				}, []string{"group"})).
+				Build(),
 		},
 		logger:   logger,
 		builder:  builder,
 		store:    store,
 		broker:   broker,
 		registry: registry,
+		retry:    DefaultRetryPolicy(),
+
+		subscriberErrors: DefaultSubscriberErrorPolicy(),
+		batchSize:        defaultBatchSize,
 	}
 }
 
@@ -102,6 +240,71 @@ func WithLogger(logger Logger) Option {
 	}
 }
 
+// Retry policy used by Execute
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(opt *Options) error {
+		opt.retry = policy
+		return nil
+	}
+}
+
+// Catch up policy of the subscriptions
+func WithCatchUp(policy CatchUpPolicy) Option {
+	return func(opt *Options) error {
+		opt.catchUp = policy
+		return nil
+	}
+}
+
+// Error policy of the subscribers, unless overridden with SubscribeWithErrorPolicy
+func WithSubscriberErrorPolicy(policy SubscriberErrorPolicy) Option {
+	return func(opt *Options) error {
+		opt.subscriberErrors = policy
+		return nil
+	}
+}
+
+// Number of messages each subscriber handles per transaction
+func WithSubscriberBatchSize(size int) Option {
+	return func(opt *Options) error {
+		opt.batchSize = size
+		return nil
+	}
+}
+
+// Identifies this instance among the instances sharing partitioned subscribers, defaults to a random id
+func WithInstanceId(instanceId string) Option {
+	return func(opt *Options) error {
+		opt.instanceId = instanceId
+		return nil
+	}
+}
+
+// Time the partitions of partitioned subscribers are leased for, renewed every third of it
+func WithLeaseTTL(ttl time.Duration) Option {
+	return func(opt *Options) error {
+		opt.leaseTTL = ttl
+		return nil
+	}
+}
+
+// Writes a notification to the outbox along with the messages of each append,
+// relayed to the protocol until published. Needs a store able to keep an outbox.
+func WithOutbox(policy OutboxPolicy) Option {
+	return func(opt *Options) error {
+		opt.outbox = &policy
+		return nil
+	}
+}
+
+// Lock mode used by Execute
+func WithLockMode(mode LockMode) Option {
+	return func(opt *Options) error {
+		opt.lock = mode
+		return nil
+	}
+}
+
 func WithStore(store Store) Option {
 	return func(opt *Options) error {
 		opt.store = store
@@ -144,6 +347,33 @@ func WithStorePostgresDB(db *sql.DB) Option {
 	}
 }
 
+// Connects to MySQL or MariaDB using a data source name,
+// e.g. po:po@tcp(localhost:3305)/po
+func WithStoreMySQLUrl(dataSourceName string) Option {
+	return func(opt *Options) (err error) {
+		opt.store, err = NewStoreMySQLUrl(dataSourceName)
+		return err
+	}
+}
+
+// The connection must be opened with parseTime=true and multiStatements=true
+func WithStoreMySQLDB(db *sql.DB) Option {
+	return func(opt *Options) (err error) {
+		opt.store, err = NewStoreMySQLDB(db)
+		return
+	}
+}
+
+// Wakes up subscribers with LISTEN/NOTIFY on the database.
+// Notifications are sent on db, and received on a connection of
+// its own opened from connectionUrl.
+func WithProtocolPostgres(db *sql.DB, connectionUrl string) Option {
+	return func(opt *Options) error {
+		opt.protocol = NewProtocolPostgres(db, connectionUrl)
+		return nil
+	}
+}
+
 func WithProtocolChannels() Option {
 	return func(opt *Options) error {
 		opt.protocol = NewProtocolChannels()
@@ -161,6 +391,10 @@ func NewProtocolChannels() *channels.Channels {
 	return channels.New()
 }
 
+func NewProtocolPostgres(db *sql.DB, connectionUrl string) *pgnotify.Protocol {
+	return pgnotify.New(db, connectionUrl)
+}
+
 func NewStorePostgresUrl(connectionUrl string) (*postgres.Storage, error) {
 	return postgres.NewFromUrl(connectionUrl)
 }
@@ -168,3 +402,16 @@ func NewStorePostgresUrl(connectionUrl string) (*postgres.Storage, error) {
 func NewStorePostgresDB(db *sql.DB) (*postgres.Storage, error) {
 	return postgres.NewFromConn(db)
 }
+
+func NewStoreMySQLUrl(dataSourceName string) (*mysql.Storage, error) {
+	return mysql.NewFromUrl(dataSourceName)
+}
+
+func NewStoreMySQLDB(db *sql.DB) (*mysql.Storage, error) {
+	return mysql.NewFromConn(db)
+}
+
+// File log in dir, flushing every write to the disk before it returns
+func NewStoreFileLog(dir string) (*filelog.FileLog, error) {
+	return filelog.New(dir)
+}
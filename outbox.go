@@ -0,0 +1,32 @@
+package po
+
+import (
+	"time"
+)
+
+// Decides how the notifications written to the outbox are relayed to the protocol
+type OutboxPolicy struct {
+	Interval  time.Duration // time between relays, besides the relay woken by each append
+	BatchSize int           // notifications read from the outbox at a time
+	BaseDelay time.Duration // delay before the first retry of a failed relay, doubled for each retry after it
+	MaxDelay  time.Duration // upper bound of the delay, zero for no bound
+	Jitter    float64       // fraction of the delay randomized, from 0 to 1
+}
+
+func DefaultOutboxPolicy() OutboxPolicy {
+	return OutboxPolicy{
+		Interval:  time.Second,
+		BatchSize: 100,
+		BaseDelay: 100 * time.Millisecond,
+		MaxDelay:  30 * time.Second,
+		Jitter:    0.2,
+	}
+}
+
+func (policy OutboxPolicy) delay(retry int) time.Duration {
+	return RetryPolicy{
+		BaseDelay: policy.BaseDelay,
+		MaxDelay:  policy.MaxDelay,
+		Jitter:    policy.Jitter,
+	}.delay(retry)
+}
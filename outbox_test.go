@@ -0,0 +1,103 @@
+package po
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-po/po/internal/store/filelog"
+	"github.com/go-po/po/internal/store/inmemory"
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPo_Outbox(t *testing.T) {
+	t.Run("relayed", func(t *testing.T) {
+		// setup
+		ctx := context.Background()
+		es, err := NewFromOptions(
+			WithStoreInMemory(),
+			WithProtocolChannels(),
+			WithRegistry(testRegistry),
+			WithOutbox(DefaultOutboxPolicy()),
+		)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer func() {
+			_ = es.Close(ctx)
+		}()
+		received := make(chan streams.Message, 1)
+		_, err = es.Subscribe(ctx, "outbox", streams.ParseId("relayed"), HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+			received <- msg
+			return nil
+		}))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		// execute
+		_, err = es.Append(ctx, streams.ParseId("relayed-1"), Msg{Name: "relayed"})
+
+		// verify
+		assert.NoError(t, err)
+		select {
+		case msg := <-received:
+			assert.Equal(t, "relayed-1", msg.Stream.String())
+		case <-time.After(time.Second):
+			t.Fatal("not relayed")
+		}
+	})
+
+	t.Run("protocol failing", func(t *testing.T) {
+		// setup
+		ctx := context.Background()
+		mem := inmemory.New()
+		es, err := NewFromOptions(
+			WithStore(mem),
+			WithProtocol(droppingProtocol{}),
+			WithRegistry(testRegistry),
+			WithOutbox(OutboxPolicy{Interval: time.Hour, BatchSize: 10, BaseDelay: time.Hour}),
+		)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer func() {
+			_ = es.Close(ctx)
+		}()
+
+		// execute
+		_, err = es.Append(ctx, streams.ParseId("dropped-1"), Msg{Name: "kept"})
+
+		// verify
+		assert.NoError(t, err, "notification kept in the outbox")
+		count, err := mem.CountNotifications(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("store without outbox", func(t *testing.T) {
+		// setup
+		dir, err := ioutil.TempDir("", "po-outbox")
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer func() {
+			_ = os.RemoveAll(dir)
+		}()
+		store, err := filelog.New(dir)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer func() {
+			_ = store.Close()
+		}()
+		// execute
+		_, err = NewFromOptions(WithStore(store), WithProtocolChannels(), WithOutbox(DefaultOutboxPolicy()))
+		// verify
+		assert.True(t, errors.Is(err, ErrNoOutbox))
+	})
+}
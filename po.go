@@ -2,9 +2,13 @@ package po
 
 import (
 	"context"
+	"io"
+	"time"
 
+	"github.com/go-po/po/internal/broker"
 	"github.com/go-po/po/internal/observer"
 	"github.com/go-po/po/internal/observer/nullary"
+	"github.com/go-po/po/internal/observer/unary"
 	"github.com/go-po/po/internal/record"
 	"github.com/go-po/po/internal/registry"
 	"github.com/go-po/po/internal/store"
@@ -14,17 +18,27 @@ import (
 type Store interface {
 	WriteRecords(ctx context.Context, id streams.Id, data ...record.Data) ([]record.Record, error)
 	WriteRecordsFrom(ctx context.Context, id streams.Id, position int64, data ...record.Data) ([]record.Record, error)
+	WriteBatch(ctx context.Context, writes ...store.StreamWrite) ([]record.Record, error)
 	ReadSnapshot(ctx context.Context, id streams.Id, snapshotId string) (record.Snapshot, error)
 	UpdateSnapshot(ctx context.Context, id streams.Id, snapshotId string, snapshot record.Snapshot) error
 	Begin(ctx context.Context) (store.Tx, error)
 	SubscriptionPositionLock(tx store.Tx, id streams.Id, subscriptionIds ...string) ([]store.SubscriptionPosition, error)
 	ReadRecords(ctx context.Context, id streams.Id, from, to, limit int64) ([]record.Record, error)
 	SetSubscriptionPosition(tx store.Tx, id streams.Id, position store.SubscriptionPosition) error
+	LockStream(ctx context.Context, id streams.Id, timeout time.Duration) (store.Tx, error)
 }
 
 type Broker interface {
 	Notify(ctx context.Context, records ...record.Record) error
-	Register(ctx context.Context, subscriberId string, streamId streams.Id, subscriber streams.Handler) error
+	Register(ctx context.Context, subscriberId string, streamId streams.Id, subscriber streams.Handler, opts ...broker.SubscriberOption) error
+	RegisterPartitioned(ctx context.Context, subscriberId string, streamId streams.Id, partitions int, subscriber streams.Handler, opts ...broker.SubscriberOption) error
+	Unregister(subscriberId string, streamId streams.Id) error
+	MoveSubscriber(ctx context.Context, subscriberId string, streamId streams.Id, partitions int, start broker.Start) error
+	Subscriptions(ctx context.Context) ([]broker.SubscriptionState, error)
+	Close(ctx context.Context) error
+	DeadLetters(ctx context.Context, subscriberId string) ([]broker.DeadLetter, error)
+	ReplayDeadLetter(ctx context.Context, subscriberId string, number int64) error
+	DiscardDeadLetter(ctx context.Context, subscriberId string, number int64) error
 }
 
 type Registry interface {
@@ -78,12 +92,15 @@ func (fn HandlerFunc) Handle(ctx context.Context, msg streams.Message) error {
 type poObserver struct {
 	Stream  nullary.ClientTrace
 	Project nullary.ClientTrace
+	Execute unary.ClientTrace
+	Retry   unary.ClientTrace
 }
 
 type messageStream interface {
-	Project(projection Handler) error
+	Project(projection Handler, opts ...ProjectOption) error
 	Execute(exec CommandHandler) error
 	Append(messages ...interface{}) (int64, error)
+	AppendExpected(expected ExpectedVersion, messages ...interface{}) (int64, error)
 }
 
 type Po struct {
@@ -93,26 +110,121 @@ type Po struct {
 	store    Store
 	broker   Broker
 	registry Registry
+	retry    RetryPolicy
+	lock     LockMode
+
+	subscriberErrors SubscriberErrorPolicy
+	batchSize        int
 }
 
 func (po *Po) Stream(ctx context.Context, id streams.Id) *Stream {
 	done := po.obs.Stream.Observe(ctx)
 	defer done()
-	return NewStream(ctx, id, po.store, po.broker, po.registry)
+	return newStream(ctx, id, po.store, po.broker, po.registry, executeConfig{
+		retry:   po.retry,
+		onRetry: po.obs.Retry,
+		lock:    po.lock,
+	})
 }
 
 // convenience method to load a stream and project it
-func (po *Po) Project(ctx context.Context, id streams.Id, projection Handler) error {
+func (po *Po) Project(ctx context.Context, id streams.Id, projection Handler, opts ...ProjectOption) error {
 	done := po.obs.Project.Observe(ctx)
 	defer done()
-	return po.Stream(ctx, id).Project(projection)
-}
-
-func (po *Po) Subscribe(ctx context.Context, subscriptionId string, id streams.Id, subscriber Handler) error {
-	return po.broker.Register(ctx, subscriptionId, id, subscriber)
-}
-
+	return po.Stream(ctx, id).Project(projection, opts...)
+}
+
+// Registers the subscriber, returning a handle to unsubscribe it with
+func (po *Po) Subscribe(ctx context.Context, subscriptionId string, id streams.Id, subscriber Handler, opts ...SubscribeOption) (*Subscription, error) {
+	options := &subscribeOptions{
+		errorPolicy: po.subscriberErrors,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	subscriberOpts := []broker.SubscriberOption{
+		broker.WithErrorPolicy(options.errorPolicy.toBroker()),
+		broker.WithBatchSize(po.batchSize),
+	}
+	if options.start != nil {
+		subscriberOpts = append(subscriberOpts, broker.StartingAt(options.start))
+	}
+	if !options.filter.isZero() {
+		contentTypes, err := options.filter.contentTypes(po.registry)
+		if err != nil {
+			return nil, err
+		}
+		subscriberOpts = append(subscriberOpts, broker.Filtering(contentTypes, options.filter.predicate))
+	}
+	var err error
+	if options.partitions > 0 {
+		err = po.broker.RegisterPartitioned(ctx, subscriptionId, id, options.partitions, subscriber, subscriberOpts...)
+	} else {
+		err = po.broker.Register(ctx, subscriptionId, id, subscriber, subscriberOpts...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Subscription{
+		broker:         po.broker,
+		subscriptionId: subscriptionId,
+		streamId:       id,
+	}, nil
+}
+
+// Moves the subscription to the position, forwards or backwards, to replay messages or skip them.
+// A subscriber handling a batch keeps it, and continues from the position afterwards.
+// Subscriptions made with SubscribeWithPartitions are moved with the same option.
+func (po *Po) MoveSubscription(ctx context.Context, subscriptionId string, id streams.Id, position Position, opts ...SubscribeOption) error {
+	options := &subscribeOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return po.broker.MoveSubscriber(ctx, subscriptionId, id, options.partitions, position.start)
+}
+
+// Lists the subscriptions stored, with their positions and how far behind they are.
+// Last errors are only known for subscribers subscribed with this Po.
+func (po *Po) Subscriptions(ctx context.Context) ([]SubscriptionState, error) {
+	return po.broker.Subscriptions(ctx)
+}
+
+// Stops the subscribers once their batches in flight are committed,
+// then closes the protocol and the store. A database handed to the store by the application is left open.
+// Returns the error of the context if it is done first.
+func (po *Po) Close(ctx context.Context) error {
+	err := po.broker.Close(ctx)
+	if err != nil {
+		return err
+	}
+	if closer, ok := po.store.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Messages the subscriber parked in its dead-letter stream, and not yet replayed or discarded
+func (po *Po) DeadLetters(ctx context.Context, subscriptionId string) ([]DeadLetter, error) {
+	return po.broker.DeadLetters(ctx, subscriptionId)
+}
+
+// Hands the dead letter to the handler of the subscriber once more, resolving it if the handler succeeds.
+// The subscriber must be subscribed with this Po.
+func (po *Po) ReplayDeadLetter(ctx context.Context, subscriptionId string, number int64) error {
+	return po.broker.ReplayDeadLetter(ctx, subscriptionId, number)
+}
+
+// Resolves the dead letter without handling it
+func (po *Po) DiscardDeadLetter(ctx context.Context, subscriptionId string, number int64) error {
+	return po.broker.DiscardDeadLetter(ctx, subscriptionId, number)
+}
+
+// Executes the command, retrying it as decided by the RetryPolicy,
+// or holding a lock on the stream if the LockMode is pessimistic.
+// Both can be overridden for a single call with ContextWithRetryPolicy and ContextWithLockMode.
 func (po *Po) Execute(ctx context.Context, id streams.Id, exec CommandHandler) error {
+	done := po.obs.Execute.Observe(ctx, id.Group)
+	defer done()
 	stream := po.Stream(ctx, id)
 	return stream.Execute(exec)
 }
@@ -121,6 +233,11 @@ func (po *Po) Append(ctx context.Context, id streams.Id, messages ...interface{}
 	return po.Stream(ctx, id).Append(messages...)
 }
 
+// Appends the messages to the stream if it is at the expected version
+func (po *Po) AppendExpected(ctx context.Context, id streams.Id, expected ExpectedVersion, messages ...interface{}) (int64, error) {
+	return po.Stream(ctx, id).AppendExpected(expected, messages...)
+}
+
 func RegisterMessages(initializers ...registry.MessageUnmarshaller) {
 	registry.Register(initializers...)
 }
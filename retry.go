@@ -0,0 +1,92 @@
+package po
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/go-po/po/internal/store"
+)
+
+// Decides how Execute retries a command
+type RetryPolicy struct {
+	MaxAttempts int                  // attempts including the first one
+	BaseDelay   time.Duration        // delay before the first retry, doubled for each retry after it
+	MaxDelay    time.Duration        // upper bound of the delay, zero for no bound
+	Jitter      float64              // fraction of the delay randomized, from 0 to 1
+	Retryable   func(err error) bool // errors worth another attempt, defaults to write conflicts
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   10 * time.Millisecond,
+		MaxDelay:    time.Second,
+		Jitter:      0.5,
+		Retryable:   isWriteConflict,
+	}
+}
+
+func isWriteConflict(err error) bool {
+	return errors.Is(err, store.WriteConflictError{})
+}
+
+func (policy RetryPolicy) retryable(err error) bool {
+	if policy.Retryable == nil {
+		return isWriteConflict(err)
+	}
+	return policy.Retryable(err)
+}
+
+// delay before the given retry, counting from 1
+func (policy RetryPolicy) delay(retry int) time.Duration {
+	if policy.BaseDelay <= 0 {
+		return 0
+	}
+	delay := policy.BaseDelay
+	for i := 1; i < retry; i++ {
+		if delay > math.MaxInt64/2 || (policy.MaxDelay > 0 && delay > policy.MaxDelay) {
+			break
+		}
+		delay = delay * 2
+	}
+	if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+	if policy.Jitter > 0 {
+		delay = delay - time.Duration(rand.Float64()*policy.Jitter*float64(delay))
+	}
+	return delay
+}
+
+type retryPolicyKey struct{}
+
+// Overrides the retry policy of Execute calls made with the returned context
+func ContextWithRetryPolicy(ctx context.Context, policy RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, policy)
+}
+
+func retryPolicyFromContext(ctx context.Context, fallback RetryPolicy) RetryPolicy {
+	policy, found := ctx.Value(retryPolicyKey{}).(RetryPolicy)
+	if !found {
+		return fallback
+	}
+	return policy
+}
+
+// waits for the delay, unless the context is done first
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,23 @@
+// Package sqlite stores the messages of po in a SQLite database file.
+// It is kept out of the root package, as the driver needs cgo to build.
+package sqlite
+
+import (
+	"github.com/go-po/po"
+	"github.com/go-po/po/internal/store/sqlite"
+)
+
+// Stores the messages in the SQLite database file at path
+func WithStore(path string) po.Option {
+	return func(opt *po.Options) error {
+		store, err := NewStore(path)
+		if err != nil {
+			return err
+		}
+		return po.WithStore(store)(opt)
+	}
+}
+
+func NewStore(path string) (*sqlite.Storage, error) {
+	return sqlite.New(path)
+}
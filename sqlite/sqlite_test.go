@@ -0,0 +1,42 @@
+package sqlite_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-po/po"
+	"github.com/go-po/po/sqlite"
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+type Msg struct {
+	Name string
+}
+
+func TestWithStore(t *testing.T) {
+	// setup
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "po-sqlite")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	// execute
+	es, err := po.NewFromOptions(sqlite.WithStore(filepath.Join(dir, "po.db")), po.WithProtocolChannels())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	position, err := es.Append(ctx, streams.ParseId("sqlite-1"), Msg{Name: "a"})
+
+	// verify
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), position)
+	assert.NoError(t, es.Close(ctx))
+}
@@ -4,23 +4,42 @@ import (
 	"context"
 	"sync"
 
+	"github.com/go-po/po/internal/observer/unary"
 	"github.com/go-po/po/streams"
 )
 
 var _ messageStream = &Stream{}
 
 func NewStream(ctx context.Context, streamId streams.Id, store Store, broker Broker, registry Registry) *Stream {
+	return newStream(ctx, streamId, store, broker, registry, executeConfig{
+		retry:   DefaultRetryPolicy(),
+		onRetry: unary.Noop(),
+	})
+}
+
+// decides how commands are executed on a stream
+type executeConfig struct {
+	retry   RetryPolicy
+	onRetry unary.ClientTrace
+	lock    LockMode
+}
+
+func newStream(ctx context.Context, streamId streams.Id, store Store, broker Broker, registry Registry, exec executeConfig) *Stream {
 	projector := newProjectorFunc(store, registry)
 	snapshotter := newSnapshots(store, projector)
 	appender := newAppenderFunc(store, broker, registry)
-	executioner := newRetryExecutor(3, newExecutor(projector, appender))
+	expectedAppender := newExpectedAppenderFunc(store, broker, registry)
+	executioner := newRetryExecutor(exec.retry, exec.onRetry,
+		newLockingExecutor(exec.lock, store, broker, newExecutor(projector, appender)))
 	return &Stream{
 		Id:  streamId,
 		ctx: ctx,
 
-		projector: snapshotter,
-		appender:  appender,
-		executor:  executioner,
+		projector:        snapshotter,
+		filtered:         newFilteredProjectors(store, registry),
+		appender:         appender,
+		expectedAppender: expectedAppender,
+		executor:         executioner,
 
 		mu:           sync.RWMutex{},
 		lockPosition: -1,
@@ -30,11 +49,13 @@ func NewStream(ctx context.Context, streamId streams.Id, store Store, broker Bro
 // Stream that uses Optimistic locking when
 // appending to the message stream
 type Stream struct {
-	Id        streams.Id
-	ctx       context.Context // to use for the operation
-	projector projector
-	executor  executorFunc
-	appender  appenderFunc
+	Id               streams.Id
+	ctx              context.Context // to use for the operation
+	projector        projector
+	filtered         func(filter Filter) (projector, error) // projects the messages the filter lets through
+	executor         executorFunc
+	appender         appenderFunc
+	expectedAppender expectedAppenderFunc
 
 	mu           sync.RWMutex // protects the fields below
 	lockPosition int64        // last known position
@@ -54,17 +75,51 @@ func (stream *Stream) Append(messages ...interface{}) (int64, error) {
 	return stream.lockPosition, nil
 }
 
+// Appends the messages if the stream is at the expected version.
+// If not, a WrongExpectedVersionError holding the actual position is returned.
+// On success, this Stream instance is locked to the position of the last appended message.
+func (stream *Stream) AppendExpected(expected ExpectedVersion, messages ...interface{}) (int64, error) {
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+
+	if len(messages) == 0 {
+		return stream.lockPosition, nil
+	}
+
+	position, err := stream.expectedAppender(stream.ctx, stream.Id, expected, messages...)
+	if position >= 0 {
+		stream.lockPosition = position
+	}
+	if err != nil {
+		return position, expectedVersionError(stream.Id, expected, err)
+	}
+	return position, nil
+}
+
 // Projects all messages onto the given Handler.
 // If the handler implements streams.NamedSnapshot, snapshotting will be performed.
 //
 // The projection will also lock this Stream instance to the most recently read
 // message number for the stream.
-func (stream *Stream) Project(projection Handler) error {
+func (stream *Stream) Project(projection Handler, opts ...ProjectOption) error {
 	// TODO add observability
+	options := &projectOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	projector := stream.projector
+	if !options.filter.isZero() {
+		var err error
+		projector, err = stream.filtered(options.filter)
+		if err != nil {
+			return err
+		}
+	}
+
 	stream.mu.Lock()
 	defer stream.mu.Unlock()
 
-	position, err := stream.projector.Project(stream.ctx, stream.Id, stream.lockPosition, projection)
+	position, err := projector.Project(stream.ctx, stream.Id, stream.lockPosition, projection)
 	if err != nil {
 		return err
 	}
@@ -2,11 +2,38 @@ package po
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
 
 	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
 	"github.com/go-po/po/streams"
 )
 
+// Expected position of a stream when appending to it.
+// Zero and above expects the last message of the stream to have exactly that number.
+type ExpectedVersion int64
+
+const (
+	ExpectAny          ExpectedVersion = -2 // append no matter the position of the stream
+	ExpectNoStream     ExpectedVersion = -1 // the stream must not have any messages
+	ExpectStreamExists ExpectedVersion = -3 // the stream must have at least one message
+)
+
+func (expected ExpectedVersion) String() string {
+	switch expected {
+	case ExpectAny:
+		return "any"
+	case ExpectNoStream:
+		return "no stream"
+	case ExpectStreamExists:
+		return "stream exists"
+	default:
+		return fmt.Sprintf("%d", int64(expected))
+	}
+}
+
 type appender interface {
 	Append(ctx context.Context, id streams.Id, position int64, messages ...interface{}) (int64, error)
 }
@@ -17,9 +44,12 @@ func (fn appenderFunc) Append(ctx context.Context, id streams.Id, position int64
 	return fn(ctx, id, position, messages...)
 }
 
+type expectedAppenderFunc func(ctx context.Context, id streams.Id, expected ExpectedVersion, messages ...interface{}) (int64, error)
+
 type appenderStore interface {
 	WriteRecords(ctx context.Context, id streams.Id, data ...record.Data) ([]record.Record, error)
 	WriteRecordsFrom(ctx context.Context, id streams.Id, position int64, data ...record.Data) ([]record.Record, error)
+	ReadRecords(ctx context.Context, id streams.Id, from, to, limit int64) ([]record.Record, error)
 }
 
 type notifier interface {
@@ -27,43 +57,116 @@ type notifier interface {
 }
 
 func newAppenderFunc(store appenderStore, notify notifier, registry Registry) appenderFunc {
+	expectedAppender := newExpectedAppenderFunc(store, notify, registry)
 	return func(ctx context.Context, id streams.Id, position int64, messages ...interface{}) (int64, error) {
 		if len(messages) == 0 {
 			return position, nil
 		}
+		if position < 0 {
+			// this append have not seen the lockPosition yet,
+			// so have to get it from the store when performing the first write
+			return expectedAppender(ctx, id, ExpectAny, messages...)
+		}
+		return expectedAppender(ctx, id, ExpectedVersion(position), messages...)
+	}
+}
+
+func newExpectedAppenderFunc(store appenderStore, notify notifier, registry Registry) expectedAppenderFunc {
+	return func(ctx context.Context, id streams.Id, expected ExpectedVersion, messages ...interface{}) (int64, error) {
+		if len(messages) == 0 {
+			if expected < ExpectNoStream {
+				return -1, nil
+			}
+			return int64(expected), nil
+		}
 		var data []record.Data
 		for _, msg := range messages {
-			b, contentType, err := registry.Marshal(msg)
+			d, err := toRecordData(ctx, registry, msg)
 			if err != nil {
 				return -1, err
 			}
-			data = append(data, record.Data{
-				ContentType: contentType,
-				Data:        b,
-			})
-		}
-
-		var written []record.Record
-		var err error
-		if position < 0 {
-			// this append have not seen the lockPosition yet,
-			// so have to get it from the store when performing the first write
-			written, err = store.WriteRecords(ctx, id, data...)
-		} else {
-			written, err = store.WriteRecordsFrom(ctx, id, position, data...)
+			data = append(data, d)
 		}
 
+		written, err := writeExpected(ctx, store, id, expected, data)
 		if err != nil {
 			return -1, err
 		}
 
+		var position int64 = -1
 		for _, r := range written {
 			// find max
 			if r.Number > position {
 				position = r.Number
 			}
 		}
-		err = notify.Notify(ctx, written...)
-		return position, err
+		err = notifierFromContext(ctx, notify).Notify(ctx, written...)
+		if err != nil {
+			return position, NotifyFailedError{StreamId: id, Position: position, Err: err}
+		}
+		return position, nil
+	}
+}
+
+func writeExpected(ctx context.Context, dao appenderStore, id streams.Id, expected ExpectedVersion, data []record.Data) ([]record.Record, error) {
+	var written []record.Record
+	var err error
+	switch expected {
+	case ExpectAny:
+		written, err = dao.WriteRecords(ctx, id, data...)
+	case ExpectStreamExists:
+		var first []record.Record
+		first, err = dao.ReadRecords(ctx, id, -1, math.MaxInt64, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(first) == 0 {
+			return nil, WrongExpectedVersionError{
+				StreamId: id,
+				Expected: expected,
+				Actual:   -1,
+				Err:      ErrStreamNotFound,
+			}
+		}
+		written, err = dao.WriteRecords(ctx, id, data...)
+	default:
+		if expected < ExpectNoStream {
+			return nil, fmt.Errorf("unknown expected version: %d", int64(expected))
+		}
+		written, err = dao.WriteRecordsFrom(ctx, id, int64(expected), data...)
+	}
+	return written, err
+}
+
+// wraps the failure to notify of written records, pointing at the stream it failed on
+func notifyFailedError(written []record.Record, err error) error {
+	failed := NotifyError{}
+	if !errors.As(err, &failed) {
+		if len(written) == 0 {
+			return err
+		}
+		failed.Stream = written[0].Stream
+	}
+	position := int64(-1)
+	for _, r := range written {
+		if r.Stream.String() == failed.Stream.String() && r.Number > position {
+			position = r.Number
+		}
+	}
+	return NotifyFailedError{StreamId: failed.Stream, Position: position, Err: err}
+}
+
+// converts write conflicts into the error returned to callers
+// explicitly expecting a version of the stream
+func expectedVersionError(id streams.Id, expected ExpectedVersion, err error) error {
+	conflict := store.WriteConflictError{}
+	if errors.As(err, &conflict) {
+		return WrongExpectedVersionError{
+			StreamId: id,
+			Expected: expected,
+			Actual:   conflict.Current,
+			Err:      conflict,
+		}
 	}
+	return err
 }
@@ -2,11 +2,16 @@ package po
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/go-po/po/internal/broker/channels"
+	"github.com/go-po/po/internal/logger"
+	"github.com/go-po/po/internal/observer"
 	"github.com/go-po/po/internal/record"
 	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/internal/store/inmemory"
 	"github.com/go-po/po/streams"
 	"github.com/stretchr/testify/assert"
 )
@@ -55,13 +60,17 @@ func (stub *stubAppenderStore) WriteRecords(ctx context.Context, id streams.Id,
 	for _, data := range datas {
 		stub.incCount()
 		written = append(written, record.Record{
-			Stream:       id,
-			Number:       stub.messageCount,
-			GlobalNumber: stub.messageCount,
-			Data:         data.Data,
-			Group:        id.Group,
-			ContentType:  data.ContentType,
-			Time:         time.Now(),
+			MessageId:     data.MessageId,
+			Stream:        id,
+			Number:        stub.messageCount,
+			GlobalNumber:  stub.messageCount,
+			Data:          data.Data,
+			Group:         id.Group,
+			ContentType:   data.ContentType,
+			Time:          time.Now(),
+			CorrelationId: data.CorrelationId,
+			CausationId:   data.CausationId,
+			Metadata:      data.Metadata,
 		})
 	}
 	stub.records = append(stub.records, written...)
@@ -148,4 +157,150 @@ func TestOptimisticLockingStream_Append(t *testing.T) {
 		verifyAll(t, n, err, store, errWriteConflict())
 	})
 
+	t.Run("correlation from context", func(t *testing.T) {
+		// setup
+		store := &stubAppenderStore{messageCount: 0}
+		sut := newAppenderFunc(store, stubNotifier{}, testRegistry)
+		ctx := streams.ContextWithCorrelationId(ctx, "correlation")
+		ctx = streams.ContextWithCausationId(ctx, "causation")
+		// execute
+		_, err := sut(ctx, streamId, -1, Msg{Name: "Append Test"})
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(store.records)) {
+			assert.Equal(t, "correlation", store.records[0].CorrelationId)
+			assert.Equal(t, "causation", store.records[0].CausationId)
+		}
+	})
+
+	t.Run("correlation from envelope", func(t *testing.T) {
+		// setup
+		store := &stubAppenderStore{messageCount: 0}
+		sut := newAppenderFunc(store, stubNotifier{}, testRegistry)
+		ctx := streams.ContextWithCorrelationId(ctx, "context")
+		// execute
+		_, err := sut(ctx, streamId, -1, Envelope{
+			Message:       Msg{Name: "Append Test"},
+			CorrelationId: "envelope",
+		})
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(store.records)) {
+			assert.Equal(t, "envelope", store.records[0].CorrelationId)
+			assert.Equal(t, "", store.records[0].CausationId)
+			assert.Equal(t, `{"Name":"Append Test"}`, string(store.records[0].Data))
+		}
+	})
+	t.Run("generated message id", func(t *testing.T) {
+		// setup
+		store := &stubAppenderStore{messageCount: 0}
+		sut := newAppenderFunc(store, stubNotifier{}, testRegistry)
+		// execute
+		_, err := sut(ctx, streamId, -1, Msg{Name: "Append Test"}, Msg{Name: "Append Test"})
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 2, len(store.records)) {
+			assert.NotEmpty(t, store.records[0].MessageId)
+			assert.NotEqual(t, store.records[0].MessageId, store.records[1].MessageId)
+		}
+	})
+	t.Run("message id from envelope", func(t *testing.T) {
+		// setup
+		store := &stubAppenderStore{messageCount: 0}
+		sut := newAppenderFunc(store, stubNotifier{}, testRegistry)
+		// execute
+		_, err := sut(ctx, streamId, -1, Envelope{
+			Message:   Msg{Name: "Append Test"},
+			MessageId: "0b6c3f5e-2d0c-4f4e-9a57-2f1d7b6e1c11",
+		})
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(store.records)) {
+			assert.Equal(t, "0b6c3f5e-2d0c-4f4e-9a57-2f1d7b6e1c11", store.records[0].MessageId)
+		}
+	})
+	t.Run("invalid message id", func(t *testing.T) {
+		// setup
+		store := &stubAppenderStore{messageCount: 0}
+		sut := newAppenderFunc(store, stubNotifier{}, testRegistry)
+		// execute
+		_, err := sut(ctx, streamId, -1, Envelope{
+			Message:   Msg{Name: "Append Test"},
+			MessageId: "not a uuid",
+		})
+		// verify
+		assert.Error(t, err)
+		assert.Empty(t, store.records)
+	})
+	t.Run("metadata", func(t *testing.T) {
+		// setup
+		store := &stubAppenderStore{messageCount: 0}
+		sut := newAppenderFunc(store, stubNotifier{}, testRegistry)
+		ctx := streams.ContextWithMetadata(ctx, streams.Metadata{
+			"tenant": []byte(`"acme"`),
+			"user":   []byte(`"context"`),
+		})
+		// execute
+		_, err := sut(ctx, streamId, -1, Envelope{
+			Message:  Msg{Name: "Append Test"},
+			Metadata: streams.Metadata{"user": []byte(`"envelope"`)},
+		})
+		// verify
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(store.records)) {
+			assert.Equal(t, streams.Metadata{
+				"tenant": []byte(`"acme"`),
+				"user":   []byte(`"envelope"`),
+			}, store.records[0].Metadata)
+		}
+	})
+}
+
+func TestStream_AppendExpected(t *testing.T) {
+	ctx := context.Background()
+	streamId := streams.ParseId("expected-1")
+
+	tests := []struct {
+		name     string
+		existing int // messages in the stream before appending
+		expected ExpectedVersion
+		position int64 // position after appending
+		actual   int64 // reported position if wrong expected version
+	}{
+		{name: "any on empty", existing: 0, expected: ExpectAny, position: 0},
+		{name: "any on existing", existing: 3, expected: ExpectAny, position: 3},
+		{name: "no stream on empty", existing: 0, expected: ExpectNoStream, position: 0},
+		{name: "no stream on existing", existing: 3, expected: ExpectNoStream, actual: 2},
+		{name: "exists on empty", existing: 0, expected: ExpectStreamExists, actual: -1},
+		{name: "exists on existing", existing: 3, expected: ExpectStreamExists, position: 3},
+		{name: "exact", existing: 3, expected: 2, position: 3},
+		{name: "exact behind", existing: 3, expected: 1, actual: 2},
+		{name: "exact ahead", existing: 3, expected: 5, actual: 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// setup
+			es := newPo(inmemory.New(), channels.New(), testRegistry, &logger.NoopLogger{}, observer.NewStub(), CatchUpPolicy{})
+			_, err := es.Subscribe(ctx, "expected", streamId, HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+				return nil
+			}))
+			assert.NoError(t, err)
+			for i := 0; i < test.existing; i++ {
+				_, err := es.Append(ctx, streamId, Msg{Name: "existing"})
+				assert.NoError(t, err)
+			}
+			// execute
+			position, err := es.AppendExpected(ctx, streamId, test.expected, Msg{Name: "expected"})
+			// verify
+			wrongVersion := WrongExpectedVersionError{}
+			if errors.As(err, &wrongVersion) {
+				assert.Equal(t, test.expected, wrongVersion.Expected)
+				assert.Equal(t, test.actual, wrongVersion.Actual, "actual position")
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, test.position, position)
+			}
+		})
+	}
 }
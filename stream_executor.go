@@ -2,9 +2,8 @@ package po
 
 import (
 	"context"
-	"errors"
 
-	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/internal/observer/unary"
 	"github.com/go-po/po/streams"
 )
 
@@ -50,21 +49,32 @@ func (appender *optimisticAppender) Size() int64 {
 	return appender.position
 }
 
-func newRetryExecutor(maxRetryCount int, inner executor) executorFunc {
+func newRetryExecutor(policy RetryPolicy, onRetry unary.ClientTrace, inner executor) executorFunc {
 	return func(ctx context.Context, id streams.Id, position int64, cmd CommandHandler) (int64, error) {
-		retryCount := 0
-		var err error
-		for retryCount < maxRetryCount {
+		policy := retryPolicyFromContext(ctx, policy)
+		attempts := 0
+		for {
+			var err error
+			attempts = attempts + 1
 			position, err = inner.Execute(ctx, id, position, cmd)
-			if errors.Is(err, store.WriteConflictError{}) {
-				retryCount = retryCount + 1
-				continue // attempt a retry
+			if err == nil {
+				return position, nil
 			}
-			if err != nil {
+			if !policy.retryable(err) {
 				return position, err // unknown error, bail out
 			}
-			break // all went fine, exit the loop
+			if attempts >= policy.MaxAttempts {
+				return position, RetriesExhaustedError{
+					StreamId: id,
+					Attempts: attempts,
+					Err:      err,
+				}
+			}
+			onRetry.Observe(ctx, id.Group)()
+			err = sleep(ctx, policy.delay(attempts))
+			if err != nil {
+				return position, err
+			}
 		}
-		return position, nil
 	}
 }
@@ -2,9 +2,12 @@ package po
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/go-po/po/internal/observer/unary"
 	"github.com/go-po/po/internal/store"
 	"github.com/go-po/po/streams"
 	"github.com/stretchr/testify/assert"
@@ -95,7 +98,7 @@ func TestExecutorFunc_Execute_Retry(t *testing.T) {
 	t.Run("no retry", func(t *testing.T) {
 		// setup
 		calls := 0
-		exec := newRetryExecutor(3, executorFunc(func(ctx context.Context, id streams.Id, lockPosition int64, cmd CommandHandler) (int64, error) {
+		exec := newRetryExecutor(RetryPolicy{MaxAttempts: 3}, unary.Noop(), executorFunc(func(ctx context.Context, id streams.Id, lockPosition int64, cmd CommandHandler) (int64, error) {
 			calls = calls + 1
 			return 5, nil
 		}))
@@ -110,7 +113,7 @@ func TestExecutorFunc_Execute_Retry(t *testing.T) {
 	t.Run("with 1 retry", func(t *testing.T) {
 		// setup
 		calls := 0
-		exec := newRetryExecutor(10, executorFunc(func(ctx context.Context, id streams.Id, lockPosition int64, cmd CommandHandler) (int64, error) {
+		exec := newRetryExecutor(RetryPolicy{MaxAttempts: 10}, unary.Noop(), executorFunc(func(ctx context.Context, id streams.Id, lockPosition int64, cmd CommandHandler) (int64, error) {
 			calls = calls + 1
 			if calls < 3 {
 				return -1, store.WriteConflictError{StreamId: id, Position: lockPosition}
@@ -128,7 +131,7 @@ func TestExecutorFunc_Execute_Retry(t *testing.T) {
 	t.Run("with multiple retries", func(t *testing.T) {
 		// setup
 		var positions []int64
-		exec := newRetryExecutor(10, executorFunc(func(ctx context.Context, id streams.Id, lockPosition int64, cmd CommandHandler) (int64, error) {
+		exec := newRetryExecutor(RetryPolicy{MaxAttempts: 10}, unary.Noop(), executorFunc(func(ctx context.Context, id streams.Id, lockPosition int64, cmd CommandHandler) (int64, error) {
 			positions = append(positions, lockPosition)
 			if lockPosition < 3 {
 				return lockPosition + 1, store.WriteConflictError{StreamId: id, Position: lockPosition}
@@ -142,4 +145,86 @@ func TestExecutorFunc_Execute_Retry(t *testing.T) {
 		assert.Equal(t, 5, int(pos))
 		assert.Equal(t, []int64{-1, 0, 1, 2, 3}, positions)
 	})
+	t.Run("retries exhausted", func(t *testing.T) {
+		// setup
+		calls := 0
+		exec := newRetryExecutor(RetryPolicy{MaxAttempts: 3}, unary.Noop(), executorFunc(func(ctx context.Context, id streams.Id, lockPosition int64, cmd CommandHandler) (int64, error) {
+			calls = calls + 1
+			return 4, store.WriteConflictError{StreamId: id, Position: lockPosition}
+		}))
+		// execute
+		_, err := exec.Execute(ctx, streamId, -1, newStubCmd(0))
+		// verify
+		exhausted := RetriesExhaustedError{}
+		if assert.True(t, errors.As(err, &exhausted), "retries exhausted error") {
+			assert.Equal(t, 3, exhausted.Attempts)
+		}
+		assert.True(t, errors.Is(err, store.WriteConflictError{}), "wraps the conflict")
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("not retryable", func(t *testing.T) {
+		// setup
+		calls := 0
+		policy := RetryPolicy{MaxAttempts: 3, Retryable: func(err error) bool { return false }}
+		exec := newRetryExecutor(policy, unary.Noop(), executorFunc(func(ctx context.Context, id streams.Id, lockPosition int64, cmd CommandHandler) (int64, error) {
+			calls = calls + 1
+			return 4, store.WriteConflictError{StreamId: id, Position: lockPosition}
+		}))
+		// execute
+		_, err := exec.Execute(ctx, streamId, -1, newStubCmd(0))
+		// verify
+		assert.True(t, errors.Is(err, store.WriteConflictError{}))
+		assert.False(t, errors.As(err, &RetriesExhaustedError{}))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("policy from context", func(t *testing.T) {
+		// setup
+		calls := 0
+		retries := 0
+		onRetry := unary.ClientTraceFunc(func(ctx context.Context, a string) func() {
+			retries = retries + 1
+			return func() {}
+		})
+		exec := newRetryExecutor(RetryPolicy{MaxAttempts: 3}, onRetry, executorFunc(func(ctx context.Context, id streams.Id, lockPosition int64, cmd CommandHandler) (int64, error) {
+			calls = calls + 1
+			return 4, store.WriteConflictError{StreamId: id, Position: lockPosition}
+		}))
+		ctx := ContextWithRetryPolicy(ctx, RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond})
+		// execute
+		_, err := exec.Execute(ctx, streamId, -1, newStubCmd(0))
+		// verify
+		assert.True(t, errors.As(err, &RetriesExhaustedError{}))
+		assert.Equal(t, 5, calls)
+		assert.Equal(t, 4, retries)
+	})
+
+	t.Run("cancelled while waiting", func(t *testing.T) {
+		// setup
+		ctx, cancel := context.WithCancel(ctx)
+		exec := newRetryExecutor(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour}, unary.Noop(), executorFunc(func(ctx context.Context, id streams.Id, lockPosition int64, cmd CommandHandler) (int64, error) {
+			cancel()
+			return 4, store.WriteConflictError{StreamId: id, Position: lockPosition}
+		}))
+		// execute
+		_, err := exec.Execute(ctx, streamId, -1, newStubCmd(0))
+		// verify
+		assert.True(t, errors.Is(err, context.Canceled))
+	})
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	assert.Equal(t, 10*time.Millisecond, policy.delay(1))
+	assert.Equal(t, 20*time.Millisecond, policy.delay(2))
+	assert.Equal(t, 40*time.Millisecond, policy.delay(3))
+	assert.Equal(t, 50*time.Millisecond, policy.delay(4))
+	assert.Equal(t, 50*time.Millisecond, policy.delay(40))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay := policy.delay(2)
+		assert.True(t, delay > 10*time.Millisecond && delay <= 20*time.Millisecond, "jittered delay %s", delay)
+	}
 }
@@ -3,7 +3,9 @@ package po
 import (
 	"context"
 	"encoding/json"
+	"math"
 
+	"github.com/go-po/po/internal/broker"
 	"github.com/go-po/po/internal/pager"
 	"github.com/go-po/po/internal/record"
 	"github.com/go-po/po/streams"
@@ -73,6 +75,51 @@ func newProjectorFunc(store projectorStore, registry Registry) projectorFunc {
 	}
 }
 
+// Projects the records of the content types only, and the messages the predicate accepts, if any.
+// The lock position moves past the messages skipped, up to the last message of the stream.
+func newFilteredProjectorFunc(store projectorStore, registry Registry, contentTypes []string, predicate func(msg streams.Message) bool) projectorFunc {
+	return func(ctx context.Context, id streams.Id, lockPosition int64, projection Handler) (int64, error) {
+		const pageSize = 100
+		for {
+			records, position, err := broker.ReadRecordsOfTypes(ctx, store, id, lockPosition, math.MaxInt64, pageSize, contentTypes)
+			if err != nil {
+				return -1, err
+			}
+			for _, r := range records {
+				message, err := registry.ToMessage(r)
+				if err != nil {
+					return -1, err
+				}
+				if predicate != nil && !predicate(message) {
+					continue
+				}
+				err = projection.Handle(ctx, message)
+				if err != nil {
+					return -1, err
+				}
+			}
+			if position > lockPosition {
+				lockPosition = position
+			}
+			if len(records) < pageSize {
+				return lockPosition, nil
+			}
+		}
+	}
+}
+
+// builds the projectors of the messages filters let through.
+// They never snapshot, as the state of a filtered projection must not be read back by an unfiltered one.
+func newFilteredProjectors(store projectorStore, registry Registry) func(filter Filter) (projector, error) {
+	return func(filter Filter) (projector, error) {
+		contentTypes, err := filter.contentTypes(registry)
+		if err != nil {
+			return nil, err
+		}
+		return newFilteredProjectorFunc(store, registry, contentTypes, filter.predicate), nil
+	}
+}
+
 type snapshotStore interface {
 	ReadSnapshot(ctx context.Context, id streams.Id, snapshotId string) (record.Snapshot, error)
 	UpdateSnapshot(ctx context.Context, id streams.Id, snapshotId string, snapshot record.Snapshot) error
@@ -82,12 +129,15 @@ func newSnapshots(store snapshotStore, inner projector) projectorFunc {
 	var reader projector = newSnapshotReader(store)
 	var writer projector = newSnapshotWriter(store)
 
-	// TODO Observe errors from the reader and writer.
-	// They should never fail though, as projection
+	// TODO Observe errors from the writer.
+	// It should never fail though, as projection
 	// must not halter execution.
 	return func(ctx context.Context, id streams.Id, lockPosition int64, projection Handler) (int64, error) {
-		var err error
-		position, _ := reader.Project(ctx, id, lockPosition, projection)
+		position, err := reader.Project(ctx, id, lockPosition, projection)
+		if decodeErr, isDecode := err.(SnapshotDecodeError); isDecode {
+			// the projection might be half filled by the snapshot, so it is not projected on top of it
+			return lockPosition, decodeErr
+		}
 
 		position, err = inner.Project(ctx, id, position, projection)
 		if err != nil {
@@ -125,8 +175,9 @@ func newSnapshotWriter(store snapshotStore) projectorFunc {
 	}
 }
 
-// reads snapshots, but never fails as snapshotting should just default
-// to not working if something goes wrong
+// Reads snapshots. Failing to read the snapshot leaves the projection as it was,
+// so the caller can project from the start instead, while a snapshot failing to decode
+// fails with a SnapshotDecodeError, as it might have filled part of the projection.
 func newSnapshotReader(store snapshotStore) projectorFunc {
 	return func(ctx context.Context, id streams.Id, lockPosition int64, projection Handler) (int64, error) {
 		snap, supportsSnapshot := projection.(streams.NamedSnapshot)
@@ -139,7 +190,7 @@ func newSnapshotReader(store snapshotStore) projectorFunc {
 			err = json.Unmarshal(snapshot.Data, projection)
 			if err != nil {
 				// failed to unmarshal, discard
-				return lockPosition, err
+				return lockPosition, SnapshotDecodeError{StreamId: id, SnapshotId: snap.SnapshotName(), Err: err}
 			}
 			return snapshot.Position, nil
 		}
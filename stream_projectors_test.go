@@ -2,6 +2,7 @@ package po
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 
@@ -168,6 +169,28 @@ func TestOptimisticLockingStream_Project_Snapshot(t *testing.T) {
 		assert.Equal(t, 10, int(store.snapshot.Position))
 	})
 
+	t.Run("corrupt snapshot", func(t *testing.T) {
+		// setup
+		projected := false
+		inner := projectorFunc(func(ctx context.Context, id streams.Id, lockPosition int64, projection Handler) (int64, error) {
+			projected = true
+			return 10, nil
+		})
+		sut, store := newTestFixture(4, inner)
+		store.snapshot.Data = []byte(`{"broken`)
+
+		// execute
+		pos, err := sut.Project(ctx, streamId, -1, newProjectionHandler(func(ctx context.Context, msg streams.Message) error {
+			return nil
+		}))
+
+		// verify
+		assert.True(t, errors.Is(err, ErrSnapshotDecode), "decode error: %v", err)
+		assert.Equal(t, -1, int(pos))
+		assert.False(t, projected, "projected on top of the snapshot")
+		assert.Equal(t, 0, store.writes)
+	})
+
 	t.Run("not projector", func(t *testing.T) {
 		// setup
 		inner := projectorFunc(func(ctx context.Context, id streams.Id, lockPosition int64, projection Handler) (int64, error) {
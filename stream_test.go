@@ -10,6 +10,10 @@ type Msg struct {
 	Name string
 }
 
+type OtherMsg struct {
+	Name string
+}
+
 var testRegistry = registry.New()
 
 func init() {
@@ -18,4 +22,9 @@ func init() {
 		err := json.Unmarshal(b, &msg)
 		return msg, err
 	})
+	testRegistry.Register(func(b []byte) (interface{}, error) {
+		msg := OtherMsg{}
+		err := json.Unmarshal(b, &msg)
+		return msg, err
+	})
 }
@@ -0,0 +1,50 @@
+package streams
+
+import (
+	"context"
+	"fmt"
+)
+
+type correlationIdKey struct{}
+type causationIdKey struct{}
+
+// Returns a copy of ctx carrying the correlation id.
+// Messages appended using the returned context are stored with the id.
+func ContextWithCorrelationId(ctx context.Context, correlationId string) context.Context {
+	return context.WithValue(ctx, correlationIdKey{}, correlationId)
+}
+
+// Returns a copy of ctx carrying the causation id.
+// Messages appended using the returned context are stored with the id.
+func ContextWithCausationId(ctx context.Context, causationId string) context.Context {
+	return context.WithValue(ctx, causationIdKey{}, causationId)
+}
+
+// Returns a copy of ctx carrying the correlation and causation id
+// for messages caused by handling the given message.
+// If the message have no correlation id, the message itself starts
+// the correlation.
+func ContextWithMessage(ctx context.Context, msg Message) context.Context {
+	causationId := msg.MessageId
+	if causationId == "" {
+		causationId = fmt.Sprintf("%s#%d", msg.Stream, msg.Number)
+	}
+	correlationId := msg.CorrelationId
+	if correlationId == "" {
+		correlationId = causationId
+	}
+	ctx = ContextWithCorrelationId(ctx, correlationId)
+	return ContextWithCausationId(ctx, causationId)
+}
+
+// The correlation id carried by ctx, or the empty string if none
+func CorrelationIdFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIdKey{}).(string)
+	return id
+}
+
+// The causation id carried by ctx, or the empty string if none
+func CausationIdFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(causationIdKey{}).(string)
+	return id
+}
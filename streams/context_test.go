@@ -0,0 +1,36 @@
+package streams
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextWithMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       Message
+		correlation string
+		causation   string
+	}{
+		{name: "uncorrelated",
+			input:       Message{Stream: ParseId("users-peter"), Number: 3},
+			correlation: "users-peter#3", causation: "users-peter#3"},
+		{name: "correlated",
+			input:       Message{Stream: ParseId("users-peter"), Number: 3, CorrelationId: "flow"},
+			correlation: "flow", causation: "users-peter#3"},
+		{name: "with message id",
+			input:       Message{MessageId: "message", Stream: ParseId("users-peter"), Number: 3},
+			correlation: "message", causation: "message"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// execute
+			got := ContextWithMessage(context.Background(), test.input)
+			// verify
+			assert.Equal(t, test.correlation, CorrelationIdFromContext(got))
+			assert.Equal(t, test.causation, CausationIdFromContext(got))
+		})
+	}
+}
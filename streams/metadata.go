@@ -0,0 +1,56 @@
+package streams
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Values stored along with a message, but not part of it.
+// Each value is kept as its JSON encoding.
+type Metadata map[string]json.RawMessage
+
+// Stores the JSON encoding of value under key
+func (md Metadata) Set(key string, value interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	md[key] = b
+	return nil
+}
+
+// Decodes the value stored under key into target.
+// Reports false if no value is stored under the key.
+func (md Metadata) Get(key string, target interface{}) (bool, error) {
+	b, found := md[key]
+	if !found {
+		return false, nil
+	}
+	return true, json.Unmarshal(b, target)
+}
+
+// Returns a new Metadata with the values of md overwritten by those of other
+func (md Metadata) Merge(other Metadata) Metadata {
+	merged := make(Metadata, len(md)+len(other))
+	for key, value := range md {
+		merged[key] = value
+	}
+	for key, value := range other {
+		merged[key] = value
+	}
+	return merged
+}
+
+type metadataKey struct{}
+
+// Returns a copy of ctx carrying md merged on top of any metadata already carried.
+// Messages appended using the returned context are stored with the metadata.
+func ContextWithMetadata(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, MetadataFromContext(ctx).Merge(md))
+}
+
+// The metadata carried by ctx, or nil if none
+func MetadataFromContext(ctx context.Context) Metadata {
+	md, _ := ctx.Value(metadataKey{}).(Metadata)
+	return md
+}
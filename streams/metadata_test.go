@@ -0,0 +1,41 @@
+package streams
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadata(t *testing.T) {
+	t.Run("set and get", func(t *testing.T) {
+		// setup
+		md := Metadata{}
+		// execute
+		err := md.Set("user", struct{ Id int }{Id: 7})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, `{"Id":7}`, string(md["user"]))
+		var got struct{ Id int }
+		found, err := md.Get("user", &got)
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, 7, got.Id)
+	})
+
+	t.Run("get missing", func(t *testing.T) {
+		var got string
+		found, err := Metadata{}.Get("user", &got)
+		assert.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("context", func(t *testing.T) {
+		// setup
+		ctx := ContextWithMetadata(context.Background(), Metadata{"a": []byte(`1`), "b": []byte(`2`)})
+		// execute
+		ctx = ContextWithMetadata(ctx, Metadata{"b": []byte(`3`)})
+		// verify
+		assert.Equal(t, Metadata{"a": []byte(`1`), "b": []byte(`3`)}, MetadataFromContext(ctx))
+	})
+}
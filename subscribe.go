@@ -0,0 +1,92 @@
+package po
+
+import (
+	"time"
+
+	"github.com/go-po/po/internal/broker"
+	"github.com/go-po/po/streams"
+)
+
+// Where a subscription is and how far behind, listed by Subscriptions
+type SubscriptionState = broker.SubscriptionState
+
+// Handle of a subscriber, returned by Subscribe
+type Subscription struct {
+	broker         Broker
+	subscriptionId string
+	streamId       streams.Id
+}
+
+// Stops the subscriber once its batch in flight is committed.
+// Partitioned subscribers leave their partitions to the other instances.
+func (sub *Subscription) Unsubscribe() error {
+	return sub.broker.Unregister(sub.subscriptionId, sub.streamId)
+}
+
+type subscribeOptions struct {
+	errorPolicy SubscriberErrorPolicy
+	partitions  int
+	start       broker.Start // nil for the beginning
+	filter      Filter
+}
+
+type SubscribeOption func(opt *subscribeOptions)
+
+// Overrides the error policy set with WithSubscriberErrorPolicy for a single subscriber
+func SubscribeWithErrorPolicy(policy SubscriberErrorPolicy) SubscribeOption {
+	return func(opt *subscribeOptions) {
+		opt.errorPolicy = policy
+	}
+}
+
+// Shares the messages of a group between every instance subscribing with the same subscription id.
+// The streams of the group are split into the partitions by their entity, and each partition is
+// handled by one instance at a time, keeping the order of each stream.
+// Needs a store able to hold leases, and the same number of partitions on every instance.
+func SubscribeWithPartitions(partitions int) SubscribeOption {
+	return func(opt *subscribeOptions) {
+		opt.partitions = partitions
+	}
+}
+
+// Starts a new subscription at the position, instead of the beginning of the stream.
+// A subscription with a stored position resumes from it.
+func SubscribeFrom(position Position) SubscribeOption {
+	return func(opt *subscribeOptions) {
+		opt.start = position.start
+	}
+}
+
+// Hands the messages the filter lets through only to the subscriber.
+// The position of the subscription moves past the messages skipped.
+func SubscribeWithFilter(filter Filter) SubscribeOption {
+	return func(opt *subscribeOptions) {
+		opt.filter = filter
+	}
+}
+
+// Where a subscription starts, or is moved to with MoveSubscription.
+// The zero value is the beginning of the stream.
+type Position struct {
+	start broker.Start
+}
+
+// Every message of the stream
+func FromBeginning() Position {
+	return Position{}
+}
+
+// New messages only, after the last one appended
+func FromHead() Position {
+	return Position{start: broker.StartAtHead()}
+}
+
+// From the message with the number, the global number when subscribing to a group
+func FromNumber(number int64) Position {
+	return Position{start: broker.StartAtNumber(number)}
+}
+
+// From the first message appended at or after the time
+func FromTime(t time.Time) Position {
+	return Position{start: broker.StartAtTime(t)}
+}
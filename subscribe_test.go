@@ -0,0 +1,353 @@
+package po
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-po/po/internal/broker/channels"
+	"github.com/go-po/po/internal/store/filelog"
+	"github.com/go-po/po/internal/store/inmemory"
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPo_SubscribeWithPartitions(t *testing.T) {
+	t.Run("partitioned", func(t *testing.T) {
+		// setup
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		es, err := NewFromOptions(
+			WithStoreInMemory(),
+			WithProtocolChannels(),
+			WithRegistry(testRegistry),
+			WithLeaseTTL(time.Second),
+		)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		mu := sync.Mutex{}
+		received := make(map[string]int)
+		_, err = es.Subscribe(ctx, "partitioned", streams.ParseId("shares"), HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			received[msg.Stream.String()] = received[msg.Stream.String()] + 1
+			return nil
+		}), SubscribeWithPartitions(4))
+		assert.NoError(t, err)
+
+		// execute
+		for _, entity := range []string{"a", "b", "c", "d", "e"} {
+			_, _ = es.Append(ctx, streams.ParseId("shares-%s", entity), Msg{Name: entity})
+		}
+
+		// verify
+		assert.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(received) == 5
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("store without leases", func(t *testing.T) {
+		// setup
+		dir, err := ioutil.TempDir("", "po-partitions")
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer func() {
+			_ = os.RemoveAll(dir)
+		}()
+		store, err := filelog.New(dir)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer func() {
+			_ = store.Close()
+		}()
+		es := New(store, channels.New())
+		// execute
+		_, err = es.Subscribe(context.Background(), "partitioned", streams.ParseId("shares"), HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+			return nil
+		}), SubscribeWithPartitions(4))
+		// verify
+		assert.True(t, errors.Is(err, ErrNoLeases))
+	})
+}
+
+func TestSubscription_Unsubscribe(t *testing.T) {
+	// setup
+	ctx := context.Background()
+	es, err := NewFromOptions(WithStoreInMemory(), WithProtocolChannels(), WithRegistry(testRegistry))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	received := 0
+	sub, err := es.Subscribe(ctx, "unsubscribed", streams.ParseId("unsubscribe"), HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+		received = received + 1
+		return nil
+	}))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, _ = es.Append(ctx, streams.ParseId("unsubscribe-1"), Msg{Name: "before"})
+
+	// execute
+	err = sub.Unsubscribe()
+	_, _ = es.Append(ctx, streams.ParseId("unsubscribe-1"), Msg{Name: "after"})
+
+	// verify
+	assert.NoError(t, err)
+	assert.Equal(t, 1, received)
+	assert.True(t, errors.Is(sub.Unsubscribe(), ErrNoSubscriber), "unsubscribed twice")
+}
+
+func TestSubscription_UnsubscribeSameIdTwice(t *testing.T) {
+	// setup
+	ctx := context.Background()
+	es, err := NewFromOptions(WithStoreInMemory(), WithProtocolChannels(), WithRegistry(testRegistry))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	handler := HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+		return nil
+	})
+	sub, err := es.Subscribe(ctx, "twice", streams.ParseId("twice-1"), handler)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// execute
+	_, err = es.Subscribe(ctx, "twice", streams.ParseId("twice-2"), handler)
+
+	// verify
+	assert.True(t, errors.Is(err, ErrSubscribed), "same id on the group")
+	assert.NoError(t, sub.Unsubscribe())
+	_, err = es.Subscribe(ctx, "twice", streams.ParseId("twice-2"), handler)
+	assert.NoError(t, err, "subscribed once unsubscribed")
+}
+
+func TestPo_Close(t *testing.T) {
+	// setup
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "po-close")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	log, err := NewStoreFileLog(dir)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	es, err := NewFromOptions(WithStore(log), WithProtocolChannels(), WithRegistry(testRegistry))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = es.Subscribe(ctx, "closed", streams.ParseId("close"), HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+		return nil
+	}))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// execute
+	err = es.Close(ctx)
+
+	// verify
+	assert.NoError(t, err)
+	_, err = es.Subscribe(ctx, "closed", streams.ParseId("close"), HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+		return nil
+	}))
+	assert.True(t, errors.Is(err, ErrClosed), "subscribe after close")
+	_, err = es.Append(ctx, streams.ParseId("close-1"), Msg{Name: "closed"})
+	assert.Error(t, err, "store closed")
+	assert.NoError(t, es.Close(ctx), "closed twice")
+}
+
+func TestPo_AppendWithoutSubscriber(t *testing.T) {
+	// setup
+	ctx := context.Background()
+	es, err := NewFromOptions(WithStoreInMemory(), WithProtocolChannels(), WithRegistry(testRegistry))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// execute
+	position, err := es.Append(ctx, streams.ParseId("unsubscribed-1"), Msg{Name: "written"})
+
+	// verify
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), position)
+
+	t.Run("subscribed later", func(t *testing.T) {
+		// setup
+		received := 0
+		_, err := es.Subscribe(ctx, "later", streams.ParseId("unsubscribed"), HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+			received = received + 1
+			return nil
+		}))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		_, err = es.Append(ctx, streams.ParseId("unsubscribed-1"), Msg{Name: "notified"})
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, 2, received)
+	})
+}
+
+// records the names of the messages received
+type nameLog struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (log *nameLog) Handle(ctx context.Context, msg streams.Message) error {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	log.names = append(log.names, msg.Data.(Msg).Name)
+	return nil
+}
+
+func (log *nameLog) received() []string {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	return append([]string(nil), log.names...)
+}
+
+func TestPo_SubscribeFrom(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		position func(es *Po) Position
+		want     []string
+	}{
+		{name: "beginning", position: func(es *Po) Position { return FromBeginning() }, want: []string{"a", "b", "c"}},
+		{name: "head", position: func(es *Po) Position { return FromHead() }, want: []string{"c"}},
+		{name: "number", position: func(es *Po) Position { return FromNumber(2) }, want: []string{"b", "c"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// setup
+			es, err := NewFromOptions(WithStoreInMemory(), WithProtocolChannels(), WithRegistry(testRegistry))
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			_, err = es.Append(ctx, streams.ParseId("from-1"), Msg{Name: "a"}, Msg{Name: "b"})
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			log := &nameLog{}
+			// execute
+			_, err = es.Subscribe(ctx, "from", streams.ParseId("from"), log, SubscribeFrom(test.position(es)))
+			_, _ = es.Append(ctx, streams.ParseId("from-1"), Msg{Name: "c"})
+			// verify
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, log.received())
+		})
+	}
+
+	entityTests := []struct {
+		name     string
+		position Position
+		want     []string
+	}{
+		{name: "beginning", position: FromBeginning(), want: []string{"a", "b", "c"}},
+		{name: "head", position: FromHead(), want: []string{"c"}},
+		{name: "number", position: FromNumber(1), want: []string{"b", "c"}},
+	}
+	for _, test := range entityTests {
+		t.Run("entity stream "+test.name, func(t *testing.T) {
+			// setup
+			es, err := NewFromOptions(WithStoreInMemory(), WithProtocolChannels(), WithRegistry(testRegistry))
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			_, err = es.Append(ctx, streams.ParseId("from-1"), Msg{Name: "a"}, Msg{Name: "b"})
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			log := &nameLog{}
+			// execute
+			_, err = es.Subscribe(ctx, "from", streams.ParseId("from-1"), log, SubscribeFrom(test.position))
+			_, _ = es.Append(ctx, streams.ParseId("from-2"), Msg{Name: "other"})
+			_, _ = es.Append(ctx, streams.ParseId("from-1"), Msg{Name: "c"})
+			// verify
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, log.received())
+		})
+	}
+
+	t.Run("store without positions", func(t *testing.T) {
+		// setup
+		es, err := NewFromOptions(WithStore(struct{ Store }{inmemory.New()}), WithProtocolChannels(), WithRegistry(testRegistry))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		// execute
+		_, err = es.Subscribe(ctx, "from", streams.ParseId("from"), &nameLog{}, SubscribeFrom(FromHead()))
+		// verify
+		assert.True(t, errors.Is(err, ErrNoPositions))
+	})
+}
+
+func TestPo_MoveSubscription(t *testing.T) {
+	// setup
+	ctx := context.Background()
+	es, err := NewFromOptions(WithStoreInMemory(), WithProtocolChannels(), WithRegistry(testRegistry))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	log := &nameLog{}
+	_, err = es.Subscribe(ctx, "moved", streams.ParseId("move"), log)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = es.Append(ctx, streams.ParseId("move-1"), Msg{Name: "a"}, Msg{Name: "b"})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// execute
+	err = es.MoveSubscription(ctx, "moved", streams.ParseId("move"), FromBeginning())
+
+	// verify
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return len(log.received()) == 4 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"a", "b", "a", "b"}, log.received())
+}
+
+func TestPo_Subscriptions(t *testing.T) {
+	// setup
+	ctx := context.Background()
+	es, err := NewFromOptions(WithStoreInMemory(), WithProtocolChannels(), WithRegistry(testRegistry))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = es.Subscribe(ctx, "listed", streams.ParseId("list"), HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+		return errors.New("refused")
+	}))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, _ = es.Append(ctx, streams.ParseId("list-1"), Msg{Name: "a"}, Msg{Name: "b"})
+
+	// execute
+	subscriptions, err := es.Subscriptions(ctx)
+
+	// verify
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(subscriptions)) {
+		assert.Equal(t, "listed", subscriptions[0].SubscriptionId)
+		assert.Equal(t, int64(-1), subscriptions[0].Position)
+		assert.Equal(t, int64(2), subscriptions[0].Lag)
+		assert.Equal(t, "refused", subscriptions[0].LastError)
+	}
+}
@@ -0,0 +1,164 @@
+package po
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/internal/store/postgres"
+	"github.com/go-po/po/streams"
+)
+
+// Database transaction owned by the application, such as a *sql.Tx,
+// or the *sql.Conn the application began its transaction on
+type DBTX interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	PrepareContext(context.Context, string) (*sql.Stmt, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+// Appends made within a database transaction owned by the application.
+// Subscribers are notified of the appended messages once Commit succeeds.
+type Tx struct {
+	ctx    context.Context
+	tx     DBTX
+	po     *Po
+	broker *deferredBroker
+}
+
+// Binds appends, executions and projections to the transaction.
+// Requires the Postgres store.
+func (po *Po) WithTx(ctx context.Context, tx DBTX) (*Tx, error) {
+	s := po.store
+	if observed, ok := s.(*observesStore); ok {
+		s = observed.store
+	}
+	pg, ok := s.(*postgres.Storage)
+	if !ok {
+		return nil, fmt.Errorf("po: store %T can not take part in a sql transaction", s)
+	}
+	broker := &deferredBroker{Broker: po.broker}
+	return &Tx{
+		ctx: ctx,
+		tx:  tx,
+		po: &Po{
+			obs:      po.obs,
+			builder:  po.builder,
+			logger:   po.logger,
+			store:    observeStore(pg.WithTx(tx), po.builder),
+			broker:   broker,
+			registry: po.registry,
+			retry:    po.retry,
+			lock:     po.lock,
+		},
+		broker: broker,
+	}, nil
+}
+
+func (tx *Tx) Stream(id streams.Id) *Stream {
+	return tx.po.Stream(tx.ctx, id)
+}
+
+func (tx *Tx) Project(id streams.Id, projection Handler, opts ...ProjectOption) error {
+	return tx.po.Project(tx.ctx, id, projection, opts...)
+}
+
+func (tx *Tx) Execute(id streams.Id, exec CommandHandler) error {
+	return tx.po.Execute(tx.ctx, id, exec)
+}
+
+func (tx *Tx) Append(id streams.Id, messages ...interface{}) (int64, error) {
+	return tx.po.Append(tx.ctx, id, messages...)
+}
+
+func (tx *Tx) AppendExpected(id streams.Id, expected ExpectedVersion, messages ...interface{}) (int64, error) {
+	return tx.po.AppendExpected(tx.ctx, id, expected, messages...)
+}
+
+func (tx *Tx) UnitOfWork() *UnitOfWork {
+	return tx.po.UnitOfWork(tx.ctx)
+}
+
+// Records the position of a subscription as part of the transaction,
+// for subscribers handling messages within it.
+// Positions only move forward, the first one recorded is taken as given.
+func (tx *Tx) SetSubscriptionPosition(id streams.Id, subscriptionId string, position int64) error {
+	storeTx, err := tx.po.store.Begin(tx.ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = storeTx.Rollback()
+	}()
+	_, err = tx.po.store.SubscriptionPositionLock(storeTx, id, subscriptionId)
+	if err != nil {
+		return err
+	}
+	err = tx.po.store.SetSubscriptionPosition(storeTx, id, store.SubscriptionPosition{
+		SubscriptionId: subscriptionId,
+		Position:       position,
+	})
+	if err != nil {
+		return err
+	}
+	return storeTx.Commit()
+}
+
+// Commits the transaction and then notifies subscribers of the appended messages.
+// A DBTX unable to commit, such as a *sql.Conn, is committed by the application before calling Commit.
+func (tx *Tx) Commit() error {
+	if committer, ok := tx.tx.(interface{ Commit() error }); ok {
+		err := committer.Commit()
+		if err != nil {
+			tx.broker.discard()
+			return err
+		}
+	}
+	return tx.broker.flush(tx.ctx)
+}
+
+// Rolls back the transaction, discarding the appended messages.
+// A DBTX unable to roll back is rolled back by the application.
+func (tx *Tx) Rollback() error {
+	tx.broker.discard()
+	if rollbacker, ok := tx.tx.(interface{ Rollback() error }); ok {
+		return rollbacker.Rollback()
+	}
+	return nil
+}
+
+// holds back notifications until the transaction is committed
+type deferredBroker struct {
+	Broker
+	mu      sync.Mutex
+	pending []record.Record
+}
+
+func (broker *deferredBroker) Notify(ctx context.Context, records ...record.Record) error {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	broker.pending = append(broker.pending, records...)
+	return nil
+}
+
+func (broker *deferredBroker) flush(ctx context.Context) error {
+	broker.mu.Lock()
+	pending := broker.pending
+	broker.pending = nil
+	broker.mu.Unlock()
+	err := broker.Broker.Notify(ctx, pending...)
+	if err != nil {
+		return notifyFailedError(pending, err)
+	}
+	return nil
+}
+
+func (broker *deferredBroker) discard() {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	broker.pending = nil
+}
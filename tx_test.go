@@ -0,0 +1,61 @@
+package po
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-po/po/internal/broker/channels"
+	"github.com/go-po/po/internal/logger"
+	"github.com/go-po/po/internal/observer"
+	"github.com/go-po/po/internal/record"
+	"github.com/go-po/po/internal/store/inmemory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPo_WithTx(t *testing.T) {
+	// setup
+	es := newPo(inmemory.New(), channels.New(), testRegistry, &logger.NoopLogger{}, observer.NewStub(), CatchUpPolicy{})
+	// execute
+	_, err := es.WithTx(context.Background(), nil)
+	// verify
+	assert.Error(t, err, "in-memory store can not join a sql transaction")
+}
+
+type recordingBroker struct {
+	Broker
+	notified []record.Record
+}
+
+func (broker *recordingBroker) Notify(ctx context.Context, records ...record.Record) error {
+	broker.notified = append(broker.notified, records...)
+	return nil
+}
+
+func TestDeferredBroker(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("flush", func(t *testing.T) {
+		// setup
+		recorder := &recordingBroker{}
+		deferred := &deferredBroker{Broker: recorder}
+		assert.NoError(t, deferred.Notify(ctx, record.Record{Number: 0}, record.Record{Number: 1}))
+		assert.Empty(t, recorder.notified)
+		// execute
+		err := deferred.flush(ctx)
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(recorder.notified))
+	})
+
+	t.Run("discard", func(t *testing.T) {
+		// setup
+		recorder := &recordingBroker{}
+		deferred := &deferredBroker{Broker: recorder}
+		assert.NoError(t, deferred.Notify(ctx, record.Record{Number: 0}))
+		// execute
+		deferred.discard()
+		// verify
+		assert.NoError(t, deferred.flush(ctx))
+		assert.Empty(t, recorder.notified)
+	})
+}
@@ -0,0 +1,115 @@
+package po
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-po/po/internal/store"
+	"github.com/go-po/po/streams"
+)
+
+// Collects appends to several streams and writes all of them in
+// a single store transaction on Commit.
+// Either every append is written or none of them are.
+type UnitOfWork struct {
+	ctx      context.Context
+	store    Store
+	broker   Broker
+	registry Registry
+	appends  []unitAppend
+}
+
+type unitAppend struct {
+	id       streams.Id
+	expected ExpectedVersion
+	messages []interface{}
+}
+
+// Starts a unit of work appending to any number of streams
+func (po *Po) UnitOfWork(ctx context.Context) *UnitOfWork {
+	return &UnitOfWork{
+		ctx:      ctx,
+		store:    po.store,
+		broker:   po.broker,
+		registry: po.registry,
+	}
+}
+
+// Appends the messages to the stream no matter its position
+func (uow *UnitOfWork) Append(id streams.Id, messages ...interface{}) {
+	uow.AppendExpected(id, ExpectAny, messages...)
+}
+
+// Appends the messages to the stream if it is at the expected version when committing
+func (uow *UnitOfWork) AppendExpected(id streams.Id, expected ExpectedVersion, messages ...interface{}) {
+	if len(messages) == 0 {
+		return
+	}
+	uow.appends = append(uow.appends, unitAppend{
+		id:       id,
+		expected: expected,
+		messages: messages,
+	})
+}
+
+// Writes all the appends in one transaction and notifies subscribers once it is committed.
+// If any stream is not at its expected version, nothing is written
+// and a WrongExpectedVersionError is returned.
+func (uow *UnitOfWork) Commit() error {
+	appends := uow.appends
+	uow.appends = nil
+	if len(appends) == 0 {
+		return nil
+	}
+
+	var writes []store.StreamWrite
+	for _, a := range appends {
+		if a.expected < ExpectStreamExists {
+			return fmt.Errorf("unknown expected version: %d", int64(a.expected))
+		}
+		write := store.StreamWrite{
+			Id:       a.id,
+			Position: storePosition(a.expected),
+		}
+		for _, msg := range a.messages {
+			d, err := toRecordData(uow.ctx, uow.registry, msg)
+			if err != nil {
+				return err
+			}
+			write.Data = append(write.Data, d)
+		}
+		writes = append(writes, write)
+	}
+
+	written, err := uow.store.WriteBatch(uow.ctx, writes...)
+	if err != nil {
+		conflict := store.WriteConflictError{}
+		if errors.As(err, &conflict) {
+			for _, a := range appends {
+				if a.id.String() == conflict.StreamId.String() {
+					return expectedVersionError(a.id, a.expected, err)
+				}
+			}
+		}
+		return err
+	}
+
+	err = uow.broker.Notify(uow.ctx, written...)
+	if err != nil {
+		return notifyFailedError(written, err)
+	}
+	return nil
+}
+
+// position given to the store when appending at the expected version
+func storePosition(expected ExpectedVersion) int64 {
+	switch expected {
+	case ExpectAny:
+		return store.AnyPosition
+	case ExpectStreamExists:
+		return store.ExistingStream
+	default:
+		return int64(expected)
+	}
+}
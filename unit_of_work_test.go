@@ -0,0 +1,98 @@
+package po
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-po/po/internal/broker/channels"
+	"github.com/go-po/po/internal/logger"
+	"github.com/go-po/po/internal/observer"
+	"github.com/go-po/po/internal/store/inmemory"
+	"github.com/go-po/po/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnitOfWork_Commit(t *testing.T) {
+	ctx := context.Background()
+	from := streams.ParseId("accounts-from")
+	to := streams.ParseId("accounts-to")
+
+	setup := func(t *testing.T) (*Po, func() int) {
+		es := newPo(inmemory.New(), channels.New(), testRegistry, &logger.NoopLogger{}, observer.NewStub(), CatchUpPolicy{})
+		mu := sync.Mutex{}
+		received := 0
+		_, err := es.Subscribe(ctx, "unit-of-work", streams.ParseId("accounts"), HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			received = received + 1
+			return nil
+		}))
+		assert.NoError(t, err)
+		return es, func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return received
+		}
+	}
+
+	size := func(t *testing.T, es *Po, id streams.Id) int {
+		count := 0
+		err := es.Project(ctx, id, HandlerFunc(func(ctx context.Context, msg streams.Message) error {
+			count = count + 1
+			return nil
+		}))
+		assert.NoError(t, err)
+		return count
+	}
+
+	t.Run("all streams", func(t *testing.T) {
+		// setup
+		es, received := setup(t)
+		uow := es.UnitOfWork(ctx)
+		uow.AppendExpected(from, ExpectNoStream, Msg{Name: "withdrawn"})
+		uow.AppendExpected(to, ExpectNoStream, Msg{Name: "deposited"}, Msg{Name: "deposited"})
+		// execute
+		err := uow.Commit()
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, 1, size(t, es, from))
+		assert.Equal(t, 2, size(t, es, to))
+		assert.Eventually(t, func() bool { return received() == 3 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("conflict rolls back", func(t *testing.T) {
+		// setup
+		es, received := setup(t)
+		_, err := es.Append(ctx, to, Msg{Name: "opened"})
+		assert.NoError(t, err)
+		uow := es.UnitOfWork(ctx)
+		uow.AppendExpected(from, ExpectNoStream, Msg{Name: "withdrawn"})
+		uow.AppendExpected(to, ExpectNoStream, Msg{Name: "deposited"})
+		// execute
+		err = uow.Commit()
+		// verify
+		wrongVersion := WrongExpectedVersionError{}
+		if assert.True(t, errors.As(err, &wrongVersion), "wrong expected version") {
+			assert.Equal(t, to.String(), wrongVersion.StreamId.String())
+			assert.Equal(t, 0, int(wrongVersion.Actual))
+		}
+		assert.Equal(t, 0, size(t, es, from))
+		assert.Equal(t, 1, size(t, es, to))
+		assert.Eventually(t, func() bool { return received() == 1 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("stream exists", func(t *testing.T) {
+		// setup
+		es, _ := setup(t)
+		uow := es.UnitOfWork(ctx)
+		uow.AppendExpected(from, ExpectStreamExists, Msg{Name: "withdrawn"})
+		// execute
+		err := uow.Commit()
+		// verify
+		assert.True(t, errors.As(err, &WrongExpectedVersionError{}), "wrong expected version")
+		assert.Equal(t, 0, size(t, es, from))
+	})
+}